@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync/backup"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreTarget       string
+	restorePasswordFile string
+	restoreRequest      = model.PostTeleporterRequest{}
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Manage teleporter backups of the primary",
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored backups, newest first",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf := loadBackupConfig()
+
+		backups, err := backup.NewStore(conf.Sync.Backup.Dir, backup.Retention{}).List()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to list backups")
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTIME\tFINGERPRINT\tSIZE")
+		for _, b := range backups {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", b.ID, b.Time.Local().Format(time.RFC3339), b.Fingerprint[:min(12, len(b.Fingerprint))], b.Size)
+		}
+		w.Flush()
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "Import a stored backup on a Pi-hole",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		conf := loadBackupConfig()
+
+		target, err := restorePiHole()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid target")
+		}
+
+		client := pihole.NewClient(*target, conf.Client.NewHttpClient(), conf.Client.NewTimeouts())
+		store := backup.NewStore(conf.Sync.Backup.Dir, backup.Retention{})
+		if err := store.Restore(args[0], client, &restoreRequest); err != nil {
+			log.Fatal().Err(err).Str("backup", args[0]).Msg("Failed to restore backup")
+		}
+
+		log.Info().Str("backup", args[0]).Str("target", client.String()).Msg("Backup restored")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+	backupCmd.AddCommand(backupListCmd)
+
+	backupCmd.PersistentFlags().StringVar(&envFile, "env-file", "", "Read env from `.env` file")
+	backupCmd.PersistentFlags().StringVar(&configFile, "config", "", "Read settings from a YAML or TOML `file`, env vars take precedence")
+	restoreCmd.Flags().StringVar(&envFile, "env-file", "", "Read env from `.env` file")
+	restoreCmd.Flags().StringVar(&configFile, "config", "", "Read settings from a YAML or TOML `file`, env vars take precedence")
+
+	restoreCmd.Flags().StringVar(&restoreTarget, "target", "", "`url` of the Pi-hole to restore to")
+	restoreCmd.MarkFlagRequired("target")
+	restoreCmd.Flags().StringVar(&restorePasswordFile, "password-file", "", "Read the target's password from `file`, or stdin if -, instead of PRIMARY or REPLICAS")
+
+	flags := restoreCmd.Flags()
+	flags.BoolVar(&restoreRequest.Config, "import-config", true, "Import config")
+	flags.BoolVar(&restoreRequest.DHCPLeases, "dhcp-leases", true, "Import DHCP leases")
+	flags.BoolVar(&restoreRequest.Gravity.Group, "group", true, "Import groups")
+	flags.BoolVar(&restoreRequest.Gravity.Adlist, "adlist", true, "Import adlists")
+	flags.BoolVar(&restoreRequest.Gravity.AdlistByGroup, "adlist-by-group", true, "Import adlist group assignments")
+	flags.BoolVar(&restoreRequest.Gravity.Domainlist, "domainlist", true, "Import domains")
+	flags.BoolVar(&restoreRequest.Gravity.DomainlistByGroup, "domainlist-by-group", true, "Import domain group assignments")
+	flags.BoolVar(&restoreRequest.Gravity.Client, "client", true, "Import clients")
+	flags.BoolVar(&restoreRequest.Gravity.ClientByGroup, "client-by-group", true, "Import client group assignments")
+}
+
+// restorePiHole returns the restore target with the password read from --password-file, or else with the
+// password of the matching PRIMARY or REPLICAS entry. The password is never taken from the command line.
+func restorePiHole() (*model.PiHole, error) {
+	if strings.Contains(restoreTarget, "|") {
+		return nil, fmt.Errorf("--target takes a url without password, use --password-file or PRIMARY/REPLICAS")
+	}
+
+	if restorePasswordFile == "" {
+		return config.LookupTarget(restoreTarget)
+	}
+
+	u, err := url.Parse(restoreTarget)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+
+	var password []byte
+	if restorePasswordFile == "-" {
+		password, err = io.ReadAll(os.Stdin)
+	} else {
+		password, err = os.ReadFile(restorePasswordFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read password: %w", err)
+	}
+
+	return &model.PiHole{Url: u, Password: strings.TrimSpace(string(password))}, nil
+}
+
+func loadBackupConfig() *config.Config {
+	source := &config.Source{EnvFile: envFile, ConfigFile: configFile}
+	if err := source.Apply(); err != nil {
+		log.Fatal().Err(err).Msg("Failed to load config files")
+	}
+
+	conf := config.Config{}
+	if err := conf.LoadBackup(); err != nil {
+		log.Fatal().Err(err).Msg("Failed to load config")
+	}
+	return &conf
+}
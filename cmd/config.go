@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var validateOnline bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the config",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Report every problem in the config, exits non-zero if there are any",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		problems := validateConfig()
+		if len(problems) == 0 {
+			fmt.Println("Config is valid")
+			return
+		}
+
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "- %s\n", problem)
+		}
+		fmt.Fprintf(os.Stderr, "Config has %d problem(s)\n", len(problems))
+		os.Exit(1)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+
+	configValidateCmd.Flags().StringVar(&envFile, "env-file", "", "Read env from `.env` file")
+	configValidateCmd.Flags().StringVar(&configFile, "config", "", "Read settings from a YAML or TOML `file`, env vars take precedence")
+	configValidateCmd.Flags().BoolVar(&validateOnline, "online", false, "Also authenticate to every Pi-hole, check versions and verify config keys")
+}
+
+func validateConfig() []error {
+	source := config.Source{EnvFile: envFile, ConfigFile: configFile}
+	if err := source.Apply(); err != nil {
+		return []error{err}
+	}
+
+	conf := config.Config{}
+	if problems := config.Problems(conf.Load()); len(problems) > 0 || !validateOnline {
+		// the online checks need a complete config
+		return problems
+	}
+
+	httpClient := conf.Client.NewHttpClient()
+	timeouts := conf.Client.NewTimeouts()
+
+	primary := pihole.NewClient(conf.Primary, httpClient, timeouts)
+	var replicas, sources []pihole.Client
+	for _, replica := range conf.Replicas {
+		replicas = append(replicas, pihole.NewClient(replica, httpClient, timeouts))
+	}
+	if conf.Sync.Merge != nil && conf.Sync.Merge.Enabled {
+		for _, source := range conf.Sync.Merge.Sources {
+			sources = append(sources, pihole.NewClient(source, httpClient, timeouts))
+		}
+	}
+
+	return sync.CheckOnline(primary, replicas, sources, conf.Sync)
+}
@@ -1,26 +1,37 @@
 package cmd
 
 import (
+	"errors"
+	"os"
+
 	"github.com/lovelaze/nebula-sync/internal/config"
 	"github.com/lovelaze/nebula-sync/internal/service"
+	"github.com/lovelaze/nebula-sync/internal/sync"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
 
-var envFile string
+const exitCodeDrift = 2
+
+var (
+	envFile    string
+	configFile string
+)
 
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run sync",
 	Run: func(cmd *cobra.Command, args []string) {
-		readEnvFile()
-
-		service, err := service.Init()
+		service, err := service.InitFrom(&config.Source{EnvFile: envFile, ConfigFile: configFile})
 		if err != nil {
 			log.Fatal().Err(err).Msg("Failed to initialize service")
 		}
 
 		if err = service.Run(); err != nil {
+			if errors.Is(err, sync.ErrDrift) {
+				log.Error().Msg("Drift detected")
+				os.Exit(exitCodeDrift)
+			}
 			log.Fatal().Err(err).Msg("Sync failed")
 		}
 	},
@@ -30,14 +41,5 @@ func init() {
 	rootCmd.AddCommand(runCmd)
 
 	runCmd.Flags().StringVar(&envFile, "env-file", "", "Read env from `.env` file")
-}
-
-func readEnvFile() {
-	if envFile == "" {
-		return
-	}
-
-	if err := config.LoadEnvFile(envFile); err != nil {
-		log.Fatal().Err(err).Msg("Failed to load env file")
-	}
+	runCmd.Flags().StringVar(&configFile, "config", "", "Read settings from a YAML or TOML `file`, env vars take precedence")
 }
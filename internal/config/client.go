@@ -4,14 +4,49 @@ import (
 	"crypto/tls"
 	"fmt"
 	"github.com/kelseyhightower/envconfig"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/sync/retry"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
 type Client struct {
-	SkipTLSVerification bool  `default:"false" envconfig:"CLIENT_SKIP_TLS_VERIFICATION"`
-	RetryDelay          int64 `default:"1" envconfig:"CLIENT_RETRY_DELAY_SECONDS"`
-	Timeout             int64 `default:"20" envconfig:"CLIENT_TIMEOUT_SECONDS"`
+	SkipTLSVerification bool           `default:"false" envconfig:"CLIENT_SKIP_TLS_VERIFICATION"`
+	RetryDelay          int64          `default:"1" envconfig:"CLIENT_RETRY_DELAY_SECONDS"`
+	RetryBackoff        string         `default:"fixed" envconfig:"CLIENT_RETRY_BACKOFF"`
+	RetryMaxDelay       int64          `default:"60" envconfig:"CLIENT_RETRY_MAX_DELAY_SECONDS"`
+	RetryJitter         bool           `default:"false" envconfig:"CLIENT_RETRY_JITTER"`
+	RetryDeadline       int64          `default:"0" envconfig:"CLIENT_RETRY_DEADLINE_SECONDS"`
+	RetryPolicies       RetryOverrides `envconfig:"CLIENT_RETRY_POLICIES"`
+	Timeout             int64          `default:"20" envconfig:"CLIENT_TIMEOUT_SECONDS"`
+	ConnectTimeout      int64          `default:"10" envconfig:"CLIENT_CONNECT_TIMEOUT_SECONDS"`
+	TLSTimeout          int64          `default:"10" envconfig:"CLIENT_TLS_HANDSHAKE_TIMEOUT_SECONDS"`
+	HeaderTimeout       int64          `default:"0" envconfig:"CLIENT_RESPONSE_HEADER_TIMEOUT_SECONDS"`
+	AuthTimeout         int64          `default:"20" envconfig:"CLIENT_AUTH_TIMEOUT_SECONDS"`
+	ConfigTimeout       int64          `default:"30" envconfig:"CLIENT_CONFIG_TIMEOUT_SECONDS"`
+	TeleporterTimeout   int64          `default:"0" envconfig:"CLIENT_TELEPORTER_TIMEOUT_SECONDS"`
+	GravityTimeout      int64          `default:"0" envconfig:"CLIENT_GRAVITY_TIMEOUT_SECONDS"`
+	IdleTimeout         int64          `default:"60" envconfig:"CLIENT_IDLE_TIMEOUT_SECONDS"`
+}
+
+// RetryOverrides are retry policy overrides separated by semicolons or newlines, since overrides contain commas.
+type RetryOverrides []*retry.Override
+
+func (overrides *RetryOverrides) Decode(value string) error {
+	*overrides = nil
+	for _, rule := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == '\n' }) {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		override, err := retry.ParseOverride(rule)
+		if err != nil {
+			return err
+		}
+		*overrides = append(*overrides, override)
+	}
+	return nil
 }
 
 func (c *Config) loadClient() error {
@@ -21,20 +56,61 @@ func (c *Config) loadClient() error {
 		return fmt.Errorf("client env vars: %w", err)
 	}
 
+	if _, err := client.NewRetryPolicies(); err != nil {
+		return fmt.Errorf("client retry: %w", err)
+	}
+
 	c.Client = &client
 
 	return nil
 }
 
+// NewHttpClient returns a client with the connect, TLS handshake and response header timeouts.
+// It has no total timeout, requests are limited per operation by NewTimeouts.
 func (settings *Client) NewHttpClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   time.Duration(settings.ConnectTimeout) * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
 	return &http.Client{
-		Timeout: time.Duration(settings.Timeout) * time.Second,
 		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: settings.SkipTLSVerification},
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			TLSClientConfig:       &tls.Config{InsecureSkipVerify: settings.SkipTLSVerification},
+			TLSHandshakeTimeout:   time.Duration(settings.TLSTimeout) * time.Second,
+			ResponseHeaderTimeout: time.Duration(settings.HeaderTimeout) * time.Second,
 		},
 	}
 }
 
+// NewTimeouts returns the timeouts of the Pi-hole operations.
+func (settings *Client) NewTimeouts() pihole.Timeouts {
+	return pihole.Timeouts{
+		Auth:       time.Duration(settings.AuthTimeout) * time.Second,
+		Config:     time.Duration(settings.ConfigTimeout) * time.Second,
+		Teleporter: time.Duration(settings.TeleporterTimeout) * time.Second,
+		Gravity:    time.Duration(settings.GravityTimeout) * time.Second,
+		Default:    time.Duration(settings.Timeout) * time.Second,
+		Idle:       time.Duration(settings.IdleTimeout) * time.Second,
+	}
+}
+
+// NewRetryPolicies returns the retry policies of the replica operations.
+func (settings *Client) NewRetryPolicies() (*retry.Policies, error) {
+	backoff, err := retry.ParseBackoff(settings.RetryBackoff)
+	if err != nil {
+		return nil, err
+	}
+
+	return retry.NewPolicies(retry.Policy{
+		Delay:    time.Duration(settings.RetryDelay) * time.Second,
+		MaxDelay: time.Duration(settings.RetryMaxDelay) * time.Second,
+		Backoff:  backoff,
+		Jitter:   settings.RetryJitter,
+		Deadline: time.Duration(settings.RetryDeadline) * time.Second,
+	}, settings.RetryPolicies), nil
+}
+
 func (c *Client) String() string {
 	return fmt.Sprintf("%+v", *c)
 }
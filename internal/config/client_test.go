@@ -1,9 +1,12 @@
 package config
 
 import (
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/sync/retry"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestConfig_LoadClient(t *testing.T) {
@@ -20,3 +23,66 @@ func TestConfig_LoadClient(t *testing.T) {
 	assert.Equal(t, int64(45), conf.Client.Timeout)
 	assert.Equal(t, int64(5), conf.Client.RetryDelay)
 }
+
+func TestConfig_LoadClient_timeouts(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("CLIENT_TIMEOUT_SECONDS", "15")
+	t.Setenv("CLIENT_AUTH_TIMEOUT_SECONDS", "5")
+	t.Setenv("CLIENT_GRAVITY_TIMEOUT_SECONDS", "600")
+	t.Setenv("CLIENT_IDLE_TIMEOUT_SECONDS", "90")
+
+	require.NoError(t, conf.loadClient())
+
+	assert.Equal(t, pihole.Timeouts{
+		Auth:       5 * time.Second,
+		Config:     30 * time.Second,
+		Teleporter: 0,
+		Gravity:    10 * time.Minute,
+		Default:    15 * time.Second,
+		Idle:       90 * time.Second,
+	}, conf.Client.NewTimeouts())
+	assert.Zero(t, conf.Client.NewHttpClient().Timeout)
+}
+
+type replicaURL string
+
+func (u replicaURL) String() string { return string(u) }
+
+func TestConfig_LoadClient_retry(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("CLIENT_RETRY_DELAY_SECONDS", "2")
+	t.Setenv("CLIENT_RETRY_BACKOFF", "exponential")
+	t.Setenv("CLIENT_RETRY_MAX_DELAY_SECONDS", "30")
+	t.Setenv("CLIENT_RETRY_JITTER", "true")
+	t.Setenv("CLIENT_RETRY_DEADLINE_SECONDS", "120")
+	t.Setenv("CLIENT_RETRY_POLICIES", "teleporter=attempts:10;ph2@*=deadline:5m")
+
+	require.NoError(t, conf.loadClient())
+	require.Len(t, conf.Client.RetryPolicies, 2)
+
+	policies, err := conf.Client.NewRetryPolicies()
+	require.NoError(t, err)
+
+	assert.Equal(t, retry.Policy{
+		Attempts: 10,
+		Delay:    2 * time.Second,
+		MaxDelay: 30 * time.Second,
+		Backoff:  retry.Exponential,
+		Jitter:   true,
+		Deadline: 2 * time.Minute,
+	}, policies.For(retry.Teleporter, replicaURL("http://ph1.lan")))
+	assert.Equal(t, 5*time.Minute, policies.For(retry.Auth, replicaURL("http://ph2.lan")).Deadline)
+}
+
+func TestConfig_LoadClient_retryInvalid(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("CLIENT_RETRY_BACKOFF", "linear")
+	assert.Error(t, conf.loadClient())
+
+	t.Setenv("CLIENT_RETRY_BACKOFF", "fixed")
+	t.Setenv("CLIENT_RETRY_POLICIES", "teleporter=tries:10")
+	assert.Error(t, conf.loadClient())
+}
@@ -1,11 +1,19 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync/exclude"
 	"github.com/lovelaze/nebula-sync/internal/sync/filter"
+	"github.com/robfig/cron/v3"
 )
 
 type Config struct {
@@ -19,109 +27,470 @@ type Sync struct {
 	FullSync        bool    `required:"true" envconfig:"FULL_SYNC"`
 	Cron            *string `envconfig:"CRON"`
 	RunGravity      bool    `default:"false" envconfig:"RUN_GRAVITY"`
+	DriftDetection  bool    `default:"false" envconfig:"DRIFT_DETECTION"`
+	DriftStatePath  string  `default:"" envconfig:"DRIFT_STATE_PATH"`
 	GravitySettings *GravitySettings
+	GravityRun      *GravityRunSettings
+	Pipeline        *PipelineSettings
+	Rolling         *RollingSettings
+	Canary          *CanarySettings
+	Breaker         *BreakerSettings
+	Rollback        *RollbackSettings
+	Verify          *VerifySettings
+	Watch           *WatchSettings
+	Reload          *ReloadSettings
+	Journal         *JournalSettings
+	Backup          *BackupSettings
+	Merge           *MergeSettings
+	Rewrites        RewriteRules `envconfig:"SYNC_CONFIG_REWRITE"`
+	Validation      *ValidationSettings
+	Exclude         *ExcludeSettings
 	ConfigSettings  *ConfigSettings  `ignored:"true"`
 	WebhookSettings *WebhookSettings `ignored:"true"`
 }
 
 type GravitySettings struct {
-	DHCPLeases        bool `default:"false" envconfig:"SYNC_GRAVITY_DHCP_LEASES"`
-	Group             bool `default:"false" envconfig:"SYNC_GRAVITY_GROUP"`
-	Adlist            bool `default:"false" envconfig:"SYNC_GRAVITY_AD_LIST"`
-	AdlistByGroup     bool `default:"false" envconfig:"SYNC_GRAVITY_AD_LIST_BY_GROUP"`
-	Domainlist        bool `default:"false" envconfig:"SYNC_GRAVITY_DOMAIN_LIST"`
-	DomainlistByGroup bool `default:"false" envconfig:"SYNC_GRAVITY_DOMAIN_LIST_BY_GROUP"`
-	Client            bool `default:"false" envconfig:"SYNC_GRAVITY_CLIENT"`
-	ClientByGroup     bool `default:"false" envconfig:"SYNC_GRAVITY_CLIENT_BY_GROUP"`
+	DHCPLeases        bool     `default:"false" envconfig:"SYNC_GRAVITY_DHCP_LEASES"`
+	Group             bool     `default:"false" envconfig:"SYNC_GRAVITY_GROUP"`
+	Adlist            bool     `default:"false" envconfig:"SYNC_GRAVITY_AD_LIST"`
+	AdlistByGroup     bool     `default:"false" envconfig:"SYNC_GRAVITY_AD_LIST_BY_GROUP"`
+	Domainlist        bool     `default:"false" envconfig:"SYNC_GRAVITY_DOMAIN_LIST"`
+	DomainlistByGroup bool     `default:"false" envconfig:"SYNC_GRAVITY_DOMAIN_LIST_BY_GROUP"`
+	Client            bool     `default:"false" envconfig:"SYNC_GRAVITY_CLIENT"`
+	ClientByGroup     bool     `default:"false" envconfig:"SYNC_GRAVITY_CLIENT_BY_GROUP"`
+	Groups            []string `envconfig:"SYNC_GRAVITY_GROUPS"`
 }
 
-type ConfigSettings struct {
-	DNS       *ConfigSetting
-	DHCP      *ConfigSetting
-	NTP       *ConfigSetting
-	Resolver  *ConfigSetting
-	Database  *ConfigSetting
-	Webserver *ConfigSetting
-	Files     *ConfigSetting
-	Misc      *ConfigSetting
-	Debug     *ConfigSetting
+func (gs *GravitySettings) Validate() error {
+	if len(gs.Groups) == 0 {
+		return nil
+	}
+
+	if gs.Group || gs.Adlist || gs.AdlistByGroup || gs.Domainlist || gs.DomainlistByGroup || gs.Client || gs.ClientByGroup {
+		return fmt.Errorf("SYNC_GRAVITY_GROUPS cannot be combined with teleporter gravity table sync")
+	}
+
+	return nil
 }
 
-type RawConfigSettings struct {
-	DNS             bool     `default:"false" envconfig:"SYNC_CONFIG_DNS"`
-	DNSInclude      []string `envconfig:"SYNC_CONFIG_DNS_INCLUDE"`
-	DNSExclude      []string `envconfig:"SYNC_CONFIG_DNS_EXCLUDE"`
-	DHCP            bool     `default:"false" envconfig:"SYNC_CONFIG_DHCP"`
-	DHCPInclude     []string `envconfig:"SYNC_CONFIG_DHCP_INCLUDE"`
-	DHCPExclude     []string `envconfig:"SYNC_CONFIG_DHCP_EXCLUDE"`
-	NTP             bool     `default:"false" envconfig:"SYNC_CONFIG_NTP"`
-	NTPInclude      []string `envconfig:"SYNC_CONFIG_NTP_INCLUDE"`
-	NTPExclude      []string `envconfig:"SYNC_CONFIG_NTP_EXCLUDE"`
-	Resolver        bool     `default:"false" envconfig:"SYNC_CONFIG_RESOLVER"`
-	ResolverInclude []string `envconfig:"SYNC_CONFIG_RESOLVER_INCLUDE"`
-	ResolverExclude []string `envconfig:"SYNC_CONFIG_RESOLVER_EXCLUDE"`
-	Database        bool     `default:"false" envconfig:"SYNC_CONFIG_DATABASE"`
-	DatabaseInclude []string `envconfig:"SYNC_CONFIG_DATABASE_INCLUDE"`
-	DatabaseExclude []string `envconfig:"SYNC_CONFIG_DATABASE_EXCLUDE"`
-	Webserver       bool     `default:"false" ignored:"true"` // ignore for now
-	Files           bool     `default:"false" ignored:"true"` // ignore for now
-	Misc            bool     `default:"false" envconfig:"SYNC_CONFIG_MISC"`
-	MiscInclude     []string `envconfig:"SYNC_CONFIG_MISC_INCLUDE"`
-	MiscExclude     []string `envconfig:"SYNC_CONFIG_MISC_EXCLUDE"`
-	Debug           bool     `default:"false" envconfig:"SYNC_CONFIG_DEBUG"`
-	DebugInclude    []string `envconfig:"SYNC_CONFIG_DEBUG_INCLUDE"`
-	DebugExclude    []string `envconfig:"SYNC_CONFIG_DEBUG_EXCLUDE"`
+const (
+	GravityRunAlways  = "always"
+	GravityRunChanged = "changed"
+)
+
+type GravityRunSettings struct {
+	Mode    string `default:"always" envconfig:"RUN_GRAVITY_MODE"`
+	MaxAge  int64  `default:"0" envconfig:"RUN_GRAVITY_MAX_AGE_HOURS"`
+	Primary bool   `default:"false" envconfig:"RUN_GRAVITY_PRIMARY"`
 }
 
-func (raw *RawConfigSettings) Validate() error {
-	exclusive := func(name string, include, exclude []string) error {
-		if include != nil && exclude != nil {
-			return fmt.Errorf("%s: INCLUDE/EXCLUDE must be mutually exclusive", name)
+func (gs *GravityRunSettings) Validate() error {
+	if gs.Mode != GravityRunAlways && gs.Mode != GravityRunChanged {
+		return fmt.Errorf("RUN_GRAVITY_MODE must be %q or %q, got %q", GravityRunAlways, GravityRunChanged, gs.Mode)
+	}
+	return nil
+}
+
+// Conditional reports whether gravity should only run on replicas whose adlists changed or whose gravity is too old.
+func (gs *GravityRunSettings) Conditional() bool {
+	return gs != nil && gs.Mode == GravityRunChanged
+}
+
+type PipelineSettings struct {
+	Steps   []string `envconfig:"SYNC_STEPS"`
+	Disable []string `envconfig:"SYNC_STEPS_DISABLE"`
+}
+
+type RollingSettings struct {
+	Enabled      bool     `default:"false" envconfig:"ROLLING_ENABLED"`
+	BatchSize    int      `default:"1" envconfig:"ROLLING_BATCH_SIZE"`
+	Pause        int64    `default:"0" envconfig:"ROLLING_PAUSE_SECONDS"`
+	ReadyTimeout int64    `default:"120" envconfig:"ROLLING_READY_TIMEOUT_SECONDS"`
+	Order        []string `envconfig:"ROLLING_ORDER"`
+}
+
+func (rs *RollingSettings) Validate() error {
+	if rs.Enabled && rs.BatchSize < 1 {
+		return fmt.Errorf("ROLLING_BATCH_SIZE must be at least 1, got %d", rs.BatchSize)
+	}
+	return nil
+}
+
+// BreakerSettings quarantine a replica after Threshold consecutive failed runs. A quarantined replica is
+// skipped until it is probed again after Backoff seconds, doubling after every failed probe up to MaxBackoff.
+type BreakerSettings struct {
+	Enabled    bool  `default:"false" envconfig:"BREAKER_ENABLED"`
+	Threshold  int   `default:"3" envconfig:"BREAKER_THRESHOLD"`
+	Backoff    int64 `default:"300" envconfig:"BREAKER_BACKOFF_SECONDS"`
+	MaxBackoff int64 `default:"21600" envconfig:"BREAKER_MAX_BACKOFF_SECONDS"`
+}
+
+func (bs *BreakerSettings) Validate() error {
+	if !bs.Enabled {
+		return nil
+	}
+
+	if bs.Threshold < 1 {
+		return fmt.Errorf("BREAKER_THRESHOLD must be at least 1, got %d", bs.Threshold)
+	}
+
+	if bs.Backoff < 1 || bs.MaxBackoff < bs.Backoff {
+		return fmt.Errorf("BREAKER_BACKOFF_SECONDS must be positive and at most BREAKER_MAX_BACKOFF_SECONDS")
+	}
+
+	return nil
+}
+
+type CanarySettings struct {
+	Enabled      bool     `default:"false" envconfig:"CANARY_ENABLED"`
+	Replica      string   `default:"" envconfig:"CANARY_REPLICA"`
+	DNSServer    string   `default:"" envconfig:"CANARY_DNS_SERVER"`
+	AllowDomains []string `envconfig:"CANARY_PROBE_ALLOW"`
+	BlockDomains []string `envconfig:"CANARY_PROBE_BLOCK"`
+	LocalRecords []string `envconfig:"CANARY_PROBE_LOCAL"`
+	Timeout      int64    `default:"5" envconfig:"CANARY_PROBE_TIMEOUT_SECONDS"`
+}
+
+func (cs *CanarySettings) Validate() error {
+	if !cs.Enabled {
+		return nil
+	}
+
+	if cs.Replica == "" {
+		return fmt.Errorf("CANARY_REPLICA is required when canary is enabled")
+	}
+
+	if len(cs.AllowDomains) == 0 && len(cs.BlockDomains) == 0 && len(cs.LocalRecords) == 0 {
+		return fmt.Errorf("at least one of CANARY_PROBE_ALLOW, CANARY_PROBE_BLOCK or CANARY_PROBE_LOCAL is required when canary is enabled")
+	}
+
+	_, err := cs.LocalProbes()
+	return err
+}
+
+// LocalProbes parses the CANARY_PROBE_LOCAL records, written as `domain=ip`, into a map of domain to expected ips.
+func (cs *CanarySettings) LocalProbes() (map[string][]string, error) {
+	records := make(map[string][]string)
+	for _, record := range cs.LocalRecords {
+		domain, ip, found := strings.Cut(record, "=")
+		if !found || domain == "" || ip == "" {
+			return nil, fmt.Errorf("invalid local record %q, expected domain=ip", record)
 		}
+		records[domain] = append(records[domain], ip)
+	}
+	return records, nil
+}
+
+type RollbackSettings struct {
+	Enabled           bool   `default:"false" envconfig:"ROLLBACK_ENABLED"`
+	SnapshotDir       string `default:"" envconfig:"ROLLBACK_SNAPSHOT_DIR"`
+	SnapshotRetention int64  `default:"168" envconfig:"ROLLBACK_SNAPSHOT_RETENTION_HOURS"`
+}
+
+func (rs *RollbackSettings) Validate() error {
+	if rs.Enabled && rs.SnapshotDir == "" {
+		return fmt.Errorf("ROLLBACK_SNAPSHOT_DIR is required when rollback is enabled")
+	}
+	if rs.SnapshotRetention < 0 {
+		return fmt.Errorf("ROLLBACK_SNAPSHOT_RETENTION_HOURS must not be negative, got %d", rs.SnapshotRetention)
+	}
+	return nil
+}
+
+type VerifySettings struct {
+	Enabled bool `default:"false" envconfig:"VERIFY_ENABLED"`
+	Strict  bool `default:"false" envconfig:"VERIFY_STRICT"`
+}
+
+// ValidationSettings control the startup check of the configured config keys against the primary's config.
+type ValidationSettings struct {
+	Enabled bool `default:"true" envconfig:"SYNC_CONFIG_VALIDATE"`
+	Strict  bool `default:"false" envconfig:"SYNC_CONFIG_VALIDATE_STRICT"`
+}
+
+type WatchSettings struct {
+	Enabled  bool  `default:"false" envconfig:"WATCH_ENABLED"`
+	Interval int64 `default:"10" envconfig:"WATCH_INTERVAL_SECONDS"`
+	Debounce int64 `default:"5" envconfig:"WATCH_DEBOUNCE_SECONDS"`
+}
+
+func (ws *WatchSettings) Validate() error {
+	if !ws.Enabled {
 		return nil
 	}
+	if ws.Interval < 1 {
+		return fmt.Errorf("WATCH_INTERVAL_SECONDS must be at least 1, got %d", ws.Interval)
+	}
+	if ws.Debounce < 0 {
+		return fmt.Errorf("WATCH_DEBOUNCE_SECONDS must not be negative, got %d", ws.Debounce)
+	}
+	return nil
+}
 
-	if err := exclusive("dns", raw.DNSInclude, raw.DNSExclude); err != nil {
-		return err
+// ReloadSettings control reloading the config when the env or config file changes. The config is always
+// reloaded on SIGHUP.
+type ReloadSettings struct {
+	WatchFiles bool  `default:"false" envconfig:"RELOAD_WATCH_FILES"`
+	Interval   int64 `default:"10" envconfig:"RELOAD_INTERVAL_SECONDS"`
+}
+
+func (rs *ReloadSettings) Validate() error {
+	if rs.WatchFiles && rs.Interval < 1 {
+		return fmt.Errorf("RELOAD_INTERVAL_SECONDS must be at least 1, got %d", rs.Interval)
 	}
-	if err := exclusive("dhcp", raw.DHCPInclude, raw.DHCPExclude); err != nil {
-		return err
+	return nil
+}
+
+type JournalSettings struct {
+	Enabled   bool   `default:"false" envconfig:"JOURNAL_ENABLED"`
+	Path      string `default:"" envconfig:"JOURNAL_PATH"`
+	Retention int64  `default:"720" envconfig:"JOURNAL_RETENTION_HOURS"`
+}
+
+func (js *JournalSettings) Validate() error {
+	if js.Enabled && js.Path == "" {
+		return fmt.Errorf("JOURNAL_PATH is required when the journal is enabled")
 	}
-	if err := exclusive("ntp", raw.NTPInclude, raw.NTPExclude); err != nil {
-		return err
+	if js.Retention < 0 {
+		return fmt.Errorf("JOURNAL_RETENTION_HOURS must not be negative, got %d", js.Retention)
 	}
-	if err := exclusive("resolver", raw.ResolverInclude, raw.ResolverExclude); err != nil {
-		return err
+	return nil
+}
+
+type BackupSettings struct {
+	Enabled     bool   `default:"false" envconfig:"BACKUP_ENABLED"`
+	Dir         string `default:"" envconfig:"BACKUP_DIR"`
+	KeepLast    int    `default:"10" envconfig:"BACKUP_KEEP_LAST"`
+	KeepDaily   int    `default:"7" envconfig:"BACKUP_KEEP_DAILY"`
+	KeepWeekly  int    `default:"4" envconfig:"BACKUP_KEEP_WEEKLY"`
+	KeepMonthly int    `default:"6" envconfig:"BACKUP_KEEP_MONTHLY"`
+}
+
+func (bs *BackupSettings) Validate() error {
+	if bs.Enabled && bs.Dir == "" {
+		return fmt.Errorf("BACKUP_DIR is required when backups are enabled")
 	}
-	if err := exclusive("database", raw.DatabaseInclude, raw.DatabaseExclude); err != nil {
-		return err
+	if bs.KeepLast < 0 || bs.KeepDaily < 0 || bs.KeepWeekly < 0 || bs.KeepMonthly < 0 {
+		return fmt.Errorf("BACKUP_KEEP_* must not be negative")
 	}
-	if err := exclusive("misc", raw.MiscInclude, raw.MiscExclude); err != nil {
-		return err
+	return nil
+}
+
+const (
+	MergeDenyWins  = "deny"
+	MergeAllowWins = "allow"
+	MergePriority  = "priority"
+)
+
+type MergeSettings struct {
+	Enabled  bool           `default:"false" envconfig:"MERGE_ENABLED"`
+	Sources  []model.PiHole `envconfig:"MERGE_SOURCES"`
+	Conflict string         `default:"deny" envconfig:"MERGE_CONFLICT"`
+}
+
+func (ms *MergeSettings) Validate() error {
+	if !ms.Enabled {
+		return nil
 	}
-	if err := exclusive("debug", raw.DebugInclude, raw.DebugExclude); err != nil {
-		return err
+	if len(ms.Sources) == 0 {
+		return fmt.Errorf("MERGE_SOURCES is required when merging")
 	}
+	switch ms.Conflict {
+	case MergeDenyWins, MergeAllowWins, MergePriority:
+		return nil
+	default:
+		return fmt.Errorf("invalid MERGE_CONFLICT %q, expected one of %s,%s,%s", ms.Conflict, MergeDenyWins, MergeAllowWins, MergePriority)
+	}
+}
 
+// RewriteRules are config rewrite rules separated by semicolons or newlines, since rules may contain commas.
+type RewriteRules []*filter.Rewrite
+
+func (rules *RewriteRules) Decode(value string) error {
+	*rules = nil
+	for _, rule := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == '\n' }) {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		rewrite, err := filter.ParseRewrite(rule)
+		if err != nil {
+			return err
+		}
+		*rules = append(*rules, rewrite)
+	}
+	return nil
+}
+
+// ElementFilters are config array element filters separated by semicolons or newlines, since filters may
+// contain commas.
+type ElementFilters []*filter.ElementFilter
+
+func (filters *ElementFilters) Decode(value string) error {
+	*filters = nil
+	for _, rule := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == '\n' }) {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		ef, err := filter.ParseElementFilter(rule)
+		if err != nil {
+			return err
+		}
+		*filters = append(*filters, ef)
+	}
 	return nil
 }
 
+type ExcludeSettings struct {
+	Domains    []string `envconfig:"SYNC_EXCLUDE_DOMAINS"`
+	Adlists    []string `envconfig:"SYNC_EXCLUDE_ADLISTS"`
+	CommentTag string   `default:"" envconfig:"SYNC_EXCLUDE_COMMENT_TAG"`
+	Groups     []string `envconfig:"SYNC_EXCLUDE_GROUPS"`
+}
+
+func (es *ExcludeSettings) Rules() (*exclude.Rules, error) {
+	if es == nil {
+		return nil, nil
+	}
+	return exclude.NewRules(es.Domains, es.Adlists, es.CommentTag, es.Groups)
+}
+
+// ConfigSettings hold the config sync settings by config section, e.g. "dns". Sections without their own
+// setting use Default, and are not synced if it is nil.
+type ConfigSettings struct {
+	Sections map[string]*ConfigSetting
+	Default  *ConfigSetting
+	Elements []*filter.ElementFilter
+}
+
+// Setting returns the setting of the section.
+func (cs *ConfigSettings) Setting(section string) *ConfigSetting {
+	if setting, ok := cs.Sections[section]; ok {
+		return setting
+	}
+	if cs.Default != nil {
+		return cs.Default
+	}
+	return NewConfigSetting(false, nil, nil)
+}
+
+// configSectionEnv matches the SYNC_CONFIG_<SECTION>, SYNC_CONFIG_<SECTION>_INCLUDE,
+// SYNC_CONFIG_<SECTION>_EXCLUDE and SYNC_CONFIG_<SECTION>_RULES env vars.
+var configSectionEnv = regexp.MustCompile(`^SYNC_CONFIG_([A-Z0-9]+)(_INCLUDE|_EXCLUDE|_RULES)?$`)
+
+// reservedConfigSettings are SYNC_CONFIG_* settings that are not config sections.
+var reservedConfigSettings = []string{"REWRITE", "ELEMENTS", "VALIDATE"}
+
+type RawConfigSection struct {
+	Enabled bool
+	Include []string
+	Exclude []string
+	Rules   []string
+}
+
+type RawConfigSettings struct {
+	Sections map[string]*RawConfigSection `ignored:"true"`
+	Elements ElementFilters               `envconfig:"SYNC_CONFIG_ELEMENTS"`
+}
+
+// loadSections reads the section settings of any config section from env vars of the form
+// SYNC_CONFIG_<SECTION>[_INCLUDE|_EXCLUDE|_RULES], e.g. SYNC_CONFIG_WEBSERVER or SYNC_CONFIG_DNS_INCLUDE.
+func (raw *RawConfigSettings) loadSections(environ []string) error {
+	raw.Sections = make(map[string]*RawConfigSection)
+	for _, env := range environ {
+		key, value, _ := strings.Cut(env, "=")
+		match := configSectionEnv.FindStringSubmatch(key)
+		if match == nil || slices.Contains(reservedConfigSettings, match[1]) {
+			continue
+		}
+
+		name := strings.ToLower(match[1])
+		section, ok := raw.Sections[name]
+		if !ok {
+			section = &RawConfigSection{}
+			raw.Sections[name] = section
+		}
+
+		switch match[2] {
+		case "":
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			section.Enabled = enabled
+		case "_INCLUDE":
+			section.Include = splitList(value)
+		case "_EXCLUDE":
+			section.Exclude = splitList(value)
+		case "_RULES":
+			section.Rules = splitList(value)
+		}
+	}
+	return nil
+}
+
+// splitList splits a list env var like envconfig does for slices.
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// Validate returns the invalid selectors and rules of every section, the selectors a section both includes and
+// excludes, and sections that combine rules with include or exclude selectors.
+func (raw *RawConfigSettings) Validate() error {
+	names := make([]string, 0, len(raw.Sections))
+	for name := range raw.Sections {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var errs []error
+	for _, name := range names {
+		section := raw.Sections[name]
+		if section.Rules != nil {
+			if section.Include != nil || section.Exclude != nil {
+				errs = append(errs, fmt.Errorf("%s: rules cannot be combined with include or exclude selectors", name))
+			} else if _, err := filter.ParseRules(section.Rules); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+			continue
+		}
+
+		if _, err := filter.NewRules(section.Include, section.Exclude); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		for _, include := range section.Include {
+			if slices.ContainsFunc(section.Exclude, func(exclude string) bool {
+				return strings.TrimSpace(exclude) == strings.TrimSpace(include)
+			}) {
+				errs = append(errs, fmt.Errorf("%s: %s is both included and excluded", name, strings.TrimSpace(include)))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func (raw *RawConfigSettings) Parse() (*ConfigSettings, error) {
 	if err := raw.Validate(); err != nil {
 		return nil, err
 	}
 
-	return &ConfigSettings{
-		DNS:       NewConfigSetting(raw.DNS, raw.DNSInclude, raw.DNSExclude),
-		DHCP:      NewConfigSetting(raw.DHCP, raw.DHCPInclude, raw.DHCPExclude),
-		NTP:       NewConfigSetting(raw.NTP, raw.NTPInclude, raw.NTPExclude),
-		Resolver:  NewConfigSetting(raw.Resolver, raw.ResolverInclude, raw.ResolverExclude),
-		Database:  NewConfigSetting(raw.Database, raw.DatabaseInclude, raw.DatabaseExclude),
-		Webserver: NewConfigSetting(raw.Webserver, nil, nil),
-		Files:     NewConfigSetting(raw.Files, nil, nil),
-		Misc:      NewConfigSetting(raw.Misc, raw.MiscInclude, raw.MiscExclude),
-		Debug:     NewConfigSetting(raw.Debug, raw.DebugInclude, raw.DebugExclude),
-	}, nil
+	settings := &ConfigSettings{
+		Sections: make(map[string]*ConfigSetting, len(raw.Sections)),
+		Elements: raw.Elements,
+	}
+	for name, section := range raw.Sections {
+		setting := NewConfigSetting(section.Enabled, section.Include, section.Exclude)
+		if section.Rules != nil {
+			setting.Filter = &ConfigFilter{Ordered: section.Rules}
+		}
+		settings.Sections[name] = setting
+	}
+	return settings, nil
 }
 
 type ConfigSetting struct {
@@ -129,27 +498,27 @@ type ConfigSetting struct {
 	Filter  *ConfigFilter
 }
 
+// ConfigFilter holds the include and exclude selectors of a config section, or its ordered rules. Exclude
+// selectors take precedence over include selectors, see filter.NewRules, while ordered rules are applied in the
+// given order, see filter.ParseRules.
 type ConfigFilter struct {
-	Type filter.Type
-	Keys []string
+	Include []string
+	Exclude []string
+	Ordered []string
 }
 
-func newConfigFilter(filterType filter.Type, keys []string) *ConfigFilter {
-	return &ConfigFilter{
-		Type: filterType,
-		Keys: keys,
+func (cf *ConfigFilter) Rules() (filter.Rules, error) {
+	if cf.Ordered != nil {
+		return filter.ParseRules(cf.Ordered)
 	}
+	return filter.NewRules(cf.Include, cf.Exclude)
 }
 
 func NewConfigSetting(enabled bool, included, excluded []string) *ConfigSetting {
 	var configFilter *ConfigFilter
 
-	if included != nil {
-		configFilter = newConfigFilter(filter.Include, included)
-	} else if excluded != nil {
-		configFilter = newConfigFilter(filter.Exclude, excluded)
-	} else {
-		configFilter = nil
+	if included != nil || excluded != nil {
+		configFilter = &ConfigFilter{Include: included, Exclude: excluded}
 	}
 
 	return &ConfigSetting{
@@ -158,23 +527,68 @@ func NewConfigSetting(enabled bool, included, excluded []string) *ConfigSetting
 	}
 }
 
+// Load loads the config from env vars. It returns every problem found, joined with errors.Join, see Problems.
 func (c *Config) Load() error {
+	var errs []error
 	if err := c.loadTargets(); err != nil {
-		return err
+		errs = append(errs, err)
 	}
 
 	if err := c.loadClient(); err != nil {
-		return err
+		errs = append(errs, err)
 	}
 
 	if err := c.loadSync(); err != nil {
-		return err
+		errs = append(errs, err)
+		// the webhook settings are checked even if the sync settings are invalid
+		c.Sync = &Sync{}
 	}
 
 	if err := c.loadWebhookSettings(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Problems splits an error returned by Load into the problems it joins.
+func Problems(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+
+	var problems []error
+	for _, err := range joined.Unwrap() {
+		problems = append(problems, Problems(err)...)
+	}
+	return problems
+}
+
+// LoadBackup loads the client and backup settings used by the backup commands, which need no targets.
+func (c *Config) LoadBackup() error {
+	if err := c.loadClient(); err != nil {
 		return err
 	}
 
+	backup := BackupSettings{}
+	if err := envconfig.Process("", &backup); err != nil {
+		return fmt.Errorf("backup env vars: %w", err)
+	}
+
+	if backup.Dir == "" {
+		return fmt.Errorf("missing required env: BACKUP_DIR")
+	}
+
+	if err := backup.Validate(); err != nil {
+		return fmt.Errorf("backup settings: %w", err)
+	}
+
+	c.Sync = &Sync{Backup: &backup}
 	return nil
 }
 
@@ -184,8 +598,39 @@ func (c *Config) loadSync() error {
 		return fmt.Errorf("sync env vars: %w", err)
 	}
 
-	if err := sync.loadConfigSettings(); err != nil {
-		return fmt.Errorf("load config settings: %w", err)
+	var errs []error
+	check := func(err error, context string) {
+		for _, problem := range Problems(err) {
+			errs = append(errs, fmt.Errorf("%s: %w", context, problem))
+		}
+	}
+
+	if sync.Cron != nil {
+		_, err := cron.ParseStandard(*sync.Cron)
+		check(err, "CRON")
+	}
+	check(sync.GravitySettings.Validate(), "gravity settings")
+	check(sync.GravityRun.Validate(), "gravity run settings")
+	check(sync.Rolling.Validate(), "rolling settings")
+	check(sync.Canary.Validate(), "canary settings")
+	check(sync.Breaker.Validate(), "breaker settings")
+	check(sync.Watch.Validate(), "watch settings")
+	check(sync.Reload.Validate(), "reload settings")
+	check(sync.Rollback.Validate(), "rollback settings")
+	check(sync.Journal.Validate(), "journal settings")
+	check(sync.Backup.Validate(), "backup settings")
+	check(sync.Merge.Validate(), "merge settings")
+
+	if sync.Merge.Enabled && len(sync.GravitySettings.Groups) > 0 {
+		errs = append(errs, fmt.Errorf("merge settings: MERGE_ENABLED cannot be combined with SYNC_GRAVITY_GROUPS"))
+	}
+
+	_, err := sync.Exclude.Rules()
+	check(err, "exclude settings")
+	check(sync.loadConfigSettings(), "load config settings")
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
 	}
 
 	c.Sync = &sync
@@ -198,6 +643,9 @@ func (sync *Sync) loadConfigSettings() error {
 	if err := envconfig.Process("", &raw); err != nil {
 		return fmt.Errorf("config settings env vars: %w", err)
 	}
+	if err := raw.loadSections(os.Environ()); err != nil {
+		return fmt.Errorf("config settings env vars: %w", err)
+	}
 
 	configSettings, err := raw.Parse()
 	if err != nil {
@@ -220,6 +668,62 @@ func (gs *GravitySettings) String() string {
 	return fmt.Sprintf("%+v", *gs)
 }
 
+func (gs *GravityRunSettings) String() string {
+	return fmt.Sprintf("%+v", *gs)
+}
+
+func (ps *PipelineSettings) String() string {
+	return fmt.Sprintf("%+v", *ps)
+}
+
+func (rs *RollingSettings) String() string {
+	return fmt.Sprintf("%+v", *rs)
+}
+
+func (cs *CanarySettings) String() string {
+	return fmt.Sprintf("%+v", *cs)
+}
+
+func (bs *BreakerSettings) String() string {
+	return fmt.Sprintf("%+v", *bs)
+}
+
+func (rs *RollbackSettings) String() string {
+	return fmt.Sprintf("%+v", *rs)
+}
+
+func (vs *ValidationSettings) String() string {
+	return fmt.Sprintf("%+v", *vs)
+}
+
+func (vs *VerifySettings) String() string {
+	return fmt.Sprintf("%+v", *vs)
+}
+
+func (ws *WatchSettings) String() string {
+	return fmt.Sprintf("%+v", *ws)
+}
+
+func (rs *ReloadSettings) String() string {
+	return fmt.Sprintf("%+v", *rs)
+}
+
+func (js *JournalSettings) String() string {
+	return fmt.Sprintf("%+v", *js)
+}
+
+func (bs *BackupSettings) String() string {
+	return fmt.Sprintf("%+v", *bs)
+}
+
+func (ms *MergeSettings) String() string {
+	return fmt.Sprintf("%+v", *ms)
+}
+
+func (es *ExcludeSettings) String() string {
+	return fmt.Sprintf("%+v", *es)
+}
+
 func (cs *ConfigSettings) String() string {
 	return fmt.Sprintf("%+v", *cs)
 }
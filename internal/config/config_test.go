@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/lovelaze/nebula-sync/internal/sync/filter"
@@ -63,13 +64,13 @@ func TestConfig_loadSync(t *testing.T) {
 	assert.NotNil(t, conf.Sync.ConfigSettings)
 	assert.NotNil(t, conf.Sync.GravitySettings)
 
-	assert.True(t, conf.Sync.ConfigSettings.DNS.Enabled)
-	assert.True(t, conf.Sync.ConfigSettings.DHCP.Enabled)
-	assert.True(t, conf.Sync.ConfigSettings.NTP.Enabled)
-	assert.True(t, conf.Sync.ConfigSettings.Resolver.Enabled)
-	assert.True(t, conf.Sync.ConfigSettings.Database.Enabled)
-	assert.True(t, conf.Sync.ConfigSettings.Misc.Enabled)
-	assert.True(t, conf.Sync.ConfigSettings.Debug.Enabled)
+	assert.True(t, conf.Sync.ConfigSettings.Setting("dns").Enabled)
+	assert.True(t, conf.Sync.ConfigSettings.Setting("dhcp").Enabled)
+	assert.True(t, conf.Sync.ConfigSettings.Setting("ntp").Enabled)
+	assert.True(t, conf.Sync.ConfigSettings.Setting("resolver").Enabled)
+	assert.True(t, conf.Sync.ConfigSettings.Setting("database").Enabled)
+	assert.True(t, conf.Sync.ConfigSettings.Setting("misc").Enabled)
+	assert.True(t, conf.Sync.ConfigSettings.Setting("debug").Enabled)
 
 	assert.True(t, conf.Sync.GravitySettings.DHCPLeases)
 	assert.True(t, conf.Sync.GravitySettings.Group)
@@ -82,31 +83,40 @@ func TestConfig_loadSync(t *testing.T) {
 }
 
 func TestRawConfig_Validate_Both(t *testing.T) {
-	settings := RawConfigSettings{
-		DNSInclude: []string{"a"},
-		DNSExclude: []string{"b"},
-	}
-	assert.Error(t, settings.Validate())
+	settings := RawConfigSettings{Sections: map[string]*RawConfigSection{
+		"dns": {Include: []string{"reply.**", "!reply.blocking"}, Exclude: []string{"**.IPv6"}},
+	}}
+	assert.NoError(t, settings.Validate())
+}
+
+func TestRawConfig_Validate_InvalidSelector(t *testing.T) {
+	assert.Error(t, (&RawConfigSettings{Sections: map[string]*RawConfigSection{"dhcp": {Include: []string{"/(/"}}}}).Validate())
+	assert.Error(t, (&RawConfigSettings{Sections: map[string]*RawConfigSection{"misc": {Exclude: []string{"a..b"}}}}).Validate())
+}
+
+func TestRawConfig_Validate_Conflict(t *testing.T) {
+	settings := RawConfigSettings{Sections: map[string]*RawConfigSection{
+		"dns":  {Include: []string{"upstreams", "hosts"}, Exclude: []string{" hosts"}},
+		"dhcp": {Include: []string{"router"}, Exclude: []string{"router"}},
+	}}
+	assert.EqualError(t, settings.Validate(), "dhcp: router is both included and excluded\ndns: hosts is both included and excluded")
 }
 
 func TestRawConfig_Validate_Single(t *testing.T) {
-	include := RawConfigSettings{
-		DNSInclude: []string{"a"},
-		DNSExclude: nil,
-	}
-	exclude := RawConfigSettings{
-		DNSInclude: nil,
-		DNSExclude: []string{"a"},
-	}
+	include := RawConfigSettings{Sections: map[string]*RawConfigSection{
+		"dns": {Include: []string{"a"}},
+	}}
+	exclude := RawConfigSettings{Sections: map[string]*RawConfigSection{
+		"dns": {Exclude: []string{"a"}},
+	}}
 	assert.NoError(t, include.Validate())
 	assert.NoError(t, exclude.Validate())
 }
 
 func TestRawConfig_Validate_None(t *testing.T) {
-	settings := RawConfigSettings{
-		DNSInclude: nil,
-		DNSExclude: nil,
-	}
+	settings := RawConfigSettings{Sections: map[string]*RawConfigSection{
+		"dns": {},
+	}}
 	assert.NoError(t, settings.Validate())
 }
 
@@ -124,20 +134,20 @@ func TestRawConfig_Parse_Include(t *testing.T) {
 
 	settings := sync.ConfigSettings
 
-	assert.Equal(t, settings.DNS.Filter.Type, filter.Include)
-	assert.Equal(t, settings.DNS.Filter.Keys, []string{"key1", "key2"})
-	assert.Equal(t, settings.DHCP.Filter.Type, filter.Include)
-	assert.Equal(t, settings.DHCP.Filter.Keys, []string{"key3", "key4"})
-	assert.Equal(t, settings.NTP.Filter.Type, filter.Include)
-	assert.Equal(t, settings.NTP.Filter.Keys, []string{"key5", "key6"})
-	assert.Equal(t, settings.Resolver.Filter.Type, filter.Include)
-	assert.Equal(t, settings.Resolver.Filter.Keys, []string{"key7", "key8"})
-	assert.Equal(t, settings.Database.Filter.Type, filter.Include)
-	assert.Equal(t, settings.Database.Filter.Keys, []string{"key9", "key10"})
-	assert.Equal(t, settings.Misc.Filter.Type, filter.Include)
-	assert.Equal(t, settings.Misc.Filter.Keys, []string{"key11", "key12"})
-	assert.Equal(t, settings.Debug.Filter.Type, filter.Include)
-	assert.Equal(t, settings.Debug.Filter.Keys, []string{"key13", "key14"})
+	assert.Equal(t, []string{"key1", "key2"}, settings.Setting("dns").Filter.Include)
+	assert.Nil(t, settings.Setting("dns").Filter.Exclude)
+	assert.Equal(t, []string{"key3", "key4"}, settings.Setting("dhcp").Filter.Include)
+	assert.Nil(t, settings.Setting("dhcp").Filter.Exclude)
+	assert.Equal(t, []string{"key5", "key6"}, settings.Setting("ntp").Filter.Include)
+	assert.Nil(t, settings.Setting("ntp").Filter.Exclude)
+	assert.Equal(t, []string{"key7", "key8"}, settings.Setting("resolver").Filter.Include)
+	assert.Nil(t, settings.Setting("resolver").Filter.Exclude)
+	assert.Equal(t, []string{"key9", "key10"}, settings.Setting("database").Filter.Include)
+	assert.Nil(t, settings.Setting("database").Filter.Exclude)
+	assert.Equal(t, []string{"key11", "key12"}, settings.Setting("misc").Filter.Include)
+	assert.Nil(t, settings.Setting("misc").Filter.Exclude)
+	assert.Equal(t, []string{"key13", "key14"}, settings.Setting("debug").Filter.Include)
+	assert.Nil(t, settings.Setting("debug").Filter.Exclude)
 }
 
 func TestRawConfig_Parse_Exclude(t *testing.T) {
@@ -154,20 +164,81 @@ func TestRawConfig_Parse_Exclude(t *testing.T) {
 
 	settings := sync.ConfigSettings
 
-	assert.Equal(t, settings.DNS.Filter.Type, filter.Exclude)
-	assert.Equal(t, settings.DNS.Filter.Keys, []string{"key1", "key2"})
-	assert.Equal(t, settings.DHCP.Filter.Type, filter.Exclude)
-	assert.Equal(t, settings.DHCP.Filter.Keys, []string{"key3", "key4"})
-	assert.Equal(t, settings.NTP.Filter.Type, filter.Exclude)
-	assert.Equal(t, settings.NTP.Filter.Keys, []string{"key5", "key6"})
-	assert.Equal(t, settings.Resolver.Filter.Type, filter.Exclude)
-	assert.Equal(t, settings.Resolver.Filter.Keys, []string{"key7", "key8"})
-	assert.Equal(t, settings.Database.Filter.Type, filter.Exclude)
-	assert.Equal(t, settings.Database.Filter.Keys, []string{"key9", "key10"})
-	assert.Equal(t, settings.Misc.Filter.Type, filter.Exclude)
-	assert.Equal(t, settings.Misc.Filter.Keys, []string{"key11", "key12"})
-	assert.Equal(t, settings.Debug.Filter.Type, filter.Exclude)
-	assert.Equal(t, settings.Debug.Filter.Keys, []string{"key13", "key14"})
+	assert.Equal(t, []string{"key1", "key2"}, settings.Setting("dns").Filter.Exclude)
+	assert.Nil(t, settings.Setting("dns").Filter.Include)
+	assert.Equal(t, []string{"key3", "key4"}, settings.Setting("dhcp").Filter.Exclude)
+	assert.Nil(t, settings.Setting("dhcp").Filter.Include)
+	assert.Equal(t, []string{"key5", "key6"}, settings.Setting("ntp").Filter.Exclude)
+	assert.Nil(t, settings.Setting("ntp").Filter.Include)
+	assert.Equal(t, []string{"key7", "key8"}, settings.Setting("resolver").Filter.Exclude)
+	assert.Nil(t, settings.Setting("resolver").Filter.Include)
+	assert.Equal(t, []string{"key9", "key10"}, settings.Setting("database").Filter.Exclude)
+	assert.Nil(t, settings.Setting("database").Filter.Include)
+	assert.Equal(t, []string{"key11", "key12"}, settings.Setting("misc").Filter.Exclude)
+	assert.Nil(t, settings.Setting("misc").Filter.Include)
+	assert.Equal(t, []string{"key13", "key14"}, settings.Setting("debug").Filter.Exclude)
+	assert.Nil(t, settings.Setting("debug").Filter.Include)
+}
+
+func TestRawConfig_Parse_AnySection(t *testing.T) {
+	t.Setenv("SYNC_CONFIG_WEBSERVER", "true")
+	t.Setenv("SYNC_CONFIG_WEBSERVER_INCLUDE", "interface.theme")
+	t.Setenv("SYNC_CONFIG_FILES", "false")
+	t.Setenv("SYNC_CONFIG_REWRITE", "")
+
+	sync := Sync{}
+	assert.NoError(t, sync.loadConfigSettings())
+
+	settings := sync.ConfigSettings
+
+	assert.True(t, settings.Setting("webserver").Enabled)
+	assert.Equal(t, []string{"interface.theme"}, settings.Setting("webserver").Filter.Include)
+	assert.False(t, settings.Setting("files").Enabled)
+	assert.False(t, settings.Setting("dns").Enabled)
+	assert.NotContains(t, settings.Sections, "rewrite")
+}
+
+func TestRawConfig_Parse_Rules(t *testing.T) {
+	t.Setenv("SYNC_CONFIG_DNS", "true")
+	t.Setenv("SYNC_CONFIG_DNS_RULES", "+reply.**,-reply.blocking,+reply.blocking.IPv4")
+
+	sync := Sync{}
+	assert.NoError(t, sync.loadConfigSettings())
+
+	setting := sync.ConfigSettings.Setting("dns")
+	assert.True(t, setting.Enabled)
+	assert.Equal(t, []string{"+reply.**", "-reply.blocking", "+reply.blocking.IPv4"}, setting.Filter.Ordered)
+
+	rules, err := setting.Filter.Rules()
+	require.NoError(t, err)
+	assert.Equal(t, "+reply.**,-reply.blocking,+reply.blocking.IPv4", rules.String())
+}
+
+func TestRawConfig_Validate_Rules(t *testing.T) {
+	settings := RawConfigSettings{Sections: map[string]*RawConfigSection{
+		"dhcp": {Rules: []string{"router"}},
+		"dns":  {Rules: []string{"+upstreams"}, Exclude: []string{"hosts"}},
+		"ntp":  {Rules: []string{"+ipv4", "-ipv4.active"}},
+	}}
+	assert.EqualError(t, settings.Validate(),
+		"dhcp: rule \"router\": expected a + or - prefix\ndns: rules cannot be combined with include or exclude selectors")
+}
+
+func TestRawConfig_Parse_InvalidEnabled(t *testing.T) {
+	t.Setenv("SYNC_CONFIG_WEBSERVER", "maybe")
+
+	sync := Sync{}
+	assert.ErrorContains(t, sync.loadConfigSettings(), "SYNC_CONFIG_WEBSERVER")
+}
+
+func TestConfigSettings_Setting(t *testing.T) {
+	settings := &ConfigSettings{
+		Default:  NewConfigSetting(true, nil, nil),
+		Sections: map[string]*ConfigSetting{"files": NewConfigSetting(false, nil, nil)},
+	}
+	assert.True(t, settings.Setting("dns").Enabled)
+	assert.False(t, settings.Setting("files").Enabled)
+	assert.False(t, (&ConfigSettings{}).Setting("dns").Enabled)
 }
 
 func TestConfig_NewConfigSetting(t *testing.T) {
@@ -182,12 +253,336 @@ func TestConfig_NewConfigSetting(t *testing.T) {
 	include := NewConfigSetting(true, []string{"key1", "key2"}, nil)
 	assert.True(t, include.Enabled)
 	assert.NotNil(t, include.Filter)
-	assert.Equal(t, include.Filter.Type, filter.Include)
-	assert.Equal(t, include.Filter.Keys, []string{"key1", "key2"})
+	assert.Equal(t, []string{"key1", "key2"}, include.Filter.Include)
+	assert.Nil(t, include.Filter.Exclude)
 
 	exclude := NewConfigSetting(true, nil, []string{"key1", "key2"})
 	assert.True(t, exclude.Enabled)
 	assert.NotNil(t, exclude.Filter)
-	assert.Equal(t, exclude.Filter.Type, filter.Exclude)
-	assert.Equal(t, exclude.Filter.Keys, []string{"key1", "key2"})
+	assert.Equal(t, []string{"key1", "key2"}, exclude.Filter.Exclude)
+	assert.Nil(t, exclude.Filter.Include)
+
+	both := NewConfigSetting(true, []string{"reply", "!reply.host"}, []string{"*.IPv6"})
+	rules, err := both.Filter.Rules()
+	assert.NoError(t, err)
+	assert.Equal(t, filter.Rules{
+		{Type: filter.Include, Selector: mustSelector(t, "reply")},
+		{Type: filter.Exclude, Selector: mustSelector(t, "reply.host")},
+		{Type: filter.Exclude, Selector: mustSelector(t, "*.IPv6")},
+	}, rules)
+}
+
+func mustSelector(t *testing.T, s string) *filter.Selector {
+	selector, err := filter.ParseSelector(s)
+	assert.NoError(t, err)
+	return selector
+}
+
+func TestGravitySettings_Validate(t *testing.T) {
+	assert.NoError(t, (&GravitySettings{Adlist: true}).Validate())
+	assert.NoError(t, (&GravitySettings{DHCPLeases: true, Groups: []string{"Kids"}}).Validate())
+	assert.Error(t, (&GravitySettings{Adlist: true, Groups: []string{"Kids"}}).Validate())
+}
+
+func TestConfig_loadSync_groups(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "false")
+	t.Setenv("SYNC_GRAVITY_GROUPS", "Kids,IoT")
+
+	err := conf.loadSync()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Kids", "IoT"}, conf.Sync.GravitySettings.Groups)
+
+	t.Setenv("SYNC_GRAVITY_DOMAIN_LIST", "true")
+	assert.Error(t, conf.loadSync())
+}
+
+func TestConfig_loadSync_exclude(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "true")
+	t.Setenv("SYNC_EXCLUDE_DOMAINS", "*.test,/^debug\\./")
+	t.Setenv("SYNC_EXCLUDE_COMMENT_TAG", "#nosync")
+
+	err := conf.loadSync()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.test", "/^debug\\./"}, conf.Sync.Exclude.Domains)
+	assert.Equal(t, "#nosync", conf.Sync.Exclude.CommentTag)
+
+	t.Setenv("SYNC_EXCLUDE_ADLISTS", "/(/")
+	assert.Error(t, conf.loadSync())
+}
+
+func TestConfig_loadSync_gravityRun(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "true")
+
+	err := conf.loadSync()
+	require.NoError(t, err)
+	assert.Equal(t, GravityRunAlways, conf.Sync.GravityRun.Mode)
+	assert.False(t, conf.Sync.GravityRun.Conditional())
+
+	t.Setenv("RUN_GRAVITY_MODE", "changed")
+	t.Setenv("RUN_GRAVITY_MAX_AGE_HOURS", "24")
+
+	err = conf.loadSync()
+	require.NoError(t, err)
+	assert.True(t, conf.Sync.GravityRun.Conditional())
+	assert.Equal(t, int64(24), conf.Sync.GravityRun.MaxAge)
+
+	t.Setenv("RUN_GRAVITY_MODE", "sometimes")
+	assert.Error(t, conf.loadSync())
+}
+
+func TestConfig_loadSync_rolling(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "true")
+	t.Setenv("ROLLING_ENABLED", "true")
+	t.Setenv("ROLLING_ORDER", "lab,office")
+
+	err := conf.loadSync()
+	require.NoError(t, err)
+	assert.Equal(t, 1, conf.Sync.Rolling.BatchSize)
+	assert.Equal(t, int64(120), conf.Sync.Rolling.ReadyTimeout)
+	assert.Equal(t, []string{"lab", "office"}, conf.Sync.Rolling.Order)
+
+	t.Setenv("ROLLING_BATCH_SIZE", "0")
+	assert.Error(t, conf.loadSync())
+}
+
+func TestConfig_loadSync_canary(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "true")
+	t.Setenv("CANARY_ENABLED", "true")
+	t.Setenv("CANARY_REPLICA", "http://ph2.example.com")
+
+	assert.Error(t, conf.loadSync())
+
+	t.Setenv("CANARY_PROBE_BLOCK", "doubleclick.net")
+	t.Setenv("CANARY_PROBE_LOCAL", "nas.lan=192.168.1.10,router.lan=fe80::1,router.lan=192.168.1.1")
+
+	err := conf.loadSync()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"doubleclick.net"}, conf.Sync.Canary.BlockDomains)
+	assert.Equal(t, int64(5), conf.Sync.Canary.Timeout)
+
+	local, err := conf.Sync.Canary.LocalProbes()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"nas.lan": {"192.168.1.10"}, "router.lan": {"fe80::1", "192.168.1.1"}}, local)
+
+	t.Setenv("CANARY_PROBE_LOCAL", "nas.lan")
+	assert.Error(t, conf.loadSync())
+}
+
+func TestConfig_loadSync_pipeline(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "true")
+	t.Setenv("SYNC_STEPS", "teleporters,gravity,restartdns")
+	t.Setenv("SYNC_STEPS_DISABLE", "verify")
+
+	err := conf.loadSync()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"teleporters", "gravity", "restartdns"}, conf.Sync.Pipeline.Steps)
+	assert.Equal(t, []string{"verify"}, conf.Sync.Pipeline.Disable)
+}
+
+func TestConfig_loadSync_journal(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "true")
+	t.Setenv("JOURNAL_ENABLED", "true")
+
+	assert.Error(t, conf.loadSync())
+
+	t.Setenv("JOURNAL_PATH", "/data/journal.jsonl")
+
+	err := conf.loadSync()
+	require.NoError(t, err)
+	assert.Equal(t, "/data/journal.jsonl", conf.Sync.Journal.Path)
+	assert.Equal(t, int64(720), conf.Sync.Journal.Retention)
+
+	t.Setenv("JOURNAL_RETENTION_HOURS", "-1")
+	assert.Error(t, conf.loadSync())
+}
+
+func TestConfig_loadSync_backup(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "true")
+	t.Setenv("BACKUP_ENABLED", "true")
+
+	assert.Error(t, conf.loadSync())
+
+	t.Setenv("BACKUP_DIR", "/data/backups")
+	t.Setenv("BACKUP_KEEP_LAST", "3")
+
+	err := conf.loadSync()
+	require.NoError(t, err)
+	assert.Equal(t, "/data/backups", conf.Sync.Backup.Dir)
+	assert.Equal(t, 3, conf.Sync.Backup.KeepLast)
+	assert.Equal(t, 7, conf.Sync.Backup.KeepDaily)
+
+	t.Setenv("BACKUP_KEEP_WEEKLY", "-1")
+	assert.Error(t, conf.loadSync())
+}
+
+func TestConfig_LoadBackup(t *testing.T) {
+	conf := Config{}
+
+	assert.Error(t, conf.LoadBackup())
+
+	t.Setenv("BACKUP_DIR", "/data/backups")
+
+	err := conf.LoadBackup()
+	require.NoError(t, err)
+	assert.Equal(t, "/data/backups", conf.Sync.Backup.Dir)
+	assert.NotNil(t, conf.Client)
+}
+
+func TestConfig_loadSync_merge(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "true")
+	t.Setenv("MERGE_ENABLED", "true")
+
+	assert.Error(t, conf.loadSync())
+
+	t.Setenv("MERGE_SOURCES", "http://ph2.example.com|password2,http://ph3.example.com|password3")
+
+	err := conf.loadSync()
+	require.NoError(t, err)
+	require.Len(t, conf.Sync.Merge.Sources, 2)
+	assert.Equal(t, "ph3.example.com", conf.Sync.Merge.Sources[1].Url.Host)
+	assert.Equal(t, "password3", conf.Sync.Merge.Sources[1].Password)
+	assert.Equal(t, MergeDenyWins, conf.Sync.Merge.Conflict)
+	assert.NotContains(t, conf.Sync.Merge.String(), "password")
+
+	t.Setenv("MERGE_CONFLICT", "newest")
+	assert.Error(t, conf.loadSync())
+
+	t.Setenv("MERGE_CONFLICT", "priority")
+	t.Setenv("SYNC_GRAVITY_GROUPS", "Kids")
+	assert.Error(t, conf.loadSync())
+}
+
+func TestConfig_loadSync_rewrites(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "true")
+	t.Setenv("SYNC_CONFIG_REWRITE", "dns.hosts=cidr:192.168.1.0/24>10.0.2.0/24; ph3@dns.revServers=regex:(true),192>$1,10")
+
+	err := conf.loadSync()
+	require.NoError(t, err)
+	require.Len(t, conf.Sync.Rewrites, 2)
+	assert.Equal(t, "dns.hosts", conf.Sync.Rewrites[0].Path)
+	assert.Equal(t, "ph3", conf.Sync.Rewrites[1].Replica)
+	assert.Equal(t, "(true),192", conf.Sync.Rewrites[1].From)
+
+	t.Setenv("SYNC_CONFIG_REWRITE", "dns.hosts=cidr:192.168.1.0/24>10.0.0.0/16")
+	assert.Error(t, conf.loadSync())
+}
+
+func TestConfig_loadSync_elements(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "false")
+	t.Setenv("SYNC_CONFIG_DNS", "true")
+	t.Setenv("SYNC_CONFIG_ELEMENTS", "dns.hosts=host:*.lan;!dhcp.hosts=mac:00:11:22")
+
+	err := conf.loadSync()
+	require.NoError(t, err)
+	require.Len(t, conf.Sync.ConfigSettings.Elements, 2)
+	assert.Equal(t, "dns.hosts", conf.Sync.ConfigSettings.Elements[0].Path)
+	assert.True(t, conf.Sync.ConfigSettings.Elements[1].Drop)
+
+	t.Setenv("SYNC_CONFIG_ELEMENTS", "dns.hosts=ip:192.168.1")
+	assert.Error(t, conf.loadSync())
+}
+
+func TestConfig_loadSync_validation(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "false")
+	require.NoError(t, conf.loadSync())
+	assert.Equal(t, &ValidationSettings{Enabled: true, Strict: false}, conf.Sync.Validation)
+
+	t.Setenv("SYNC_CONFIG_VALIDATE_STRICT", "true")
+	require.NoError(t, conf.loadSync())
+	assert.True(t, conf.Sync.Validation.Strict)
+}
+
+func TestConfig_loadSync_breaker(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "false")
+	t.Setenv("BREAKER_ENABLED", "true")
+	require.NoError(t, conf.loadSync())
+	assert.Equal(t, &BreakerSettings{Enabled: true, Threshold: 3, Backoff: 300, MaxBackoff: 21600}, conf.Sync.Breaker)
+
+	t.Setenv("BREAKER_THRESHOLD", "0")
+	assert.Error(t, conf.loadSync())
+
+	t.Setenv("BREAKER_THRESHOLD", "2")
+	t.Setenv("BREAKER_MAX_BACKOFF_SECONDS", "60")
+	assert.Error(t, conf.loadSync())
+}
+
+func TestConfig_loadSync_watch(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("FULL_SYNC", "false")
+	t.Setenv("WATCH_ENABLED", "true")
+	require.NoError(t, conf.loadSync())
+	assert.Equal(t, &WatchSettings{Enabled: true, Interval: 10, Debounce: 5}, conf.Sync.Watch)
+
+	t.Setenv("WATCH_INTERVAL_SECONDS", "0")
+	assert.ErrorContains(t, conf.loadSync(), "WATCH_INTERVAL_SECONDS must be at least 1")
+
+	t.Setenv("WATCH_INTERVAL_SECONDS", "1")
+	t.Setenv("WATCH_DEBOUNCE_SECONDS", "-1")
+	assert.ErrorContains(t, conf.loadSync(), "WATCH_DEBOUNCE_SECONDS must not be negative")
+
+	t.Setenv("WATCH_DEBOUNCE_SECONDS", "0")
+	assert.NoError(t, conf.loadSync())
+}
+
+func TestConfig_Load_problems(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("PRIMARY", "http://localhost:1337|asdf")
+	t.Setenv("REPLICAS", "http://localhost:1337|qwerty")
+	t.Setenv("FULL_SYNC", "true")
+	t.Setenv("CRON", "61 * * * *")
+	t.Setenv("SYNC_CONFIG_DNS", "true")
+	t.Setenv("SYNC_CONFIG_DNS_INCLUDE", "hosts")
+	t.Setenv("SYNC_CONFIG_DNS_EXCLUDE", "hosts")
+	t.Setenv("SYNC_WEBHOOK_FAILURE_URL", "localhost/hook")
+
+	problems := Problems(conf.Load())
+
+	require.Len(t, problems, 4)
+	assert.EqualError(t, problems[0], "REPLICAS: http://localhost:1337 is the primary")
+	assert.ErrorContains(t, problems[1], "CRON: end of range (61) above maximum (59)")
+	assert.EqualError(t, problems[2], "load config settings: dns: hosts is both included and excluded")
+	assert.EqualError(t, problems[3], "SYNC_WEBHOOK_FAILURE_URL: expected an http or https url")
+}
+
+func TestProblems(t *testing.T) {
+	first, second, third := errors.New("first"), errors.New("second"), errors.New("third")
+
+	assert.Nil(t, Problems(nil))
+	assert.Equal(t, []error{first}, Problems(first))
+	assert.Equal(t, []error{first, second, third}, Problems(errors.Join(first, errors.Join(second, third))))
+}
+
+func TestRollbackSettings_Validate(t *testing.T) {
+	assert.NoError(t, (&RollbackSettings{}).Validate())
+	assert.NoError(t, (&RollbackSettings{Enabled: true, SnapshotDir: "/data/snapshots"}).Validate())
+	assert.EqualError(t, (&RollbackSettings{Enabled: true}).Validate(), "ROLLBACK_SNAPSHOT_DIR is required when rollback is enabled")
+	assert.Error(t, (&RollbackSettings{SnapshotRetention: -1}).Validate())
 }
@@ -1,11 +1,301 @@
 package config
 
 import (
-	"github.com/joho/godotenv"
-	"github.com/rs/zerolog/log"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
 )
 
-func LoadEnvFile(filename string) error {
-	log.Debug().Msgf("Loading envs from file: %s", filename)
-	return godotenv.Load(filename)
+// ReadConfigFile returns the settings of a YAML (.yaml, .yml) or TOML (.toml) config file by env var. Nested
+// keys are joined with underscores, so sync.config.dns.include is SYNC_CONFIG_DNS_INCLUDE, and lists are joined
+// like their env vars.
+func ReadConfigFile(filename string) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	document := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &document)
+	case ".toml":
+		err = toml.Unmarshal(data, &document)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format, expected .yaml, .yml or .toml", filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", filename, err)
+	}
+
+	settings := fileSettings{}
+	if err := settings.load(document); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", filename, err)
+	}
+	return settings, nil
+}
+
+// ruleSettings are the settings whose rules may contain commas, so their lists are joined with newlines.
+var ruleSettings = []string{"SYNC_CONFIG_REWRITE", "SYNC_CONFIG_ELEMENTS", "CLIENT_RETRY_POLICIES"}
+
+type fileSettings map[string]string
+
+func (settings fileSettings) load(document map[string]interface{}) error {
+	for _, key := range sortedKeys(document) {
+		var err error
+		switch key {
+		case "primary":
+			err = settings.loadTarget("PRIMARY", key, document[key])
+		case "replicas":
+			// replicas come last, since their rewrites and retry policies are added to the global ones
+			continue
+		default:
+			err = settings.add(envName(key), key, document[key])
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if replicas, ok := document["replicas"]; ok {
+		return settings.loadReplicas(replicas)
+	}
+	return nil
+}
+
+// add sets the env var of a value, or of every nested value of a table.
+func (settings fileSettings) add(name, path string, value interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		if strings.HasSuffix(name, "_HEADERS") {
+			return settings.set(name, path, joinPairs(value, ","))
+		}
+		for _, key := range sortedKeys(value) {
+			if err := settings.add(name+"_"+envName(key), path+"."+key, value[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		values, err := scalars(path, value)
+		if err != nil {
+			return err
+		}
+		separator := ","
+		if slices.Contains(ruleSettings, name) {
+			separator = "\n"
+		}
+		return settings.set(name, path, strings.Join(values, separator))
+	default:
+		return settings.set(name, path, fmt.Sprint(value))
+	}
+}
+
+func (settings fileSettings) set(name, path, value string) error {
+	// sync.config.<section>.enabled is the SYNC_CONFIG_<SECTION> switch
+	if section, found := strings.CutSuffix(name, "_ENABLED"); found && configSectionEnv.MatchString(section) {
+		name = section
+	}
+
+	if !knownSetting(name) {
+		return fmt.Errorf("%s: unknown setting %s", path, name)
+	}
+	if _, ok := settings[name]; ok {
+		return fmt.Errorf("%s: %s is set more than once", path, name)
+	}
+	settings[name] = value
+	return nil
+}
+
+// loadTarget sets a Pi-hole given as `url|password`, or as a table with url and password.
+func (settings fileSettings) loadTarget(name, path string, value interface{}) error {
+	target, err := targetValue(path, value)
+	if err != nil {
+		return err
+	}
+	return settings.set(name, path, target)
+}
+
+// loadReplicas sets the replicas, and adds the rewrites and retry policies of a replica to the global ones with
+// the replica's url as prefix.
+func (settings fileSettings) loadReplicas(value interface{}) error {
+	list, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("replicas: expected a list")
+	}
+
+	var replicas, rewrites, policies []string
+	for i, replica := range list {
+		path := fmt.Sprintf("replicas[%d]", i)
+		target, err := targetValue(path, replica)
+		if err != nil {
+			return err
+		}
+		replicas = append(replicas, target)
+
+		table, ok := replica.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		url := fmt.Sprint(table["url"])
+
+		if value, ok := table["rewrites"]; ok {
+			rules, ok := value.([]interface{})
+			if !ok {
+				return fmt.Errorf("%s.rewrites: expected a list", path)
+			}
+			values, err := scalars(path+".rewrites", rules)
+			if err != nil {
+				return err
+			}
+			for _, rule := range values {
+				rewrites = append(rewrites, url+"@"+rule)
+			}
+		}
+
+		if value, ok := table["retry"]; ok {
+			operations, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s.retry: expected a table of operations", path)
+			}
+			for _, operation := range sortedKeys(operations) {
+				policy := operations[operation]
+				if pairs, ok := policy.(map[string]interface{}); ok {
+					policy = joinPairs(pairs, ",")
+				}
+				policies = append(policies, fmt.Sprintf("%s@%s=%v", url, operation, policy))
+			}
+		}
+	}
+
+	if err := settings.set("REPLICAS", "replicas", strings.Join(replicas, ",")); err != nil {
+		return err
+	}
+	settings.appendRules("SYNC_CONFIG_REWRITE", rewrites)
+	settings.appendRules("CLIENT_RETRY_POLICIES", policies)
+	return nil
+}
+
+func (settings fileSettings) appendRules(name string, rules []string) {
+	if len(rules) == 0 {
+		return
+	}
+	if global := settings[name]; global != "" {
+		rules = append([]string{global}, rules...)
+	}
+	settings[name] = strings.Join(rules, "\n")
+}
+
+// replicaKeys are the keys of a Pi-hole table.
+var replicaKeys = []string{"url", "password", "rewrites", "retry"}
+
+func targetValue(path string, value interface{}) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case map[string]interface{}:
+		for key := range value {
+			if !slices.Contains(replicaKeys, key) || path == "primary" && (key == "rewrites" || key == "retry") {
+				return "", fmt.Errorf("%s: unknown key %s", path, key)
+			}
+		}
+		url, ok := value["url"].(string)
+		if !ok || url == "" {
+			return "", fmt.Errorf("%s: missing url", path)
+		}
+		password := ""
+		if value["password"] != nil {
+			password = fmt.Sprint(value["password"])
+		}
+		return url + "|" + password, nil
+	default:
+		return "", fmt.Errorf("%s: expected `url|password` or a table with url and password", path)
+	}
+}
+
+func scalars(path string, values []interface{}) ([]string, error) {
+	var s []string
+	for _, value := range values {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("%s: expected a list of values", path)
+		}
+		s = append(s, fmt.Sprint(value))
+	}
+	return s, nil
+}
+
+func joinPairs(table map[string]interface{}, separator string) string {
+	var pairs []string
+	for _, key := range sortedKeys(table) {
+		pairs = append(pairs, fmt.Sprintf("%s:%v", key, table[key]))
+	}
+	return strings.Join(pairs, separator)
+}
+
+func sortedKeys(table map[string]interface{}) []string {
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func envName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// knownSetting reports whether name is the env var of a setting.
+func knownSetting(name string) bool {
+	if _, ok := settingNames()[name]; ok {
+		return true
+	}
+	match := configSectionEnv.FindStringSubmatch(name)
+	return match != nil && !slices.Contains(reservedConfigSettings, match[1])
+}
+
+func settingNames() map[string]struct{} {
+	names := map[string]struct{}{
+		"PRIMARY": {}, "PRIMARY_FILE": {}, "REPLICAS": {}, "REPLICAS_FILE": {}, "TZ": {}, "NS_DEBUG": {},
+	}
+	addSettingNames(names, "", reflect.TypeOf(Client{}))
+	addSettingNames(names, "", reflect.TypeOf(Sync{}))
+	addSettingNames(names, "", reflect.TypeOf(RawConfigSettings{}))
+	for _, event := range []string{"FAILURE", "SUCCESS", "DRIFT", "QUARANTINE", "RECOVERY"} {
+		addSettingNames(names, envPrefix+event+"_", reflect.TypeOf(WebhookEventSetting{}))
+	}
+	addSettingNames(names, envPrefix+"CLIENT_", reflect.TypeOf(WebhookClient{}))
+	return names
+}
+
+// addSettingNames adds the envconfig names of the fields of a settings struct, and of its nested settings.
+func addSettingNames(names map[string]struct{}, prefix string, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("ignored") == "true" {
+			continue
+		}
+		if name := field.Tag.Get("envconfig"); name != "" {
+			names[prefix+name] = struct{}{}
+			continue
+		}
+
+		nested := field.Type
+		if nested.Kind() == reflect.Pointer {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct {
+			addSettingNames(names, prefix, nested)
+		}
+	}
 }
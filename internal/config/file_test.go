@@ -4,53 +4,48 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
-func TestConfig_LoadEnvFile(t *testing.T) {
-	os.Clearenv()
-	err := LoadEnvFile("../../testdata/.env")
-
+func TestConfig_ReadConfigFile_yaml(t *testing.T) {
+	settings, err := ReadConfigFile("../../testdata/nebula-sync.yaml")
 	require.NoError(t, err)
 
-	assert.Equal(t, "https://ph1.example.com|password", os.Getenv("PRIMARY"))
-	assert.Equal(t, "https://ph2.example.com|password", os.Getenv("REPLICAS"))
-	assert.Equal(t, "false", os.Getenv("FULL_SYNC"))
-	assert.Equal(t, "* * * * *", os.Getenv("CRON"))
-	assert.Equal(t, "Europe/London", os.Getenv("TZ"))
-
-	assert.Equal(t, "true", os.Getenv("CLIENT_SKIP_TLS_VERIFICATION"))
-	assert.Equal(t, "40", os.Getenv("CLIENT_TIMEOUT_SECONDS"))
-	assert.Equal(t, "5", os.Getenv("CLIENT_RETRY_DELAY_SECONDS"))
-
-	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_DNS"))
-	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_DHCP"))
-	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_NTP"))
-	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_RESOLVER"))
-	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_DATABASE"))
-	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_MISC"))
-	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_DEBUG"))
-
-	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_DHCP_LEASES"))
-	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_GROUP"))
-	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_AD_LIST"))
-	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_AD_LIST_BY_GROUP"))
-	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_DOMAIN_LIST"))
-	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_DOMAIN_LIST_BY_GROUP"))
-	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_CLIENT"))
-	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_CLIENT_BY_GROUP"))
-
-	os.Clearenv()
+	assert.Equal(t, map[string]string{
+		"PRIMARY":                      "https://ph1.example.com|password",
+		"REPLICAS":                     "https://ph2.example.com|password,https://ph3.example.com|password",
+		"FULL_SYNC":                    "false",
+		"CRON":                         "* * * * *",
+		"TZ":                           "Europe/London",
+		"CLIENT_SKIP_TLS_VERIFICATION": "true",
+		"CLIENT_TIMEOUT_SECONDS":       "40",
+		"CLIENT_RETRY_POLICIES":        "https://ph3.example.com@teleporter=attempts:10,backoff:exponential",
+		"SYNC_CONFIG_DNS":              "true",
+		"SYNC_CONFIG_DNS_EXCLUDE":      "upstreams,revServers",
+		"SYNC_CONFIG_DHCP":             "true",
+		"SYNC_CONFIG_REWRITE":          "dns.hosts=cidr:192.168.1.0/24>10.0.2.0/24\nhttps://ph3.example.com@dhcp.router=literal:192.168.1.1>10.0.3.1",
+		"SYNC_GRAVITY_GROUP":           "true",
+		"SYNC_GRAVITY_AD_LIST":         "true",
+		"SYNC_WEBHOOK_FAILURE_URL":     "https://hooks.example.com/failure",
+		"SYNC_WEBHOOK_FAILURE_HEADERS": "Authorization:Bearer token",
+		"BREAKER_ENABLED":              "true",
+		"BREAKER_THRESHOLD":            "5",
+	}, settings)
 }
 
-func TestConfig_LoadEnvFile_precedence(t *testing.T) {
-	assert.Empty(t, os.Getenv("CRON"))
-	t.Setenv("CRON", "0 0 * * *")
-
-	err := LoadEnvFile("../../testdata/.env")
-	require.NoError(t, err)
-
-	assert.Equal(t, "0 0 * * *", os.Getenv("CRON"))
-
-	os.Clearenv()
+func TestConfig_ReadConfigFile_errors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown.yaml":   "sync:\n  confg:\n    dns: true\n",
+		"duplicate.yaml": "full_sync: true\nfull:\n  sync: false\n",
+		"replica.yaml":   "replicas:\n  - url: https://ph2.example.com\n    passwrd: secret\n",
+		"nested.yaml":    "sync:\n  steps: [[config]]\n",
+		"format.json":    "{}",
+	} {
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		_, err := ReadConfigFile(path)
+		assert.Error(t, err, name)
+	}
 }
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/rs/zerolog/log"
+)
+
+// Source loads the config from env vars, an optional env file and an optional config file. Env vars take
+// precedence over the env file, which takes precedence over the config file. Loading again re-reads both
+// files, so a Source can reload the config after they changed.
+type Source struct {
+	EnvFile    string
+	ConfigFile string
+	// fromFiles are the env vars set from the files by the latest load, with the values they were set to.
+	fromFiles map[string]string
+	contents  map[string][]byte
+}
+
+// Load reads the files into env vars and loads the config. It returns the settings the config was loaded
+// from, see Settings.
+func (source *Source) Load() (*Config, Settings, error) {
+	if err := source.Apply(); err != nil {
+		return nil, nil, err
+	}
+
+	conf := Config{}
+	if err := conf.Load(); err != nil {
+		return nil, nil, err
+	}
+	return &conf, currentSettings(), nil
+}
+
+// Changed reports whether the content of a file differs from the latest load.
+func (source *Source) Changed() (bool, error) {
+	for _, filename := range source.files() {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(data, source.contents[filename]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (source *Source) files() []string {
+	var files []string
+	for _, filename := range []string{source.EnvFile, source.ConfigFile} {
+		if filename != "" {
+			files = append(files, filename)
+		}
+	}
+	return files
+}
+
+// Apply reads the files into env vars without loading the config.
+func (source *Source) Apply() error {
+	// env vars set from the files by the latest load give way to the files' current content
+	for name, value := range source.fromFiles {
+		if current, ok := os.LookupEnv(name); ok && current == value {
+			os.Unsetenv(name)
+		}
+	}
+	source.fromFiles = map[string]string{}
+	source.contents = map[string][]byte{}
+
+	if source.EnvFile != "" {
+		log.Debug().Msgf("Loading envs from file: %s", source.EnvFile)
+		env, err := source.read(source.EnvFile, func(filename string) (map[string]string, error) {
+			return godotenv.Read(filename)
+		})
+		if err != nil {
+			return fmt.Errorf("env file: %w", err)
+		}
+		source.setenv(env)
+	}
+
+	if source.ConfigFile != "" {
+		log.Debug().Msgf("Loading settings from file: %s", source.ConfigFile)
+		settings, err := source.read(source.ConfigFile, ReadConfigFile)
+		if err != nil {
+			return err
+		}
+		source.setenv(settings)
+	}
+	return nil
+}
+
+// read records the content of a file for Changed, even if it fails to parse, and parses it.
+func (source *Source) read(filename string, parse func(string) (map[string]string, error)) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	source.contents[filename] = data
+	return parse(filename)
+}
+
+func (source *Source) setenv(env map[string]string) {
+	for name, value := range env {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		os.Setenv(name, value)
+		source.fromFiles[name] = value
+	}
+}
+
+// Settings are the env vars of the settings a config was loaded from.
+type Settings map[string]string
+
+func currentSettings() Settings {
+	settings := Settings{}
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if knownSetting(name) {
+			settings[name] = value
+		}
+	}
+	return settings
+}
+
+// secretSettings are the settings whose values may hold passwords or tokens.
+var secretSettings = []string{"PRIMARY", "REPLICAS", "MERGE_SOURCES"}
+
+func isSecretSetting(name string) bool {
+	if slices.Contains(secretSettings, name) {
+		return true
+	}
+	return strings.HasPrefix(name, envPrefix) && (strings.HasSuffix(name, "_URL") || strings.HasSuffix(name, "_HEADERS"))
+}
+
+// Diff describes the settings that differ from old, sorted by name. The values of secret settings are left out.
+func (settings Settings) Diff(old Settings) []string {
+	names := map[string]struct{}{}
+	for name := range old {
+		names[name] = struct{}{}
+	}
+	for name := range settings {
+		names[name] = struct{}{}
+	}
+
+	var changes []string
+	for name := range names {
+		before, wasSet := old[name]
+		after, isSet := settings[name]
+		if wasSet == isSet && before == after {
+			continue
+		}
+
+		switch {
+		case isSecretSetting(name):
+			changes = append(changes, fmt.Sprintf("%s changed", name))
+		case !wasSet:
+			changes = append(changes, fmt.Sprintf("%s set to %q", name, after))
+		case !isSet:
+			changes = append(changes, fmt.Sprintf("%s unset, was %q", name, before))
+		default:
+			changes = append(changes, fmt.Sprintf("%s changed from %q to %q", name, before, after))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSource_Load_reload(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+	t.Setenv("FULL_SYNC", "true")
+
+	path := filepath.Join(t.TempDir(), "nebula-sync.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+primary: https://ph1.example.com|password
+replicas: [https://ph2.example.com|password]
+full_sync: false
+sync:
+  config:
+    dns: true
+`), 0o600))
+
+	source := Source{ConfigFile: path}
+	conf, settings, err := source.Load()
+	require.NoError(t, err)
+
+	assert.Len(t, conf.Replicas, 1)
+	assert.True(t, conf.Sync.FullSync, "env vars take precedence over the file")
+	assert.True(t, conf.Sync.ConfigSettings.Setting("dns").Enabled)
+
+	changed, err := source.Changed()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+primary: https://ph1.example.com|password
+replicas: [https://ph2.example.com|password, https://ph3.example.com|password]
+full_sync: false
+sync:
+  config:
+    dhcp: true
+`), 0o600))
+
+	changed, err = source.Changed()
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	reloaded, reloadedSettings, err := source.Load()
+	require.NoError(t, err)
+
+	assert.Len(t, reloaded.Replicas, 2)
+	assert.True(t, reloaded.Sync.FullSync)
+	assert.False(t, reloaded.Sync.ConfigSettings.Setting("dns").Enabled, "settings removed from the file must be unset")
+	assert.True(t, reloaded.Sync.ConfigSettings.Setting("dhcp").Enabled)
+
+	assert.Equal(t, []string{
+		"REPLICAS changed",
+		`SYNC_CONFIG_DHCP set to "true"`,
+		`SYNC_CONFIG_DNS unset, was "true"`,
+	}, reloadedSettings.Diff(settings))
+}
+
+func TestSource_Apply_envFile(t *testing.T) {
+	os.Clearenv()
+	err := (&Source{EnvFile: "../../testdata/.env"}).Apply()
+
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://ph1.example.com|password", os.Getenv("PRIMARY"))
+	assert.Equal(t, "https://ph2.example.com|password", os.Getenv("REPLICAS"))
+	assert.Equal(t, "false", os.Getenv("FULL_SYNC"))
+	assert.Equal(t, "* * * * *", os.Getenv("CRON"))
+	assert.Equal(t, "Europe/London", os.Getenv("TZ"))
+
+	assert.Equal(t, "true", os.Getenv("CLIENT_SKIP_TLS_VERIFICATION"))
+	assert.Equal(t, "40", os.Getenv("CLIENT_TIMEOUT_SECONDS"))
+	assert.Equal(t, "5", os.Getenv("CLIENT_RETRY_DELAY_SECONDS"))
+
+	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_DNS"))
+	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_DHCP"))
+	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_NTP"))
+	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_RESOLVER"))
+	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_DATABASE"))
+	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_MISC"))
+	assert.Equal(t, "true", os.Getenv("SYNC_CONFIG_DEBUG"))
+
+	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_DHCP_LEASES"))
+	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_GROUP"))
+	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_AD_LIST"))
+	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_AD_LIST_BY_GROUP"))
+	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_DOMAIN_LIST"))
+	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_DOMAIN_LIST_BY_GROUP"))
+	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_CLIENT"))
+	assert.Equal(t, "true", os.Getenv("SYNC_GRAVITY_CLIENT_BY_GROUP"))
+
+	os.Clearenv()
+}
+
+func TestSource_Apply_envFilePrecedence(t *testing.T) {
+	assert.Empty(t, os.Getenv("CRON"))
+	t.Setenv("CRON", "0 0 * * *")
+
+	err := (&Source{EnvFile: "../../testdata/.env"}).Apply()
+	require.NoError(t, err)
+
+	assert.Equal(t, "0 0 * * *", os.Getenv("CRON"))
+
+	os.Clearenv()
+}
+
+func TestSource_Apply_configFile(t *testing.T) {
+	os.Clearenv()
+	t.Setenv("FULL_SYNC", "false")
+
+	require.NoError(t, (&Source{ConfigFile: "../../testdata/nebula-sync.toml"}).Apply())
+
+	conf := Config{}
+	require.NoError(t, conf.Load())
+
+	assert.Equal(t, "https://ph1.example.com", conf.Primary.Url.String())
+	assert.Len(t, conf.Replicas, 1)
+	assert.False(t, conf.Sync.FullSync, "env vars take precedence over the file")
+	assert.True(t, conf.Sync.RunGravity)
+	assert.Equal(t, int64(5), conf.Client.RetryDelay)
+	assert.Equal(t, &ConfigSetting{
+		Enabled: true,
+		Filter:  &ConfigFilter{Include: []string{"hosts", "cnameRecords"}},
+	}, conf.Sync.ConfigSettings.Setting("dns"))
+
+	rules, err := conf.Sync.ConfigSettings.Setting("dns").Filter.Rules()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"hosts":        []interface{}{"192.168.1.10 nas.lan"},
+		"cnameRecords": []interface{}{"www.lan,nas.lan"},
+	}, rules.Apply(fixtureDNSSection()), "the selectors match keys of the dns section")
+
+	os.Clearenv()
+}
+
+func TestSource_Apply_configFileYAML(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+
+	require.NoError(t, (&Source{ConfigFile: "../../testdata/nebula-sync.yaml"}).Apply())
+
+	conf := Config{}
+	require.NoError(t, conf.Load())
+
+	rules, err := conf.Sync.ConfigSettings.Setting("dns").Filter.Rules()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"hosts":        []interface{}{"192.168.1.10 nas.lan"},
+		"cnameRecords": []interface{}{"www.lan,nas.lan"},
+		"port":         53.0,
+	}, rules.Apply(fixtureDNSSection()), "the selectors match keys of the dns section")
+}
+
+// fixtureDNSSection returns a dns section of the primary's config, as filtered by the testdata config files.
+func fixtureDNSSection() map[string]interface{} {
+	return map[string]interface{}{
+		"upstreams":    []interface{}{"1.1.1.1"},
+		"revServers":   []interface{}{"true,192.168.1.0/24,192.168.1.1,lan"},
+		"hosts":        []interface{}{"192.168.1.10 nas.lan"},
+		"cnameRecords": []interface{}{"www.lan,nas.lan"},
+		"port":         53.0,
+	}
+}
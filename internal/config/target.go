@@ -1,20 +1,22 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"net/url"
 	"os"
 	"strings"
 )
 
 func (c *Config) loadTargets() error {
-	primary, err := loadPrimary()
-	if err != nil {
-		return err
+	primary, primaryErr := loadPrimary()
+	replicas, replicasErr := loadReplicas()
+	if primaryErr != nil || replicasErr != nil {
+		return errors.Join(primaryErr, replicasErr)
 	}
 
-	replicas, err := loadReplicas()
-	if err != nil {
+	if err := validateTargets(primary, replicas); err != nil {
 		return err
 	}
 
@@ -23,6 +25,78 @@ func (c *Config) loadTargets() error {
 	return nil
 }
 
+// validateTargets returns every url that is not an absolute http or https url, every duplicate replica and the
+// primary if it is listed as a replica.
+func validateTargets(primary *model.PiHole, replicas []model.PiHole) error {
+	var errs []error
+	if err := validateUrl(primary); err != nil {
+		errs = append(errs, fmt.Errorf("PRIMARY: %w", err))
+	}
+
+	seen := map[string]bool{targetKey(primary): true}
+	for _, replica := range replicas {
+		if err := validateUrl(&replica); err != nil {
+			errs = append(errs, fmt.Errorf("REPLICAS: %w", err))
+			continue
+		}
+
+		key := targetKey(&replica)
+		switch {
+		case key == targetKey(primary):
+			errs = append(errs, fmt.Errorf("REPLICAS: %s is the primary", replica.Url))
+		case seen[key]:
+			errs = append(errs, fmt.Errorf("REPLICAS: %s is listed more than once", replica.Url))
+		}
+		seen[key] = true
+	}
+	return errors.Join(errs...)
+}
+
+func validateUrl(piHole *model.PiHole) error {
+	u := piHole.Url
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%q: expected an http or https url", u)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q: missing host", u)
+	}
+	return nil
+}
+
+// targetKey identifies a Pi-hole by its url, ignoring the case of the host and a trailing slash.
+func targetKey(piHole *model.PiHole) string {
+	u := piHole.Url
+	return u.Scheme + "://" + strings.ToLower(u.Host) + strings.TrimSuffix(u.Path, "/")
+}
+
+// LookupTarget returns the configured PRIMARY or replica in REPLICAS with the url rawUrl, so that its password
+// does not have to be passed on the command line.
+func LookupTarget(rawUrl string) (*model.PiHole, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+	target := &model.PiHole{Url: u}
+	if err := validateUrl(target); err != nil {
+		return nil, err
+	}
+
+	var targets []model.PiHole
+	if primary, err := loadPrimary(); err == nil {
+		targets = append(targets, *primary)
+	}
+	if replicas, err := loadReplicas(); err == nil {
+		targets = append(targets, replicas...)
+	}
+
+	for _, configured := range targets {
+		if targetKey(&configured) == targetKey(target) {
+			return &configured, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is neither the PRIMARY nor one of the REPLICAS", rawUrl)
+}
+
 func loadPrimary() (*model.PiHole, error) {
 	env := "PRIMARY"
 	if value := os.Getenv(fmt.Sprintf("%s_FILE", env)); len(value) > 0 {
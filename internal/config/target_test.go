@@ -70,3 +70,51 @@ func TestConfig_Load_NoReplicas(t *testing.T) {
 	err := conf.loadTargets()
 	assert.Error(t, err)
 }
+
+func TestConfig_Load_TargetConflicts(t *testing.T) {
+	conf := Config{}
+
+	t.Setenv("PRIMARY", "http://ph1.lan|asdf")
+	t.Setenv("REPLICAS", "http://PH1.lan/|qwerty,ftp://ph2.lan|foobar,http://ph3.lan|a,http://ph3.lan/|b")
+
+	err := conf.loadTargets()
+
+	var problems []string
+	for _, problem := range Problems(err) {
+		problems = append(problems, problem.Error())
+	}
+	assert.Equal(t, []string{
+		"REPLICAS: http://PH1.lan/ is the primary",
+		`REPLICAS: "ftp://ph2.lan": expected an http or https url`,
+		"REPLICAS: http://ph3.lan/ is listed more than once",
+	}, problems)
+}
+
+func TestLookupTarget(t *testing.T) {
+	t.Setenv("PRIMARY", "http://ph1.example.com|asdf")
+	t.Setenv("REPLICAS", "http://ph2.example.com|qwerty,http://ph3.example.com/|foobar")
+
+	target, err := LookupTarget("http://PH3.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "http://ph3.example.com/", target.Url.String())
+	assert.Equal(t, "foobar", target.Password)
+
+	target, err = LookupTarget("http://ph1.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "asdf", target.Password)
+
+	_, err = LookupTarget("http://ph4.example.com")
+	assert.ErrorContains(t, err, "neither the PRIMARY nor one of the REPLICAS")
+
+	_, err = LookupTarget("ph2.example.com")
+	assert.Error(t, err)
+}
+
+func TestLookupTarget_replicasOnly(t *testing.T) {
+	t.Setenv("REPLICAS", "http://ph2.example.com|qwerty")
+	require.Empty(t, os.Getenv("PRIMARY"))
+
+	target, err := LookupTarget("http://ph2.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "qwerty", target.Password)
+}
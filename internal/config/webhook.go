@@ -1,15 +1,20 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 
 	"github.com/kelseyhightower/envconfig"
 )
 
 type WebhookSettings struct {
-	Failure WebhookEventSetting `ignored:"true"`
-	Success WebhookEventSetting `ignored:"true"`
-	Client  WebhookClient       `ignored:"true"`
+	Failure    WebhookEventSetting `ignored:"true"`
+	Success    WebhookEventSetting `ignored:"true"`
+	Drift      WebhookEventSetting `ignored:"true"`
+	Quarantine WebhookEventSetting `ignored:"true"`
+	Recovery   WebhookEventSetting `ignored:"true"`
+	Client     WebhookClient       `ignored:"true"`
 }
 
 type WebhookClient struct {
@@ -27,9 +32,12 @@ const envPrefix = "SYNC_WEBHOOK_"
 
 func (c *Config) loadWebhookSettings() error {
 	webhookSettings := WebhookSettings{
-		Failure: WebhookEventSetting{},
-		Success: WebhookEventSetting{},
-		Client:  WebhookClient{},
+		Failure:    WebhookEventSetting{},
+		Success:    WebhookEventSetting{},
+		Drift:      WebhookEventSetting{},
+		Quarantine: WebhookEventSetting{},
+		Recovery:   WebhookEventSetting{},
+		Client:     WebhookClient{},
 	}
 
 	if err := envconfig.Process(envPrefix+"FAILURE", &webhookSettings.Failure); err != nil {
@@ -38,12 +46,52 @@ func (c *Config) loadWebhookSettings() error {
 	if err := envconfig.Process(envPrefix+"SUCCESS", &webhookSettings.Success); err != nil {
 		return fmt.Errorf("process webhook env vars for success: %w", err)
 	}
+	if err := envconfig.Process(envPrefix+"DRIFT", &webhookSettings.Drift); err != nil {
+		return fmt.Errorf("process webhook env vars for drift: %w", err)
+	}
+
+	if err := envconfig.Process(envPrefix+"QUARANTINE", &webhookSettings.Quarantine); err != nil {
+		return fmt.Errorf("process webhook env vars for quarantine: %w", err)
+	}
+	if err := envconfig.Process(envPrefix+"RECOVERY", &webhookSettings.Recovery); err != nil {
+		return fmt.Errorf("process webhook env vars for recovery: %w", err)
+	}
 
 	if err := envconfig.Process(envPrefix+"CLIENT", &webhookSettings.Client); err != nil {
 		return fmt.Errorf("process webhook env vars for client: %w", err)
 	}
 
+	if err := webhookSettings.Validate(); err != nil {
+		return err
+	}
+
 	c.Sync.WebhookSettings = &webhookSettings
 
 	return nil
 }
+
+// Validate returns every webhook url that is not an absolute http or https url. The urls are left out of the
+// errors, since they may hold tokens.
+func (settings *WebhookSettings) Validate() error {
+	var errs []error
+	for _, event := range []struct {
+		name    string
+		setting WebhookEventSetting
+	}{
+		{"FAILURE", settings.Failure},
+		{"SUCCESS", settings.Success},
+		{"DRIFT", settings.Drift},
+		{"QUARANTINE", settings.Quarantine},
+		{"RECOVERY", settings.Recovery},
+	} {
+		if event.setting.Url == "" {
+			continue
+		}
+
+		u, err := url.Parse(event.setting.Url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s%s_URL: expected an http or https url", envPrefix, event.name))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -106,3 +106,47 @@ func TestWebhookSettings_ClientConfiguration(t *testing.T) {
 	require.NotNil(t, conf.Sync.WebhookSettings.Client)
 	assert.True(t, conf.Sync.WebhookSettings.Client.SkipTLSVerification)
 }
+
+func TestWebhookSettings_Load_Drift(t *testing.T) {
+	t.Setenv("SYNC_WEBHOOK_DRIFT_URL", "http://drift.example.com")
+	t.Setenv("SYNC_WEBHOOK_DRIFT_BODY", "drift")
+
+	conf := Config{
+		Sync: &Sync{},
+	}
+	err := conf.loadWebhookSettings()
+	require.NoError(t, err)
+
+	drift := conf.Sync.WebhookSettings.Drift
+	assert.Equal(t, "http://drift.example.com", drift.Url)
+	assert.Equal(t, "POST", drift.Method)
+	assert.Equal(t, "drift", drift.Body)
+}
+
+func TestWebhookSettings_Load_Breaker(t *testing.T) {
+	t.Setenv("SYNC_WEBHOOK_QUARANTINE_URL", "http://quarantine.example.com")
+	t.Setenv("SYNC_WEBHOOK_RECOVERY_URL", "http://recovery.example.com")
+	t.Setenv("SYNC_WEBHOOK_RECOVERY_METHOD", "PUT")
+
+	conf := Config{
+		Sync: &Sync{},
+	}
+	err := conf.loadWebhookSettings()
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://quarantine.example.com", conf.Sync.WebhookSettings.Quarantine.Url)
+	assert.Equal(t, "POST", conf.Sync.WebhookSettings.Quarantine.Method)
+	assert.Equal(t, "http://recovery.example.com", conf.Sync.WebhookSettings.Recovery.Url)
+	assert.Equal(t, "PUT", conf.Sync.WebhookSettings.Recovery.Method)
+}
+
+func TestWebhookSettings_InvalidURL(t *testing.T) {
+	conf := Config{
+		Sync: &Sync{},
+	}
+	t.Setenv("SYNC_WEBHOOK_SUCCESS_URL", "https://hooks.example.com/token")
+	t.Setenv("SYNC_WEBHOOK_DRIFT_URL", "hooks.example.com/token")
+
+	err := conf.loadWebhookSettings()
+	assert.EqualError(t, err, "SYNC_WEBHOOK_DRIFT_URL: expected an http or https url")
+}
@@ -66,6 +66,144 @@ func (_c *Client_ApiPath_Call) RunAndReturn(run func(string) string) *Client_Api
 	return _c
 }
 
+// DeleteClient provides a mock function with given fields: client
+func (_m *Client) DeleteClient(client *model.Client) error {
+	ret := _m.Called(client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteClient")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Client) error); ok {
+		r0 = rf(client)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_DeleteClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteClient'
+type Client_DeleteClient_Call struct {
+	*mock.Call
+}
+
+// DeleteClient is a helper method to define mock.On call
+//   - client *model.Client
+func (_e *Client_Expecter) DeleteClient(client interface{}) *Client_DeleteClient_Call {
+	return &Client_DeleteClient_Call{Call: _e.mock.On("DeleteClient", client)}
+}
+
+func (_c *Client_DeleteClient_Call) Run(run func(client *model.Client)) *Client_DeleteClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Client))
+	})
+	return _c
+}
+
+func (_c *Client_DeleteClient_Call) Return(_a0 error) *Client_DeleteClient_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_DeleteClient_Call) RunAndReturn(run func(*model.Client) error) *Client_DeleteClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteDomain provides a mock function with given fields: domain
+func (_m *Client) DeleteDomain(domain *model.Domain) error {
+	ret := _m.Called(domain)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteDomain")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Domain) error); ok {
+		r0 = rf(domain)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_DeleteDomain_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteDomain'
+type Client_DeleteDomain_Call struct {
+	*mock.Call
+}
+
+// DeleteDomain is a helper method to define mock.On call
+//   - domain *model.Domain
+func (_e *Client_Expecter) DeleteDomain(domain interface{}) *Client_DeleteDomain_Call {
+	return &Client_DeleteDomain_Call{Call: _e.mock.On("DeleteDomain", domain)}
+}
+
+func (_c *Client_DeleteDomain_Call) Run(run func(domain *model.Domain)) *Client_DeleteDomain_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Domain))
+	})
+	return _c
+}
+
+func (_c *Client_DeleteDomain_Call) Return(_a0 error) *Client_DeleteDomain_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_DeleteDomain_Call) RunAndReturn(run func(*model.Domain) error) *Client_DeleteDomain_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteList provides a mock function with given fields: list
+func (_m *Client) DeleteList(list *model.List) error {
+	ret := _m.Called(list)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteList")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.List) error); ok {
+		r0 = rf(list)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_DeleteList_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteList'
+type Client_DeleteList_Call struct {
+	*mock.Call
+}
+
+// DeleteList is a helper method to define mock.On call
+//   - list *model.List
+func (_e *Client_Expecter) DeleteList(list interface{}) *Client_DeleteList_Call {
+	return &Client_DeleteList_Call{Call: _e.mock.On("DeleteList", list)}
+}
+
+func (_c *Client_DeleteList_Call) Run(run func(list *model.List)) *Client_DeleteList_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.List))
+	})
+	return _c
+}
+
+func (_c *Client_DeleteList_Call) Return(_a0 error) *Client_DeleteList_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_DeleteList_Call) RunAndReturn(run func(*model.List) error) *Client_DeleteList_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DeleteSession provides a mock function with no fields
 func (_m *Client) DeleteSession() error {
 	ret := _m.Called()
@@ -111,6 +249,63 @@ func (_c *Client_DeleteSession_Call) RunAndReturn(run func() error) *Client_Dele
 	return _c
 }
 
+// GetClients provides a mock function with no fields
+func (_m *Client) GetClients() ([]model.Client, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetClients")
+	}
+
+	var r0 []model.Client
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]model.Client, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []model.Client); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Client)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_GetClients_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetClients'
+type Client_GetClients_Call struct {
+	*mock.Call
+}
+
+// GetClients is a helper method to define mock.On call
+func (_e *Client_Expecter) GetClients() *Client_GetClients_Call {
+	return &Client_GetClients_Call{Call: _e.mock.On("GetClients")}
+}
+
+func (_c *Client_GetClients_Call) Run(run func()) *Client_GetClients_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Client_GetClients_Call) Return(_a0 []model.Client, _a1 error) *Client_GetClients_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_GetClients_Call) RunAndReturn(run func() ([]model.Client, error)) *Client_GetClients_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetConfig provides a mock function with no fields
 func (_m *Client) GetConfig() (*model.ConfigResponse, error) {
 	ret := _m.Called()
@@ -168,24 +363,24 @@ func (_c *Client_GetConfig_Call) RunAndReturn(run func() (*model.ConfigResponse,
 	return _c
 }
 
-// GetTeleporter provides a mock function with no fields
-func (_m *Client) GetTeleporter() ([]byte, error) {
+// GetConfigSchema provides a mock function with no fields
+func (_m *Client) GetConfigSchema() (*model.ConfigSchemaResponse, error) {
 	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetTeleporter")
+		panic("no return value specified for GetConfigSchema")
 	}
 
-	var r0 []byte
+	var r0 *model.ConfigSchemaResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]byte, error)); ok {
+	if rf, ok := ret.Get(0).(func() (*model.ConfigSchemaResponse, error)); ok {
 		return rf()
 	}
-	if rf, ok := ret.Get(0).(func() []byte); ok {
+	if rf, ok := ret.Get(0).(func() *model.ConfigSchemaResponse); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]byte)
+			r0 = ret.Get(0).(*model.ConfigSchemaResponse)
 		}
 	}
 
@@ -198,51 +393,51 @@ func (_m *Client) GetTeleporter() ([]byte, error) {
 	return r0, r1
 }
 
-// Client_GetTeleporter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTeleporter'
-type Client_GetTeleporter_Call struct {
+// Client_GetConfigSchema_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetConfigSchema'
+type Client_GetConfigSchema_Call struct {
 	*mock.Call
 }
 
-// GetTeleporter is a helper method to define mock.On call
-func (_e *Client_Expecter) GetTeleporter() *Client_GetTeleporter_Call {
-	return &Client_GetTeleporter_Call{Call: _e.mock.On("GetTeleporter")}
+// GetConfigSchema is a helper method to define mock.On call
+func (_e *Client_Expecter) GetConfigSchema() *Client_GetConfigSchema_Call {
+	return &Client_GetConfigSchema_Call{Call: _e.mock.On("GetConfigSchema")}
 }
 
-func (_c *Client_GetTeleporter_Call) Run(run func()) *Client_GetTeleporter_Call {
+func (_c *Client_GetConfigSchema_Call) Run(run func()) *Client_GetConfigSchema_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run()
 	})
 	return _c
 }
 
-func (_c *Client_GetTeleporter_Call) Return(_a0 []byte, _a1 error) *Client_GetTeleporter_Call {
+func (_c *Client_GetConfigSchema_Call) Return(_a0 *model.ConfigSchemaResponse, _a1 error) *Client_GetConfigSchema_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Client_GetTeleporter_Call) RunAndReturn(run func() ([]byte, error)) *Client_GetTeleporter_Call {
+func (_c *Client_GetConfigSchema_Call) RunAndReturn(run func() (*model.ConfigSchemaResponse, error)) *Client_GetConfigSchema_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetVersion provides a mock function with no fields
-func (_m *Client) GetVersion() (*model.VersionResponse, error) {
+// GetDomains provides a mock function with no fields
+func (_m *Client) GetDomains() ([]model.Domain, error) {
 	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetVersion")
+		panic("no return value specified for GetDomains")
 	}
 
-	var r0 *model.VersionResponse
+	var r0 []model.Domain
 	var r1 error
-	if rf, ok := ret.Get(0).(func() (*model.VersionResponse, error)); ok {
+	if rf, ok := ret.Get(0).(func() ([]model.Domain, error)); ok {
 		return rf()
 	}
-	if rf, ok := ret.Get(0).(func() *model.VersionResponse); ok {
+	if rf, ok := ret.Get(0).(func() []model.Domain); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.VersionResponse)
+			r0 = ret.Get(0).([]model.Domain)
 		}
 	}
 
@@ -255,165 +450,679 @@ func (_m *Client) GetVersion() (*model.VersionResponse, error) {
 	return r0, r1
 }
 
-// Client_GetVersion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVersion'
-type Client_GetVersion_Call struct {
+// Client_GetDomains_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDomains'
+type Client_GetDomains_Call struct {
 	*mock.Call
 }
 
-// GetVersion is a helper method to define mock.On call
-func (_e *Client_Expecter) GetVersion() *Client_GetVersion_Call {
-	return &Client_GetVersion_Call{Call: _e.mock.On("GetVersion")}
+// GetDomains is a helper method to define mock.On call
+func (_e *Client_Expecter) GetDomains() *Client_GetDomains_Call {
+	return &Client_GetDomains_Call{Call: _e.mock.On("GetDomains")}
 }
 
-func (_c *Client_GetVersion_Call) Run(run func()) *Client_GetVersion_Call {
+func (_c *Client_GetDomains_Call) Run(run func()) *Client_GetDomains_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run()
 	})
 	return _c
 }
 
-func (_c *Client_GetVersion_Call) Return(_a0 *model.VersionResponse, _a1 error) *Client_GetVersion_Call {
+func (_c *Client_GetDomains_Call) Return(_a0 []model.Domain, _a1 error) *Client_GetDomains_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Client_GetVersion_Call) RunAndReturn(run func() (*model.VersionResponse, error)) *Client_GetVersion_Call {
+func (_c *Client_GetDomains_Call) RunAndReturn(run func() ([]model.Domain, error)) *Client_GetDomains_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// PatchConfig provides a mock function with given fields: patchRequest
-func (_m *Client) PatchConfig(patchRequest *model.PatchConfigRequest) error {
-	ret := _m.Called(patchRequest)
+// GetGroups provides a mock function with no fields
+func (_m *Client) GetGroups() ([]model.Group, error) {
+	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for PatchConfig")
+		panic("no return value specified for GetGroups")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(*model.PatchConfigRequest) error); ok {
-		r0 = rf(patchRequest)
+	var r0 []model.Group
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]model.Group, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []model.Group); ok {
+		r0 = rf()
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Group)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// Client_PatchConfig_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PatchConfig'
-type Client_PatchConfig_Call struct {
+// Client_GetGroups_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGroups'
+type Client_GetGroups_Call struct {
 	*mock.Call
 }
 
-// PatchConfig is a helper method to define mock.On call
-//   - patchRequest *model.PatchConfigRequest
-func (_e *Client_Expecter) PatchConfig(patchRequest interface{}) *Client_PatchConfig_Call {
-	return &Client_PatchConfig_Call{Call: _e.mock.On("PatchConfig", patchRequest)}
+// GetGroups is a helper method to define mock.On call
+func (_e *Client_Expecter) GetGroups() *Client_GetGroups_Call {
+	return &Client_GetGroups_Call{Call: _e.mock.On("GetGroups")}
 }
 
-func (_c *Client_PatchConfig_Call) Run(run func(patchRequest *model.PatchConfigRequest)) *Client_PatchConfig_Call {
+func (_c *Client_GetGroups_Call) Run(run func()) *Client_GetGroups_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*model.PatchConfigRequest))
+		run()
 	})
 	return _c
 }
 
-func (_c *Client_PatchConfig_Call) Return(_a0 error) *Client_PatchConfig_Call {
-	_c.Call.Return(_a0)
+func (_c *Client_GetGroups_Call) Return(_a0 []model.Group, _a1 error) *Client_GetGroups_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Client_PatchConfig_Call) RunAndReturn(run func(*model.PatchConfigRequest) error) *Client_PatchConfig_Call {
+func (_c *Client_GetGroups_Call) RunAndReturn(run func() ([]model.Group, error)) *Client_GetGroups_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// PostAuth provides a mock function with no fields
-func (_m *Client) PostAuth() error {
+// GetLists provides a mock function with no fields
+func (_m *Client) GetLists() ([]model.List, error) {
 	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for PostAuth")
+		panic("no return value specified for GetLists")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
+	var r0 []model.List
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]model.List, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []model.List); ok {
 		r0 = rf()
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.List)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// Client_PostAuth_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PostAuth'
-type Client_PostAuth_Call struct {
+// Client_GetLists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLists'
+type Client_GetLists_Call struct {
 	*mock.Call
 }
 
-// PostAuth is a helper method to define mock.On call
-func (_e *Client_Expecter) PostAuth() *Client_PostAuth_Call {
-	return &Client_PostAuth_Call{Call: _e.mock.On("PostAuth")}
+// GetLists is a helper method to define mock.On call
+func (_e *Client_Expecter) GetLists() *Client_GetLists_Call {
+	return &Client_GetLists_Call{Call: _e.mock.On("GetLists")}
 }
 
-func (_c *Client_PostAuth_Call) Run(run func()) *Client_PostAuth_Call {
+func (_c *Client_GetLists_Call) Run(run func()) *Client_GetLists_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run()
 	})
 	return _c
 }
 
-func (_c *Client_PostAuth_Call) Return(_a0 error) *Client_PostAuth_Call {
-	_c.Call.Return(_a0)
+func (_c *Client_GetLists_Call) Return(_a0 []model.List, _a1 error) *Client_GetLists_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Client_PostAuth_Call) RunAndReturn(run func() error) *Client_PostAuth_Call {
+func (_c *Client_GetLists_Call) RunAndReturn(run func() ([]model.List, error)) *Client_GetLists_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// PostRunGravity provides a mock function with no fields
-func (_m *Client) PostRunGravity() error {
+// GetSummary provides a mock function with no fields
+func (_m *Client) GetSummary() (*model.SummaryResponse, error) {
 	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for PostRunGravity")
+		panic("no return value specified for GetSummary")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
+	var r0 *model.SummaryResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (*model.SummaryResponse, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() *model.SummaryResponse); ok {
 		r0 = rf()
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.SummaryResponse)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// Client_PostRunGravity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PostRunGravity'
-type Client_PostRunGravity_Call struct {
+// Client_GetSummary_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSummary'
+type Client_GetSummary_Call struct {
 	*mock.Call
 }
 
-// PostRunGravity is a helper method to define mock.On call
-func (_e *Client_Expecter) PostRunGravity() *Client_PostRunGravity_Call {
-	return &Client_PostRunGravity_Call{Call: _e.mock.On("PostRunGravity")}
+// GetSummary is a helper method to define mock.On call
+func (_e *Client_Expecter) GetSummary() *Client_GetSummary_Call {
+	return &Client_GetSummary_Call{Call: _e.mock.On("GetSummary")}
 }
 
-func (_c *Client_PostRunGravity_Call) Run(run func()) *Client_PostRunGravity_Call {
+func (_c *Client_GetSummary_Call) Run(run func()) *Client_GetSummary_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run()
 	})
 	return _c
 }
 
-func (_c *Client_PostRunGravity_Call) Return(_a0 error) *Client_PostRunGravity_Call {
-	_c.Call.Return(_a0)
+func (_c *Client_GetSummary_Call) Return(_a0 *model.SummaryResponse, _a1 error) *Client_GetSummary_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Client_PostRunGravity_Call) RunAndReturn(run func() error) *Client_PostRunGravity_Call {
+func (_c *Client_GetSummary_Call) RunAndReturn(run func() (*model.SummaryResponse, error)) *Client_GetSummary_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTeleporter provides a mock function with no fields
+func (_m *Client) GetTeleporter() ([]byte, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTeleporter")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]byte, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []byte); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_GetTeleporter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTeleporter'
+type Client_GetTeleporter_Call struct {
+	*mock.Call
+}
+
+// GetTeleporter is a helper method to define mock.On call
+func (_e *Client_Expecter) GetTeleporter() *Client_GetTeleporter_Call {
+	return &Client_GetTeleporter_Call{Call: _e.mock.On("GetTeleporter")}
+}
+
+func (_c *Client_GetTeleporter_Call) Run(run func()) *Client_GetTeleporter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Client_GetTeleporter_Call) Return(_a0 []byte, _a1 error) *Client_GetTeleporter_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_GetTeleporter_Call) RunAndReturn(run func() ([]byte, error)) *Client_GetTeleporter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetVersion provides a mock function with no fields
+func (_m *Client) GetVersion() (*model.VersionResponse, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVersion")
+	}
+
+	var r0 *model.VersionResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (*model.VersionResponse, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() *model.VersionResponse); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.VersionResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_GetVersion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVersion'
+type Client_GetVersion_Call struct {
+	*mock.Call
+}
+
+// GetVersion is a helper method to define mock.On call
+func (_e *Client_Expecter) GetVersion() *Client_GetVersion_Call {
+	return &Client_GetVersion_Call{Call: _e.mock.On("GetVersion")}
+}
+
+func (_c *Client_GetVersion_Call) Run(run func()) *Client_GetVersion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Client_GetVersion_Call) Return(_a0 *model.VersionResponse, _a1 error) *Client_GetVersion_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_GetVersion_Call) RunAndReturn(run func() (*model.VersionResponse, error)) *Client_GetVersion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PatchConfig provides a mock function with given fields: patchRequest
+func (_m *Client) PatchConfig(patchRequest *model.PatchConfigRequest) error {
+	ret := _m.Called(patchRequest)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PatchConfig")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.PatchConfigRequest) error); ok {
+		r0 = rf(patchRequest)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PatchConfig_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PatchConfig'
+type Client_PatchConfig_Call struct {
+	*mock.Call
+}
+
+// PatchConfig is a helper method to define mock.On call
+//   - patchRequest *model.PatchConfigRequest
+func (_e *Client_Expecter) PatchConfig(patchRequest interface{}) *Client_PatchConfig_Call {
+	return &Client_PatchConfig_Call{Call: _e.mock.On("PatchConfig", patchRequest)}
+}
+
+func (_c *Client_PatchConfig_Call) Run(run func(patchRequest *model.PatchConfigRequest)) *Client_PatchConfig_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.PatchConfigRequest))
+	})
+	return _c
+}
+
+func (_c *Client_PatchConfig_Call) Return(_a0 error) *Client_PatchConfig_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PatchConfig_Call) RunAndReturn(run func(*model.PatchConfigRequest) error) *Client_PatchConfig_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PostAuth provides a mock function with no fields
+func (_m *Client) PostAuth() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for PostAuth")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PostAuth_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PostAuth'
+type Client_PostAuth_Call struct {
+	*mock.Call
+}
+
+// PostAuth is a helper method to define mock.On call
+func (_e *Client_Expecter) PostAuth() *Client_PostAuth_Call {
+	return &Client_PostAuth_Call{Call: _e.mock.On("PostAuth")}
+}
+
+func (_c *Client_PostAuth_Call) Run(run func()) *Client_PostAuth_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Client_PostAuth_Call) Return(_a0 error) *Client_PostAuth_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PostAuth_Call) RunAndReturn(run func() error) *Client_PostAuth_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PostClient provides a mock function with given fields: client
+func (_m *Client) PostClient(client *model.Client) error {
+	ret := _m.Called(client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PostClient")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Client) error); ok {
+		r0 = rf(client)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PostClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PostClient'
+type Client_PostClient_Call struct {
+	*mock.Call
+}
+
+// PostClient is a helper method to define mock.On call
+//   - client *model.Client
+func (_e *Client_Expecter) PostClient(client interface{}) *Client_PostClient_Call {
+	return &Client_PostClient_Call{Call: _e.mock.On("PostClient", client)}
+}
+
+func (_c *Client_PostClient_Call) Run(run func(client *model.Client)) *Client_PostClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Client))
+	})
+	return _c
+}
+
+func (_c *Client_PostClient_Call) Return(_a0 error) *Client_PostClient_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PostClient_Call) RunAndReturn(run func(*model.Client) error) *Client_PostClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PostDomain provides a mock function with given fields: domain
+func (_m *Client) PostDomain(domain *model.Domain) error {
+	ret := _m.Called(domain)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PostDomain")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Domain) error); ok {
+		r0 = rf(domain)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PostDomain_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PostDomain'
+type Client_PostDomain_Call struct {
+	*mock.Call
+}
+
+// PostDomain is a helper method to define mock.On call
+//   - domain *model.Domain
+func (_e *Client_Expecter) PostDomain(domain interface{}) *Client_PostDomain_Call {
+	return &Client_PostDomain_Call{Call: _e.mock.On("PostDomain", domain)}
+}
+
+func (_c *Client_PostDomain_Call) Run(run func(domain *model.Domain)) *Client_PostDomain_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Domain))
+	})
+	return _c
+}
+
+func (_c *Client_PostDomain_Call) Return(_a0 error) *Client_PostDomain_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PostDomain_Call) RunAndReturn(run func(*model.Domain) error) *Client_PostDomain_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PostGroup provides a mock function with given fields: group
+func (_m *Client) PostGroup(group *model.Group) error {
+	ret := _m.Called(group)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PostGroup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Group) error); ok {
+		r0 = rf(group)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PostGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PostGroup'
+type Client_PostGroup_Call struct {
+	*mock.Call
+}
+
+// PostGroup is a helper method to define mock.On call
+//   - group *model.Group
+func (_e *Client_Expecter) PostGroup(group interface{}) *Client_PostGroup_Call {
+	return &Client_PostGroup_Call{Call: _e.mock.On("PostGroup", group)}
+}
+
+func (_c *Client_PostGroup_Call) Run(run func(group *model.Group)) *Client_PostGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Group))
+	})
+	return _c
+}
+
+func (_c *Client_PostGroup_Call) Return(_a0 error) *Client_PostGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PostGroup_Call) RunAndReturn(run func(*model.Group) error) *Client_PostGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PostList provides a mock function with given fields: list
+func (_m *Client) PostList(list *model.List) error {
+	ret := _m.Called(list)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PostList")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.List) error); ok {
+		r0 = rf(list)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PostList_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PostList'
+type Client_PostList_Call struct {
+	*mock.Call
+}
+
+// PostList is a helper method to define mock.On call
+//   - list *model.List
+func (_e *Client_Expecter) PostList(list interface{}) *Client_PostList_Call {
+	return &Client_PostList_Call{Call: _e.mock.On("PostList", list)}
+}
+
+func (_c *Client_PostList_Call) Run(run func(list *model.List)) *Client_PostList_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.List))
+	})
+	return _c
+}
+
+func (_c *Client_PostList_Call) Return(_a0 error) *Client_PostList_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PostList_Call) RunAndReturn(run func(*model.List) error) *Client_PostList_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PostRestartDNS provides a mock function with no fields
+func (_m *Client) PostRestartDNS() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for PostRestartDNS")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PostRestartDNS_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PostRestartDNS'
+type Client_PostRestartDNS_Call struct {
+	*mock.Call
+}
+
+// PostRestartDNS is a helper method to define mock.On call
+func (_e *Client_Expecter) PostRestartDNS() *Client_PostRestartDNS_Call {
+	return &Client_PostRestartDNS_Call{Call: _e.mock.On("PostRestartDNS")}
+}
+
+func (_c *Client_PostRestartDNS_Call) Run(run func()) *Client_PostRestartDNS_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Client_PostRestartDNS_Call) Return(_a0 error) *Client_PostRestartDNS_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PostRestartDNS_Call) RunAndReturn(run func() error) *Client_PostRestartDNS_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PostRunGravity provides a mock function with no fields
+func (_m *Client) PostRunGravity() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for PostRunGravity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PostRunGravity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PostRunGravity'
+type Client_PostRunGravity_Call struct {
+	*mock.Call
+}
+
+// PostRunGravity is a helper method to define mock.On call
+func (_e *Client_Expecter) PostRunGravity() *Client_PostRunGravity_Call {
+	return &Client_PostRunGravity_Call{Call: _e.mock.On("PostRunGravity")}
+}
+
+func (_c *Client_PostRunGravity_Call) Run(run func()) *Client_PostRunGravity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Client_PostRunGravity_Call) Return(_a0 error) *Client_PostRunGravity_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PostRunGravity_Call) RunAndReturn(run func() error) *Client_PostRunGravity_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -465,6 +1174,190 @@ func (_c *Client_PostTeleporter_Call) RunAndReturn(run func([]byte, *model.PostT
 	return _c
 }
 
+// PutClient provides a mock function with given fields: client
+func (_m *Client) PutClient(client *model.Client) error {
+	ret := _m.Called(client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutClient")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Client) error); ok {
+		r0 = rf(client)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PutClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutClient'
+type Client_PutClient_Call struct {
+	*mock.Call
+}
+
+// PutClient is a helper method to define mock.On call
+//   - client *model.Client
+func (_e *Client_Expecter) PutClient(client interface{}) *Client_PutClient_Call {
+	return &Client_PutClient_Call{Call: _e.mock.On("PutClient", client)}
+}
+
+func (_c *Client_PutClient_Call) Run(run func(client *model.Client)) *Client_PutClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Client))
+	})
+	return _c
+}
+
+func (_c *Client_PutClient_Call) Return(_a0 error) *Client_PutClient_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PutClient_Call) RunAndReturn(run func(*model.Client) error) *Client_PutClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PutDomain provides a mock function with given fields: domain
+func (_m *Client) PutDomain(domain *model.Domain) error {
+	ret := _m.Called(domain)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutDomain")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Domain) error); ok {
+		r0 = rf(domain)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PutDomain_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutDomain'
+type Client_PutDomain_Call struct {
+	*mock.Call
+}
+
+// PutDomain is a helper method to define mock.On call
+//   - domain *model.Domain
+func (_e *Client_Expecter) PutDomain(domain interface{}) *Client_PutDomain_Call {
+	return &Client_PutDomain_Call{Call: _e.mock.On("PutDomain", domain)}
+}
+
+func (_c *Client_PutDomain_Call) Run(run func(domain *model.Domain)) *Client_PutDomain_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Domain))
+	})
+	return _c
+}
+
+func (_c *Client_PutDomain_Call) Return(_a0 error) *Client_PutDomain_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PutDomain_Call) RunAndReturn(run func(*model.Domain) error) *Client_PutDomain_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PutGroup provides a mock function with given fields: group
+func (_m *Client) PutGroup(group *model.Group) error {
+	ret := _m.Called(group)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutGroup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Group) error); ok {
+		r0 = rf(group)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PutGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutGroup'
+type Client_PutGroup_Call struct {
+	*mock.Call
+}
+
+// PutGroup is a helper method to define mock.On call
+//   - group *model.Group
+func (_e *Client_Expecter) PutGroup(group interface{}) *Client_PutGroup_Call {
+	return &Client_PutGroup_Call{Call: _e.mock.On("PutGroup", group)}
+}
+
+func (_c *Client_PutGroup_Call) Run(run func(group *model.Group)) *Client_PutGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Group))
+	})
+	return _c
+}
+
+func (_c *Client_PutGroup_Call) Return(_a0 error) *Client_PutGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PutGroup_Call) RunAndReturn(run func(*model.Group) error) *Client_PutGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PutList provides a mock function with given fields: list
+func (_m *Client) PutList(list *model.List) error {
+	ret := _m.Called(list)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutList")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.List) error); ok {
+		r0 = rf(list)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PutList_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutList'
+type Client_PutList_Call struct {
+	*mock.Call
+}
+
+// PutList is a helper method to define mock.On call
+//   - list *model.List
+func (_e *Client_Expecter) PutList(list interface{}) *Client_PutList_Call {
+	return &Client_PutList_Call{Call: _e.mock.On("PutList", list)}
+}
+
+func (_c *Client_PutList_Call) Run(run func(list *model.List)) *Client_PutList_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.List))
+	})
+	return _c
+}
+
+func (_c *Client_PutList_Call) Return(_a0 error) *Client_PutList_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PutList_Call) RunAndReturn(run func(*model.List) error) *Client_PutList_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // String provides a mock function with no fields
 func (_m *Client) String() string {
 	ret := _m.Called()
@@ -0,0 +1,122 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package retry
+
+import mock "github.com/stretchr/testify/mock"
+
+// authError is an autogenerated mock type for the authError type
+type authError struct {
+	mock.Mock
+}
+
+type authError_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *authError) EXPECT() *authError_Expecter {
+	return &authError_Expecter{mock: &_m.Mock}
+}
+
+// Error provides a mock function with no fields
+func (_m *authError) Error() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Error")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// authError_Error_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Error'
+type authError_Error_Call struct {
+	*mock.Call
+}
+
+// Error is a helper method to define mock.On call
+func (_e *authError_Expecter) Error() *authError_Error_Call {
+	return &authError_Error_Call{Call: _e.mock.On("Error")}
+}
+
+func (_c *authError_Error_Call) Run(run func()) *authError_Error_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *authError_Error_Call) Return(_a0 string) *authError_Error_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *authError_Error_Call) RunAndReturn(run func() string) *authError_Error_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Unauthenticated provides a mock function with no fields
+func (_m *authError) Unauthenticated() bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Unauthenticated")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// authError_Unauthenticated_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Unauthenticated'
+type authError_Unauthenticated_Call struct {
+	*mock.Call
+}
+
+// Unauthenticated is a helper method to define mock.On call
+func (_e *authError_Expecter) Unauthenticated() *authError_Unauthenticated_Call {
+	return &authError_Unauthenticated_Call{Call: _e.mock.On("Unauthenticated")}
+}
+
+func (_c *authError_Unauthenticated_Call) Run(run func()) *authError_Unauthenticated_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *authError_Unauthenticated_Call) Return(_a0 bool) *authError_Unauthenticated_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *authError_Unauthenticated_Call) RunAndReturn(run func() bool) *authError_Unauthenticated_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newAuthError creates a new instance of authError. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newAuthError(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *authError {
+	mock := &authError{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,122 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package retry
+
+import mock "github.com/stretchr/testify/mock"
+
+// statusError is an autogenerated mock type for the statusError type
+type statusError struct {
+	mock.Mock
+}
+
+type statusError_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *statusError) EXPECT() *statusError_Expecter {
+	return &statusError_Expecter{mock: &_m.Mock}
+}
+
+// Error provides a mock function with no fields
+func (_m *statusError) Error() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Error")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// statusError_Error_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Error'
+type statusError_Error_Call struct {
+	*mock.Call
+}
+
+// Error is a helper method to define mock.On call
+func (_e *statusError_Expecter) Error() *statusError_Error_Call {
+	return &statusError_Error_Call{Call: _e.mock.On("Error")}
+}
+
+func (_c *statusError_Error_Call) Run(run func()) *statusError_Error_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *statusError_Error_Call) Return(_a0 string) *statusError_Error_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *statusError_Error_Call) RunAndReturn(run func() string) *statusError_Error_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StatusCode provides a mock function with no fields
+func (_m *statusError) StatusCode() int {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for StatusCode")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// statusError_StatusCode_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StatusCode'
+type statusError_StatusCode_Call struct {
+	*mock.Call
+}
+
+// StatusCode is a helper method to define mock.On call
+func (_e *statusError_Expecter) StatusCode() *statusError_StatusCode_Call {
+	return &statusError_StatusCode_Call{Call: _e.mock.On("StatusCode")}
+}
+
+func (_c *statusError_StatusCode_Call) Run(run func()) *statusError_StatusCode_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *statusError_StatusCode_Call) Return(_a0 int) *statusError_StatusCode_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *statusError_StatusCode_Call) RunAndReturn(run func() int) *statusError_StatusCode_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newStatusError creates a new instance of statusError. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newStatusError(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *statusError {
+	mock := &statusError{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
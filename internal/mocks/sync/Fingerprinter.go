@@ -0,0 +1,119 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package sync
+
+import mock "github.com/stretchr/testify/mock"
+
+// Fingerprinter is an autogenerated mock type for the Fingerprinter type
+type Fingerprinter struct {
+	mock.Mock
+}
+
+type Fingerprinter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Fingerprinter) EXPECT() *Fingerprinter_Expecter {
+	return &Fingerprinter_Expecter{mock: &_m.Mock}
+}
+
+// Close provides a mock function with no fields
+func (_m *Fingerprinter) Close() {
+	_m.Called()
+}
+
+// Fingerprinter_Close_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Close'
+type Fingerprinter_Close_Call struct {
+	*mock.Call
+}
+
+// Close is a helper method to define mock.On call
+func (_e *Fingerprinter_Expecter) Close() *Fingerprinter_Close_Call {
+	return &Fingerprinter_Close_Call{Call: _e.mock.On("Close")}
+}
+
+func (_c *Fingerprinter_Close_Call) Run(run func()) *Fingerprinter_Close_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Fingerprinter_Close_Call) Return() *Fingerprinter_Close_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Fingerprinter_Close_Call) RunAndReturn(run func()) *Fingerprinter_Close_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Fingerprint provides a mock function with no fields
+func (_m *Fingerprinter) Fingerprint() (string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Fingerprint")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Fingerprinter_Fingerprint_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Fingerprint'
+type Fingerprinter_Fingerprint_Call struct {
+	*mock.Call
+}
+
+// Fingerprint is a helper method to define mock.On call
+func (_e *Fingerprinter_Expecter) Fingerprint() *Fingerprinter_Fingerprint_Call {
+	return &Fingerprinter_Fingerprint_Call{Call: _e.mock.On("Fingerprint")}
+}
+
+func (_c *Fingerprinter_Fingerprint_Call) Run(run func()) *Fingerprinter_Fingerprint_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Fingerprinter_Fingerprint_Call) Return(_a0 string, _a1 error) *Fingerprinter_Fingerprint_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Fingerprinter_Fingerprint_Call) RunAndReturn(run func() (string, error)) *Fingerprinter_Fingerprint_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewFingerprinter creates a new instance of Fingerprinter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewFingerprinter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Fingerprinter {
+	mock := &Fingerprinter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
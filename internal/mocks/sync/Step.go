@@ -0,0 +1,366 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package sync
+
+import (
+	pihole "github.com/lovelaze/nebula-sync/internal/pihole"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Step is an autogenerated mock type for the Step type
+type Step struct {
+	mock.Mock
+}
+
+type Step_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Step) EXPECT() *Step_Expecter {
+	return &Step_Expecter{mock: &_m.Mock}
+}
+
+// Disruptive provides a mock function with no fields
+func (_m *Step) Disruptive() bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Disruptive")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Step_Disruptive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Disruptive'
+type Step_Disruptive_Call struct {
+	*mock.Call
+}
+
+// Disruptive is a helper method to define mock.On call
+func (_e *Step_Expecter) Disruptive() *Step_Disruptive_Call {
+	return &Step_Disruptive_Call{Call: _e.mock.On("Disruptive")}
+}
+
+func (_c *Step_Disruptive_Call) Run(run func()) *Step_Disruptive_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Step_Disruptive_Call) Return(_a0 bool) *Step_Disruptive_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Step_Disruptive_Call) RunAndReturn(run func() bool) *Step_Disruptive_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Enabled provides a mock function with no fields
+func (_m *Step) Enabled() bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Enabled")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Step_Enabled_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Enabled'
+type Step_Enabled_Call struct {
+	*mock.Call
+}
+
+// Enabled is a helper method to define mock.On call
+func (_e *Step_Expecter) Enabled() *Step_Enabled_Call {
+	return &Step_Enabled_Call{Call: _e.mock.On("Enabled")}
+}
+
+func (_c *Step_Enabled_Call) Run(run func()) *Step_Enabled_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Step_Enabled_Call) Return(_a0 bool) *Step_Enabled_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Step_Enabled_Call) RunAndReturn(run func() bool) *Step_Enabled_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Execute provides a mock function with given fields: replica
+func (_m *Step) Execute(replica pihole.Client) error {
+	ret := _m.Called(replica)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Execute")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(pihole.Client) error); ok {
+		r0 = rf(replica)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Step_Execute_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Execute'
+type Step_Execute_Call struct {
+	*mock.Call
+}
+
+// Execute is a helper method to define mock.On call
+//   - replica pihole.Client
+func (_e *Step_Expecter) Execute(replica interface{}) *Step_Execute_Call {
+	return &Step_Execute_Call{Call: _e.mock.On("Execute", replica)}
+}
+
+func (_c *Step_Execute_Call) Run(run func(replica pihole.Client)) *Step_Execute_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(pihole.Client))
+	})
+	return _c
+}
+
+func (_c *Step_Execute_Call) Return(_a0 error) *Step_Execute_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Step_Execute_Call) RunAndReturn(run func(pihole.Client) error) *Step_Execute_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Finish provides a mock function with no fields
+func (_m *Step) Finish() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Finish")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Step_Finish_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Finish'
+type Step_Finish_Call struct {
+	*mock.Call
+}
+
+// Finish is a helper method to define mock.On call
+func (_e *Step_Expecter) Finish() *Step_Finish_Call {
+	return &Step_Finish_Call{Call: _e.mock.On("Finish")}
+}
+
+func (_c *Step_Finish_Call) Run(run func()) *Step_Finish_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Step_Finish_Call) Return(_a0 error) *Step_Finish_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Step_Finish_Call) RunAndReturn(run func() error) *Step_Finish_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Name provides a mock function with no fields
+func (_m *Step) Name() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Name")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Step_Name_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Name'
+type Step_Name_Call struct {
+	*mock.Call
+}
+
+// Name is a helper method to define mock.On call
+func (_e *Step_Expecter) Name() *Step_Name_Call {
+	return &Step_Name_Call{Call: _e.mock.On("Name")}
+}
+
+func (_c *Step_Name_Call) Run(run func()) *Step_Name_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Step_Name_Call) Return(_a0 string) *Step_Name_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Step_Name_Call) RunAndReturn(run func() string) *Step_Name_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Prepare provides a mock function with given fields: replicas
+func (_m *Step) Prepare(replicas []pihole.Client) error {
+	ret := _m.Called(replicas)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Prepare")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]pihole.Client) error); ok {
+		r0 = rf(replicas)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Step_Prepare_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Prepare'
+type Step_Prepare_Call struct {
+	*mock.Call
+}
+
+// Prepare is a helper method to define mock.On call
+//   - replicas []pihole.Client
+func (_e *Step_Expecter) Prepare(replicas interface{}) *Step_Prepare_Call {
+	return &Step_Prepare_Call{Call: _e.mock.On("Prepare", replicas)}
+}
+
+func (_c *Step_Prepare_Call) Run(run func(replicas []pihole.Client)) *Step_Prepare_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]pihole.Client))
+	})
+	return _c
+}
+
+func (_c *Step_Prepare_Call) Return(_a0 error) *Step_Prepare_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Step_Prepare_Call) RunAndReturn(run func([]pihole.Client) error) *Step_Prepare_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Start provides a mock function with given fields: primary, replicas
+func (_m *Step) Start(primary pihole.Client, replicas []pihole.Client) ([]pihole.Client, error) {
+	ret := _m.Called(primary, replicas)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Start")
+	}
+
+	var r0 []pihole.Client
+	var r1 error
+	if rf, ok := ret.Get(0).(func(pihole.Client, []pihole.Client) ([]pihole.Client, error)); ok {
+		return rf(primary, replicas)
+	}
+	if rf, ok := ret.Get(0).(func(pihole.Client, []pihole.Client) []pihole.Client); ok {
+		r0 = rf(primary, replicas)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]pihole.Client)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(pihole.Client, []pihole.Client) error); ok {
+		r1 = rf(primary, replicas)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Step_Start_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Start'
+type Step_Start_Call struct {
+	*mock.Call
+}
+
+// Start is a helper method to define mock.On call
+//   - primary pihole.Client
+//   - replicas []pihole.Client
+func (_e *Step_Expecter) Start(primary interface{}, replicas interface{}) *Step_Start_Call {
+	return &Step_Start_Call{Call: _e.mock.On("Start", primary, replicas)}
+}
+
+func (_c *Step_Start_Call) Run(run func(primary pihole.Client, replicas []pihole.Client)) *Step_Start_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(pihole.Client), args[1].([]pihole.Client))
+	})
+	return _c
+}
+
+func (_c *Step_Start_Call) Return(_a0 []pihole.Client, _a1 error) *Step_Start_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Step_Start_Call) RunAndReturn(run func(pihole.Client, []pihole.Client) ([]pihole.Client, error)) *Step_Start_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewStep creates a new instance of Step. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewStep(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Step {
+	mock := &Step{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -4,6 +4,8 @@ package sync
 
 import (
 	config "github.com/lovelaze/nebula-sync/internal/config"
+	sync "github.com/lovelaze/nebula-sync/internal/sync"
+	journal "github.com/lovelaze/nebula-sync/internal/sync/journal"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -20,6 +22,111 @@ func (_m *Target) EXPECT() *Target_Expecter {
 	return &Target_Expecter{mock: &_m.Mock}
 }
 
+// BreakerEvents provides a mock function with no fields
+func (_m *Target) BreakerEvents() []sync.BreakerEvent {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for BreakerEvents")
+	}
+
+	var r0 []sync.BreakerEvent
+	if rf, ok := ret.Get(0).(func() []sync.BreakerEvent); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sync.BreakerEvent)
+		}
+	}
+
+	return r0
+}
+
+// Target_BreakerEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BreakerEvents'
+type Target_BreakerEvents_Call struct {
+	*mock.Call
+}
+
+// BreakerEvents is a helper method to define mock.On call
+func (_e *Target_Expecter) BreakerEvents() *Target_BreakerEvents_Call {
+	return &Target_BreakerEvents_Call{Call: _e.mock.On("BreakerEvents")}
+}
+
+func (_c *Target_BreakerEvents_Call) Run(run func()) *Target_BreakerEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Target_BreakerEvents_Call) Return(_a0 []sync.BreakerEvent) *Target_BreakerEvents_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Target_BreakerEvents_Call) RunAndReturn(run func() []sync.BreakerEvent) *Target_BreakerEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DetectDrift provides a mock function with given fields: _a0
+func (_m *Target) DetectDrift(_a0 *config.Sync) ([]sync.Report, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DetectDrift")
+	}
+
+	var r0 []sync.Report
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*config.Sync) ([]sync.Report, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(*config.Sync) []sync.Report); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sync.Report)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*config.Sync) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Target_DetectDrift_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DetectDrift'
+type Target_DetectDrift_Call struct {
+	*mock.Call
+}
+
+// DetectDrift is a helper method to define mock.On call
+//   - _a0 *config.Sync
+func (_e *Target_Expecter) DetectDrift(_a0 interface{}) *Target_DetectDrift_Call {
+	return &Target_DetectDrift_Call{Call: _e.mock.On("DetectDrift", _a0)}
+}
+
+func (_c *Target_DetectDrift_Call) Run(run func(_a0 *config.Sync)) *Target_DetectDrift_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*config.Sync))
+	})
+	return _c
+}
+
+func (_c *Target_DetectDrift_Call) Return(_a0 []sync.Report, _a1 error) *Target_DetectDrift_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Target_DetectDrift_Call) RunAndReturn(run func(*config.Sync) ([]sync.Report, error)) *Target_DetectDrift_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // FullSync provides a mock function with given fields: _a0
 func (_m *Target) FullSync(_a0 *config.Sync) error {
 	ret := _m.Called(_a0)
@@ -66,6 +173,86 @@ func (_c *Target_FullSync_Call) RunAndReturn(run func(*config.Sync) error) *Targ
 	return _c
 }
 
+// Inherit provides a mock function with given fields: previous
+func (_m *Target) Inherit(previous sync.Target) {
+	_m.Called(previous)
+}
+
+// Target_Inherit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Inherit'
+type Target_Inherit_Call struct {
+	*mock.Call
+}
+
+// Inherit is a helper method to define mock.On call
+//   - previous sync.Target
+func (_e *Target_Expecter) Inherit(previous interface{}) *Target_Inherit_Call {
+	return &Target_Inherit_Call{Call: _e.mock.On("Inherit", previous)}
+}
+
+func (_c *Target_Inherit_Call) Run(run func(previous sync.Target)) *Target_Inherit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(sync.Target))
+	})
+	return _c
+}
+
+func (_c *Target_Inherit_Call) Return() *Target_Inherit_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Target_Inherit_Call) RunAndReturn(run func(sync.Target)) *Target_Inherit_Call {
+	_c.Run(run)
+	return _c
+}
+
+// LastRun provides a mock function with no fields
+func (_m *Target) LastRun() *journal.Record {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for LastRun")
+	}
+
+	var r0 *journal.Record
+	if rf, ok := ret.Get(0).(func() *journal.Record); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*journal.Record)
+		}
+	}
+
+	return r0
+}
+
+// Target_LastRun_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LastRun'
+type Target_LastRun_Call struct {
+	*mock.Call
+}
+
+// LastRun is a helper method to define mock.On call
+func (_e *Target_Expecter) LastRun() *Target_LastRun_Call {
+	return &Target_LastRun_Call{Call: _e.mock.On("LastRun")}
+}
+
+func (_c *Target_LastRun_Call) Run(run func()) *Target_LastRun_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Target_LastRun_Call) Return(_a0 *journal.Record) *Target_LastRun_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Target_LastRun_Call) RunAndReturn(run func() *journal.Record) *Target_LastRun_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // SelectiveSync provides a mock function with given fields: _a0
 func (_m *Target) SelectiveSync(_a0 *config.Sync) error {
 	ret := _m.Called(_a0)
@@ -112,6 +299,52 @@ func (_c *Target_SelectiveSync_Call) RunAndReturn(run func(*config.Sync) error)
 	return _c
 }
 
+// ValidateConfig provides a mock function with given fields: _a0
+func (_m *Target) ValidateConfig(_a0 *config.Sync) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateConfig")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*config.Sync) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Target_ValidateConfig_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ValidateConfig'
+type Target_ValidateConfig_Call struct {
+	*mock.Call
+}
+
+// ValidateConfig is a helper method to define mock.On call
+//   - _a0 *config.Sync
+func (_e *Target_Expecter) ValidateConfig(_a0 interface{}) *Target_ValidateConfig_Call {
+	return &Target_ValidateConfig_Call{Call: _e.mock.On("ValidateConfig", _a0)}
+}
+
+func (_c *Target_ValidateConfig_Call) Run(run func(_a0 *config.Sync)) *Target_ValidateConfig_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*config.Sync))
+	})
+	return _c
+}
+
+func (_c *Target_ValidateConfig_Call) Return(_a0 error) *Target_ValidateConfig_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Target_ValidateConfig_Call) RunAndReturn(run func(*config.Sync) error) *Target_ValidateConfig_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewTarget creates a new instance of Target. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewTarget(t interface {
@@ -0,0 +1,68 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package sync
+
+import (
+	sync "github.com/lovelaze/nebula-sync/internal/sync"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// describer is an autogenerated mock type for the describer type
+type describer struct {
+	mock.Mock
+}
+
+type describer_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *describer) EXPECT() *describer_Expecter {
+	return &describer_Expecter{mock: &_m.Mock}
+}
+
+// Describe provides a mock function with given fields: result
+func (_m *describer) Describe(result *sync.StepResult) {
+	_m.Called(result)
+}
+
+// describer_Describe_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Describe'
+type describer_Describe_Call struct {
+	*mock.Call
+}
+
+// Describe is a helper method to define mock.On call
+//   - result *sync.StepResult
+func (_e *describer_Expecter) Describe(result interface{}) *describer_Describe_Call {
+	return &describer_Describe_Call{Call: _e.mock.On("Describe", result)}
+}
+
+func (_c *describer_Describe_Call) Run(run func(result *sync.StepResult)) *describer_Describe_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*sync.StepResult))
+	})
+	return _c
+}
+
+func (_c *describer_Describe_Call) Return() *describer_Describe_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *describer_Describe_Call) RunAndReturn(run func(*sync.StepResult)) *describer_Describe_Call {
+	_c.Run(run)
+	return _c
+}
+
+// newDescriber creates a new instance of describer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newDescriber(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *describer {
+	mock := &describer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,83 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package sync
+
+import (
+	probe "github.com/lovelaze/nebula-sync/internal/sync/probe"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// prober is an autogenerated mock type for the prober type
+type prober struct {
+	mock.Mock
+}
+
+type prober_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *prober) EXPECT() *prober_Expecter {
+	return &prober_Expecter{mock: &_m.Mock}
+}
+
+// Run provides a mock function with given fields: probes
+func (_m *prober) Run(probes []probe.Probe) []probe.Result {
+	ret := _m.Called(probes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Run")
+	}
+
+	var r0 []probe.Result
+	if rf, ok := ret.Get(0).(func([]probe.Probe) []probe.Result); ok {
+		r0 = rf(probes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]probe.Result)
+		}
+	}
+
+	return r0
+}
+
+// prober_Run_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Run'
+type prober_Run_Call struct {
+	*mock.Call
+}
+
+// Run is a helper method to define mock.On call
+//   - probes []probe.Probe
+func (_e *prober_Expecter) Run(probes interface{}) *prober_Run_Call {
+	return &prober_Run_Call{Call: _e.mock.On("Run", probes)}
+}
+
+func (_c *prober_Run_Call) Run(run func(probes []probe.Probe)) *prober_Run_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]probe.Probe))
+	})
+	return _c
+}
+
+func (_c *prober_Run_Call) Return(_a0 []probe.Result) *prober_Run_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *prober_Run_Call) RunAndReturn(run func([]probe.Probe) []probe.Result) *prober_Run_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newProber creates a new instance of prober. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newProber(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *prober {
+	mock := &prober{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,68 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package sync
+
+import (
+	retry "github.com/lovelaze/nebula-sync/internal/sync/retry"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// retrying is an autogenerated mock type for the retrying type
+type retrying struct {
+	mock.Mock
+}
+
+type retrying_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *retrying) EXPECT() *retrying_Expecter {
+	return &retrying_Expecter{mock: &_m.Mock}
+}
+
+// setRetries provides a mock function with given fields: retries
+func (_m *retrying) setRetries(retries *retry.Policies) {
+	_m.Called(retries)
+}
+
+// retrying_setRetries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'setRetries'
+type retrying_setRetries_Call struct {
+	*mock.Call
+}
+
+// setRetries is a helper method to define mock.On call
+//   - retries *retry.Policies
+func (_e *retrying_Expecter) setRetries(retries interface{}) *retrying_setRetries_Call {
+	return &retrying_setRetries_Call{Call: _e.mock.On("setRetries", retries)}
+}
+
+func (_c *retrying_setRetries_Call) Run(run func(retries *retry.Policies)) *retrying_setRetries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*retry.Policies))
+	})
+	return _c
+}
+
+func (_c *retrying_setRetries_Call) Return() *retrying_setRetries_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *retrying_setRetries_Call) RunAndReturn(run func(*retry.Policies)) *retrying_setRetries_Call {
+	_c.Run(run)
+	return _c
+}
+
+// newRetrying creates a new instance of retrying. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newRetrying(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *retrying {
+	mock := &retrying{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
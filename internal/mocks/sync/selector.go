@@ -0,0 +1,93 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package sync
+
+import (
+	pihole "github.com/lovelaze/nebula-sync/internal/pihole"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// selector is an autogenerated mock type for the selector type
+type selector struct {
+	mock.Mock
+}
+
+type selector_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *selector) EXPECT() *selector_Expecter {
+	return &selector_Expecter{mock: &_m.Mock}
+}
+
+// Select provides a mock function with given fields: replicas
+func (_m *selector) Select(replicas []pihole.Client) ([]pihole.Client, error) {
+	ret := _m.Called(replicas)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Select")
+	}
+
+	var r0 []pihole.Client
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]pihole.Client) ([]pihole.Client, error)); ok {
+		return rf(replicas)
+	}
+	if rf, ok := ret.Get(0).(func([]pihole.Client) []pihole.Client); ok {
+		r0 = rf(replicas)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]pihole.Client)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]pihole.Client) error); ok {
+		r1 = rf(replicas)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// selector_Select_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Select'
+type selector_Select_Call struct {
+	*mock.Call
+}
+
+// Select is a helper method to define mock.On call
+//   - replicas []pihole.Client
+func (_e *selector_Expecter) Select(replicas interface{}) *selector_Select_Call {
+	return &selector_Select_Call{Call: _e.mock.On("Select", replicas)}
+}
+
+func (_c *selector_Select_Call) Run(run func(replicas []pihole.Client)) *selector_Select_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]pihole.Client))
+	})
+	return _c
+}
+
+func (_c *selector_Select_Call) Return(_a0 []pihole.Client, _a1 error) *selector_Select_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *selector_Select_Call) RunAndReturn(run func([]pihole.Client) ([]pihole.Client, error)) *selector_Select_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newSelector creates a new instance of selector. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newSelector(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *selector {
+	mock := &selector{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -2,7 +2,10 @@
 
 package webhook
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	sync "github.com/lovelaze/nebula-sync/internal/sync"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // WebhookClient is an autogenerated mock type for the WebhookClient type
 type WebhookClient struct {
@@ -17,17 +20,63 @@ func (_m *WebhookClient) EXPECT() *WebhookClient_Expecter {
 	return &WebhookClient_Expecter{mock: &_m.Mock}
 }
 
-// Failure provides a mock function with no fields
-func (_m *WebhookClient) Failure() error {
-	ret := _m.Called()
+// Drift provides a mock function with given fields: reports
+func (_m *WebhookClient) Drift(reports []sync.Report) error {
+	ret := _m.Called(reports)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Drift")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]sync.Report) error); ok {
+		r0 = rf(reports)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WebhookClient_Drift_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Drift'
+type WebhookClient_Drift_Call struct {
+	*mock.Call
+}
+
+// Drift is a helper method to define mock.On call
+//   - reports []sync.Report
+func (_e *WebhookClient_Expecter) Drift(reports interface{}) *WebhookClient_Drift_Call {
+	return &WebhookClient_Drift_Call{Call: _e.mock.On("Drift", reports)}
+}
+
+func (_c *WebhookClient_Drift_Call) Run(run func(reports []sync.Report)) *WebhookClient_Drift_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]sync.Report))
+	})
+	return _c
+}
+
+func (_c *WebhookClient_Drift_Call) Return(_a0 error) *WebhookClient_Drift_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WebhookClient_Drift_Call) RunAndReturn(run func([]sync.Report) error) *WebhookClient_Drift_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Failure provides a mock function with given fields: err
+func (_m *WebhookClient) Failure(err error) error {
+	ret := _m.Called(err)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Failure")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(error) error); ok {
+		r0 = rf(err)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -41,13 +90,14 @@ type WebhookClient_Failure_Call struct {
 }
 
 // Failure is a helper method to define mock.On call
-func (_e *WebhookClient_Expecter) Failure() *WebhookClient_Failure_Call {
-	return &WebhookClient_Failure_Call{Call: _e.mock.On("Failure")}
+//   - err error
+func (_e *WebhookClient_Expecter) Failure(err interface{}) *WebhookClient_Failure_Call {
+	return &WebhookClient_Failure_Call{Call: _e.mock.On("Failure", err)}
 }
 
-func (_c *WebhookClient_Failure_Call) Run(run func()) *WebhookClient_Failure_Call {
+func (_c *WebhookClient_Failure_Call) Run(run func(err error)) *WebhookClient_Failure_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run()
+		run(args[0].(error))
 	})
 	return _c
 }
@@ -57,7 +107,99 @@ func (_c *WebhookClient_Failure_Call) Return(_a0 error) *WebhookClient_Failure_C
 	return _c
 }
 
-func (_c *WebhookClient_Failure_Call) RunAndReturn(run func() error) *WebhookClient_Failure_Call {
+func (_c *WebhookClient_Failure_Call) RunAndReturn(run func(error) error) *WebhookClient_Failure_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Quarantine provides a mock function with given fields: replica
+func (_m *WebhookClient) Quarantine(replica string) error {
+	ret := _m.Called(replica)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Quarantine")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(replica)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WebhookClient_Quarantine_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Quarantine'
+type WebhookClient_Quarantine_Call struct {
+	*mock.Call
+}
+
+// Quarantine is a helper method to define mock.On call
+//   - replica string
+func (_e *WebhookClient_Expecter) Quarantine(replica interface{}) *WebhookClient_Quarantine_Call {
+	return &WebhookClient_Quarantine_Call{Call: _e.mock.On("Quarantine", replica)}
+}
+
+func (_c *WebhookClient_Quarantine_Call) Run(run func(replica string)) *WebhookClient_Quarantine_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *WebhookClient_Quarantine_Call) Return(_a0 error) *WebhookClient_Quarantine_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WebhookClient_Quarantine_Call) RunAndReturn(run func(string) error) *WebhookClient_Quarantine_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Recovery provides a mock function with given fields: replica
+func (_m *WebhookClient) Recovery(replica string) error {
+	ret := _m.Called(replica)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Recovery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(replica)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WebhookClient_Recovery_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Recovery'
+type WebhookClient_Recovery_Call struct {
+	*mock.Call
+}
+
+// Recovery is a helper method to define mock.On call
+//   - replica string
+func (_e *WebhookClient_Expecter) Recovery(replica interface{}) *WebhookClient_Recovery_Call {
+	return &WebhookClient_Recovery_Call{Call: _e.mock.On("Recovery", replica)}
+}
+
+func (_c *WebhookClient_Recovery_Call) Run(run func(replica string)) *WebhookClient_Recovery_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *WebhookClient_Recovery_Call) Return(_a0 error) *WebhookClient_Recovery_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WebhookClient_Recovery_Call) RunAndReturn(run func(string) error) *WebhookClient_Recovery_Call {
 	_c.Call.Return(run)
 	return _c
 }
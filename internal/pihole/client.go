@@ -3,7 +3,6 @@ package pihole
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/lovelaze/nebula-sync/internal/pihole/model"
 	"github.com/lovelaze/nebula-sync/version"
@@ -18,12 +17,13 @@ var (
 	userAgent = fmt.Sprintf("nebula-sync/%s", version.Version)
 )
 
-func NewClient(piHole model.PiHole, httpClient *http.Client) Client {
+func NewClient(piHole model.PiHole, httpClient *http.Client, timeouts Timeouts) Client {
 	logger := log.With().Str("client", piHole.Url.String()).Logger()
 	return &client{
 		piHole:     piHole,
 		logger:     &logger,
 		httpClient: httpClient,
+		timeouts:   timeouts,
 	}
 }
 
@@ -34,8 +34,26 @@ type Client interface {
 	GetTeleporter() ([]byte, error)
 	PostTeleporter(payload []byte, teleporterRequest *model.PostTeleporterRequest) error
 	GetConfig() (configResponse *model.ConfigResponse, err error)
+	GetConfigSchema() (*model.ConfigSchemaResponse, error)
 	PatchConfig(patchRequest *model.PatchConfigRequest) error
 	PostRunGravity() error
+	PostRestartDNS() error
+	GetSummary() (*model.SummaryResponse, error)
+	GetGroups() ([]model.Group, error)
+	GetLists() ([]model.List, error)
+	GetDomains() ([]model.Domain, error)
+	GetClients() ([]model.Client, error)
+	PostGroup(group *model.Group) error
+	PutGroup(group *model.Group) error
+	PostList(list *model.List) error
+	PutList(list *model.List) error
+	DeleteList(list *model.List) error
+	PostDomain(domain *model.Domain) error
+	PutDomain(domain *model.Domain) error
+	DeleteDomain(domain *model.Domain) error
+	PostClient(client *model.Client) error
+	PutClient(client *model.Client) error
+	DeleteClient(client *model.Client) error
 	String() string
 	ApiPath(target string) string
 }
@@ -45,6 +63,7 @@ type client struct {
 	auth       auth
 	logger     *zerolog.Logger
 	httpClient *http.Client
+	timeouts   Timeouts
 }
 
 type auth struct {
@@ -56,7 +75,7 @@ type auth struct {
 
 func (a *auth) verify() error {
 	if !a.valid {
-		return errors.New("invalid sid found")
+		return &AuthError{Reason: "invalid sid found"}
 	}
 
 	return nil
@@ -80,10 +99,11 @@ func (client *client) PostAuth() error {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", userAgent)
 
-	response, err := client.httpClient.Do(req)
+	response, err := client.do(req, client.timeouts.Auth, 0)
 	if err != nil {
 		return client.wrapError(err, req)
 	}
+	defer response.Body.Close()
 
 	if err := successfulHttpStatus(response.StatusCode); err != nil {
 		return client.wrapError(err, req)
@@ -127,11 +147,12 @@ func (client *client) DeleteSession() error {
 	req.Header.Set("sid", client.auth.sid)
 	req.Header.Set("User-Agent", userAgent)
 
-	response, err := client.httpClient.Do(req)
+	response, err := client.do(req, client.timeouts.Auth, 0)
 
 	if err != nil {
 		return client.wrapError(err, req)
 	}
+	defer response.Body.Close()
 
 	if err := successfulHttpStatus(response.StatusCode); err != nil {
 		return client.wrapError(err, req)
@@ -154,10 +175,11 @@ func (client *client) GetVersion() (*model.VersionResponse, error) {
 	req.Header.Set("sid", client.auth.sid)
 	req.Header.Set("User-Agent", userAgent)
 
-	response, err := client.httpClient.Do(req)
+	response, err := client.do(req, client.timeouts.Default, 0)
 	if err != nil {
 		return &versionResponse, client.wrapError(err, req)
 	}
+	defer response.Body.Close()
 
 	if err := successfulHttpStatus(response.StatusCode); err != nil {
 		return &versionResponse, client.wrapError(err, req)
@@ -185,10 +207,11 @@ func (client *client) GetTeleporter() ([]byte, error) {
 	req.Header.Set("sid", client.auth.sid)
 	req.Header.Set("User-Agent", userAgent)
 
-	response, err := client.httpClient.Do(req)
+	response, err := client.do(req, client.timeouts.Teleporter, client.timeouts.Idle)
 	if err != nil {
 		return nil, client.wrapError(err, req)
 	}
+	defer response.Body.Close()
 
 	if err := successfulHttpStatus(response.StatusCode); err != nil {
 		return nil, client.wrapError(err, req)
@@ -235,10 +258,11 @@ func (client *client) PostTeleporter(payload []byte, teleporterRequest *model.Po
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("User-Agent", userAgent)
 
-	response, err := client.httpClient.Do(req)
+	response, err := client.do(req, client.timeouts.Teleporter, client.timeouts.Idle)
 	if err != nil {
 		return client.wrapError(err, req)
 	}
+	defer response.Body.Close()
 
 	if err := successfulHttpStatus(response.StatusCode); err != nil {
 		return client.wrapError(err, req)
@@ -260,10 +284,11 @@ func (client *client) GetConfig() (configResponse *model.ConfigResponse, err err
 	req.Header.Set("sid", client.auth.sid)
 	req.Header.Set("User-Agent", userAgent)
 
-	response, err := client.httpClient.Do(req)
+	response, err := client.do(req, client.timeouts.Config, 0)
 	if err != nil {
 		return configResponse, client.wrapError(err, req)
 	}
+	defer response.Body.Close()
 
 	if err := successfulHttpStatus(response.StatusCode); err != nil {
 		return configResponse, client.wrapError(err, req)
@@ -281,6 +306,15 @@ func (client *client) GetConfig() (configResponse *model.ConfigResponse, err err
 	return configResponse, client.wrapError(err, req)
 }
 
+func (client *client) GetConfigSchema() (*model.ConfigSchemaResponse, error) {
+	client.logger.Debug().Msg("Get config schema")
+	schemaResponse := model.ConfigSchemaResponse{}
+	if err := client.getJsonUrl(client.ApiPath("config")+"?detailed=true", client.timeouts.Config, &schemaResponse); err != nil {
+		return nil, err
+	}
+	return &schemaResponse, nil
+}
+
 func (client *client) PatchConfig(patchRequest *model.PatchConfigRequest) error {
 	client.logger.Debug().Any("payload", patchRequest).Msgf("Patch config")
 	if err := client.auth.verify(); err != nil {
@@ -299,10 +333,11 @@ func (client *client) PatchConfig(patchRequest *model.PatchConfigRequest) error
 	req.Header.Set("sid", client.auth.sid)
 	req.Header.Set("User-Agent", userAgent)
 
-	response, err := client.httpClient.Do(req)
+	response, err := client.do(req, client.timeouts.Config, 0)
 	if err != nil {
 		return client.wrapError(err, req)
 	}
+	defer response.Body.Close()
 
 	if err := successfulHttpStatus(response.StatusCode); err != nil {
 		return client.wrapError(err, req)
@@ -324,10 +359,39 @@ func (client *client) PostRunGravity() error {
 	req.Header.Set("sid", client.auth.sid)
 	req.Header.Set("User-Agent", userAgent)
 
-	response, err := client.httpClient.Do(req)
+	response, err := client.do(req, client.timeouts.Gravity, client.timeouts.Idle)
+	if err != nil {
+		return client.wrapError(err, req)
+	}
+	defer response.Body.Close()
+
+	if err := successfulHttpStatus(response.StatusCode); err != nil {
+		return client.wrapError(err, req)
+	}
+
+	// the gravity output is streamed until the run completes
+	_, err = io.Copy(io.Discard, response.Body)
+	return client.wrapError(err, req)
+}
+
+func (client *client) PostRestartDNS() error {
+	client.logger.Debug().Msg("Post restart dns")
+	if err := client.auth.verify(); err != nil {
+		return client.wrapError(err, nil)
+	}
+
+	req, err := http.NewRequest("POST", client.ApiPath("action/restartdns"), nil)
+	if err != nil {
+		return client.wrapError(err, req)
+	}
+	req.Header.Set("sid", client.auth.sid)
+	req.Header.Set("User-Agent", userAgent)
+
+	response, err := client.do(req, client.timeouts.Default, 0)
 	if err != nil {
 		return client.wrapError(err, req)
 	}
+	defer response.Body.Close()
 
 	if err := successfulHttpStatus(response.StatusCode); err != nil {
 		return client.wrapError(err, req)
@@ -354,10 +418,38 @@ func (client *client) wrapError(err error, req *http.Request) error {
 	return nil
 }
 
+// StatusError is returned for responses with an unsuccessful http status.
+type StatusError struct {
+	Status int
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", err.Status)
+}
+
+// StatusCode returns the http status of the response, it lets retry.Classify classify the error.
+func (err *StatusError) StatusCode() int {
+	return err.Status
+}
+
+// AuthError is returned when the Pi-hole did not grant a valid session.
+type AuthError struct {
+	Reason string
+}
+
+func (err *AuthError) Error() string {
+	return err.Reason
+}
+
+// Unauthenticated tells that the request lacked a valid session, it lets retry.Classify classify the error.
+func (err *AuthError) Unauthenticated() bool {
+	return true
+}
+
 func successfulHttpStatus(statusCode int) error {
 	if statusCode >= 200 && statusCode <= 299 {
 		return nil
 	}
 
-	return fmt.Errorf("unexpected status code: %d", statusCode)
+	return &StatusError{Status: statusCode}
 }
@@ -90,17 +90,16 @@ func (suite *clientTestSuite) TestClient_GetConfig() {
 	assert.NotNil(suite.T(), conf)
 }
 
+func (suite *clientTestSuite) TestClient_GetConfigSchema() {
+	schema, err := suite.client.GetConfigSchema()
+
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), schema.Keys(), "dns.upstreams")
+}
+
 func (suite *clientTestSuite) TestClient_PatchConfig() {
 	request := model.PatchConfigRequest{
-		Config: model.PatchConfig{
-			DNS:      nil,
-			DHCP:     nil,
-			NTP:      nil,
-			Resolver: nil,
-			Database: nil,
-			Misc:     nil,
-			Debug:    nil,
-		}}
+		Config: model.PatchConfig{}}
 	err := suite.client.PatchConfig(&request)
 
 	assert.NoError(suite.T(), err)
@@ -112,16 +111,89 @@ func (suite *clientTestSuite) TestClient_PostRunGravity() {
 	assert.NoError(suite.T(), err)
 }
 
+func (suite *clientTestSuite) TestClient_PostRestartDNS() {
+	err := suite.client.PostRestartDNS()
+
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *clientTestSuite) TestClient_GetSummary() {
+	summary, err := suite.client.GetSummary()
+
+	assert.NoError(suite.T(), err)
+	assert.NotZero(suite.T(), summary.Gravity.LastUpdate)
+}
+
+func (suite *clientTestSuite) TestClient_GetGroups() {
+	groups, err := suite.client.GetGroups()
+
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), groups)
+}
+
+func (suite *clientTestSuite) TestClient_GetLists() {
+	_, err := suite.client.GetLists()
+
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *clientTestSuite) TestClient_GetDomains() {
+	_, err := suite.client.GetDomains()
+
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *clientTestSuite) TestClient_GetClients() {
+	_, err := suite.client.GetClients()
+
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *clientTestSuite) TestClient_GravityEntities() {
+	group := model.Group{Name: "nebula-sync", Enabled: true}
+	require.NoError(suite.T(), suite.client.PostGroup(&group))
+	require.NoError(suite.T(), suite.client.PutGroup(&group))
+
+	domain := model.Domain{Domain: "nebula-sync.example.com", Type: "deny", Kind: "exact", Groups: []int{0}, Enabled: true}
+	require.NoError(suite.T(), suite.client.PostDomain(&domain))
+	domain.Enabled = false
+	require.NoError(suite.T(), suite.client.PutDomain(&domain))
+
+	domains, err := suite.client.GetDomains()
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), domainNames(domains), domain.Domain)
+
+	assert.NoError(suite.T(), suite.client.DeleteDomain(&domain))
+
+	c := model.Client{Client: "10.1.2.3", Groups: []int{0}}
+	require.NoError(suite.T(), suite.client.PostClient(&c))
+	require.NoError(suite.T(), suite.client.PutClient(&c))
+	assert.NoError(suite.T(), suite.client.DeleteClient(&c))
+
+	list := model.List{Address: "https://nebula-sync.example.com/list.txt", Type: "block", Groups: []int{0}, Enabled: true}
+	require.NoError(suite.T(), suite.client.PostList(&list))
+	require.NoError(suite.T(), suite.client.PutList(&list))
+	assert.NoError(suite.T(), suite.client.DeleteList(&list))
+}
+
+func domainNames(domains []model.Domain) []string {
+	var names []string
+	for _, domain := range domains {
+		names = append(names, domain.Domain)
+	}
+	return names
+}
+
 func TestClient_String(t *testing.T) {
 	piHole := model.NewPiHole("http://asdfasdf.com:1234", apiPassword)
-	s := NewClient(piHole, httpClient).String()
+	s := NewClient(piHole, httpClient, Timeouts{}).String()
 
 	assert.Equal(t, "http://asdfasdf.com:1234", s)
 }
 
 func TestClient_ApiPath(t *testing.T) {
 	piHole := model.NewPiHole("http://asdfasdf.com:1234", apiPassword)
-	c := NewClient(piHole, httpClient)
+	c := NewClient(piHole, httpClient, Timeouts{})
 
 	url := c.String()
 	path := c.ApiPath("testing")
@@ -151,5 +223,5 @@ func createClient(container tc.Container) Client {
 
 	host := fmt.Sprintf("http://localhost:%s", apiPort.Port())
 
-	return NewClient(model.NewPiHole(host, apiPassword), httpClient)
+	return NewClient(model.NewPiHole(host, apiPassword), httpClient, Timeouts{})
 }
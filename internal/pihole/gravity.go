@@ -0,0 +1,214 @@
+package pihole
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+)
+
+func (client *client) GetGroups() ([]model.Group, error) {
+	client.logger.Debug().Msg("Get groups")
+	groupsResponse := model.GroupsResponse{}
+	err := client.getJson("groups", &groupsResponse)
+	return groupsResponse.Groups, err
+}
+
+func (client *client) GetLists() ([]model.List, error) {
+	client.logger.Debug().Msg("Get lists")
+	listsResponse := model.ListsResponse{}
+	err := client.getJson("lists", &listsResponse)
+	return listsResponse.Lists, err
+}
+
+func (client *client) GetDomains() ([]model.Domain, error) {
+	client.logger.Debug().Msg("Get domains")
+	domainsResponse := model.DomainsResponse{}
+	err := client.getJson("domains", &domainsResponse)
+	return domainsResponse.Domains, err
+}
+
+func (client *client) GetClients() ([]model.Client, error) {
+	client.logger.Debug().Msg("Get clients")
+	clientsResponse := model.ClientsResponse{}
+	err := client.getJson("clients", &clientsResponse)
+	return clientsResponse.Clients, err
+}
+
+func (client *client) GetSummary() (*model.SummaryResponse, error) {
+	client.logger.Debug().Msg("Get summary")
+	summaryResponse := model.SummaryResponse{}
+	if err := client.getJson("stats/summary", &summaryResponse); err != nil {
+		return nil, err
+	}
+	return &summaryResponse, nil
+}
+
+func (client *client) PostGroup(group *model.Group) error {
+	client.logger.Debug().Str("group", group.Name).Msg("Post group")
+	return client.sendJson("POST", client.ApiPath("groups"), newGroupRequest(group))
+}
+
+func (client *client) PutGroup(group *model.Group) error {
+	client.logger.Debug().Str("group", group.Name).Msg("Put group")
+	return client.sendJson("PUT", client.ApiPath("groups/"+url.PathEscape(group.Name)), newGroupRequest(group))
+}
+
+func (client *client) PostList(list *model.List) error {
+	client.logger.Debug().Str("list", list.Address).Msg("Post list")
+	return client.sendJson("POST", client.ApiPath("lists")+"?type="+url.QueryEscape(list.Type), newListRequest(list))
+}
+
+func (client *client) PutList(list *model.List) error {
+	client.logger.Debug().Str("list", list.Address).Msg("Put list")
+	return client.sendJson("PUT", client.listPath(list), newListRequest(list))
+}
+
+func (client *client) DeleteList(list *model.List) error {
+	client.logger.Debug().Str("list", list.Address).Msg("Delete list")
+	return client.sendJson("DELETE", client.listPath(list), nil)
+}
+
+func (client *client) PostDomain(domain *model.Domain) error {
+	client.logger.Debug().Str("domain", domain.Domain).Msg("Post domain")
+	return client.sendJson("POST", client.ApiPath("domains/"+url.PathEscape(domain.Type)+"/"+url.PathEscape(domain.Kind)), newDomainRequest(domain))
+}
+
+func (client *client) PutDomain(domain *model.Domain) error {
+	client.logger.Debug().Str("domain", domain.Domain).Msg("Put domain")
+	return client.sendJson("PUT", client.domainPath(domain), newDomainRequest(domain))
+}
+
+func (client *client) DeleteDomain(domain *model.Domain) error {
+	client.logger.Debug().Str("domain", domain.Domain).Msg("Delete domain")
+	return client.sendJson("DELETE", client.domainPath(domain), nil)
+}
+
+func (client *client) PostClient(c *model.Client) error {
+	client.logger.Debug().Str("client", c.Client).Msg("Post client")
+	return client.sendJson("POST", client.ApiPath("clients"), newClientRequest(c))
+}
+
+func (client *client) PutClient(c *model.Client) error {
+	client.logger.Debug().Str("client", c.Client).Msg("Put client")
+	return client.sendJson("PUT", client.ApiPath("clients/"+url.PathEscape(c.Client)), newClientRequest(c))
+}
+
+func (client *client) DeleteClient(c *model.Client) error {
+	client.logger.Debug().Str("client", c.Client).Msg("Delete client")
+	return client.sendJson("DELETE", client.ApiPath("clients/"+url.PathEscape(c.Client)), nil)
+}
+
+func (client *client) listPath(list *model.List) string {
+	return client.ApiPath("lists/"+url.PathEscape(list.Address)) + "?type=" + url.QueryEscape(list.Type)
+}
+
+func (client *client) domainPath(domain *model.Domain) string {
+	return client.ApiPath("domains/" + url.PathEscape(domain.Type) + "/" + url.PathEscape(domain.Kind) + "/" + url.PathEscape(domain.Domain))
+}
+
+func newGroupRequest(group *model.Group) *model.GroupRequest {
+	return &model.GroupRequest{
+		Name:    group.Name,
+		Comment: group.Comment,
+		Enabled: group.Enabled,
+	}
+}
+
+func newListRequest(list *model.List) *model.ListRequest {
+	return &model.ListRequest{
+		Address: list.Address,
+		Type:    list.Type,
+		Comment: list.Comment,
+		Groups:  list.Groups,
+		Enabled: list.Enabled,
+	}
+}
+
+func newDomainRequest(domain *model.Domain) *model.DomainRequest {
+	return &model.DomainRequest{
+		Domain:  domain.Domain,
+		Type:    domain.Type,
+		Kind:    domain.Kind,
+		Comment: domain.Comment,
+		Groups:  domain.Groups,
+		Enabled: domain.Enabled,
+	}
+}
+
+func newClientRequest(c *model.Client) *model.ClientRequest {
+	return &model.ClientRequest{
+		Client:  c.Client,
+		Comment: c.Comment,
+		Groups:  c.Groups,
+	}
+}
+
+func (client *client) sendJson(method, path string, v interface{}) error {
+	if err := client.auth.verify(); err != nil {
+		return client.wrapError(err, nil)
+	}
+
+	var body io.Reader
+	if v != nil {
+		reqBytes, err := json.Marshal(v)
+		if err != nil {
+			return client.wrapError(err, nil)
+		}
+		body = bytes.NewReader(reqBytes)
+	}
+
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		return client.wrapError(err, req)
+	}
+	req.Header.Set("sid", client.auth.sid)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	response, err := client.do(req, client.timeouts.Default, 0)
+	if err != nil {
+		return client.wrapError(err, req)
+	}
+	defer response.Body.Close()
+
+	return client.wrapError(successfulHttpStatus(response.StatusCode), req)
+}
+
+func (client *client) getJson(path string, v interface{}) error {
+	return client.getJsonUrl(client.ApiPath(path), client.timeouts.Default, v)
+}
+
+func (client *client) getJsonUrl(url string, timeout time.Duration, v interface{}) error {
+	if err := client.auth.verify(); err != nil {
+		return client.wrapError(err, nil)
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return client.wrapError(err, req)
+	}
+	req.Header.Set("sid", client.auth.sid)
+	req.Header.Set("User-Agent", userAgent)
+
+	response, err := client.do(req, timeout, 0)
+	if err != nil {
+		return client.wrapError(err, req)
+	}
+	defer response.Body.Close()
+
+	if err := successfulHttpStatus(response.StatusCode); err != nil {
+		return client.wrapError(err, req)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return client.wrapError(err, req)
+	}
+
+	return client.wrapError(json.Unmarshal(body, v), req)
+}
@@ -0,0 +1,44 @@
+package model
+
+type Group struct {
+	Id           int     `json:"id"`
+	Name         string  `json:"name"`
+	Comment      *string `json:"comment"`
+	Enabled      bool    `json:"enabled"`
+	DateAdded    int64   `json:"date_added"`
+	DateModified int64   `json:"date_modified"`
+}
+
+type List struct {
+	Id           int     `json:"id"`
+	Address      string  `json:"address"`
+	Type         string  `json:"type"`
+	Comment      *string `json:"comment"`
+	Groups       []int   `json:"groups"`
+	Enabled      bool    `json:"enabled"`
+	DateAdded    int64   `json:"date_added"`
+	DateModified int64   `json:"date_modified"`
+	DateUpdated  int64   `json:"date_updated"`
+}
+
+type Domain struct {
+	Id           int     `json:"id"`
+	Domain       string  `json:"domain"`
+	Type         string  `json:"type"`
+	Kind         string  `json:"kind"`
+	Comment      *string `json:"comment"`
+	Groups       []int   `json:"groups"`
+	Enabled      bool    `json:"enabled"`
+	DateAdded    int64   `json:"date_added"`
+	DateModified int64   `json:"date_modified"`
+}
+
+type Client struct {
+	Id           int     `json:"id"`
+	Client       string  `json:"client"`
+	Name         *string `json:"name"`
+	Comment      *string `json:"comment"`
+	Groups       []int   `json:"groups"`
+	DateAdded    int64   `json:"date_added"`
+	DateModified int64   `json:"date_modified"`
+}
@@ -20,16 +20,38 @@ type PostTeleporterRequest struct {
 	Gravity    PostGravityRequest `json:"gravity"`
 }
 
-type PatchConfig struct {
-	DNS      map[string]interface{} `json:"dns"`
-	DHCP     map[string]interface{} `json:"dhcp"`
-	NTP      map[string]interface{} `json:"ntp"`
-	Resolver map[string]interface{} `json:"resolver"`
-	Database map[string]interface{} `json:"database"`
-	Misc     map[string]interface{} `json:"misc"`
-	Debug    map[string]interface{} `json:"debug"`
-}
+// PatchConfig holds the config values to patch by config section, e.g. "dns".
+type PatchConfig map[string]map[string]interface{}
 
 type PatchConfigRequest struct {
 	Config PatchConfig `json:"config"`
 }
+
+type GroupRequest struct {
+	Name    string  `json:"name"`
+	Comment *string `json:"comment"`
+	Enabled bool    `json:"enabled"`
+}
+
+type ListRequest struct {
+	Address string  `json:"address"`
+	Type    string  `json:"type"`
+	Comment *string `json:"comment"`
+	Groups  []int   `json:"groups"`
+	Enabled bool    `json:"enabled"`
+}
+
+type DomainRequest struct {
+	Domain  string  `json:"domain"`
+	Type    string  `json:"type"`
+	Kind    string  `json:"kind"`
+	Comment *string `json:"comment"`
+	Groups  []int   `json:"groups"`
+	Enabled bool    `json:"enabled"`
+}
+
+type ClientRequest struct {
+	Client  string  `json:"client"`
+	Comment *string `json:"comment"`
+	Groups  []int   `json:"groups"`
+}
@@ -72,3 +72,71 @@ func (c *ConfigResponse) Get(key string) map[string]interface{} {
 	}
 	return value.(map[string]interface{})
 }
+
+// ConfigSchemaResponse is the detailed config response, where each config key is described by its type,
+// value and flags.
+type ConfigSchemaResponse struct {
+	Config map[string]interface{} `json:"config"`
+}
+
+type ConfigKey struct {
+	Type    string
+	Value   interface{}
+	Restart bool
+}
+
+// Keys returns the described config keys by their dotted path, e.g. "dns.upstreams".
+func (c *ConfigSchemaResponse) Keys() map[string]ConfigKey {
+	keys := make(map[string]ConfigKey)
+	describeKeys("", c.Config, keys)
+	return keys
+}
+
+func describeKeys(prefix string, json map[string]interface{}, keys map[string]ConfigKey) {
+	for name, value := range json {
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		keyType, isKey := nested["type"].(string)
+		if _, hasValue := nested["value"]; !isKey || !hasValue {
+			describeKeys(path, nested, keys)
+			continue
+		}
+
+		key := ConfigKey{Type: keyType, Value: nested["value"]}
+		if flags, ok := nested["flags"].(map[string]interface{}); ok {
+			key.Restart, _ = flags["restart_dnsmasq"].(bool)
+		}
+		keys[path] = key
+	}
+}
+
+type GroupsResponse struct {
+	Groups []Group `json:"groups"`
+}
+
+type ListsResponse struct {
+	Lists []List `json:"lists"`
+}
+
+type DomainsResponse struct {
+	Domains []Domain `json:"domains"`
+}
+
+type ClientsResponse struct {
+	Clients []Client `json:"clients"`
+}
+
+type SummaryResponse struct {
+	Gravity struct {
+		DomainsBeingBlocked int   `json:"domains_being_blocked"`
+		LastUpdate          int64 `json:"last_update"`
+	} `json:"gravity"`
+}
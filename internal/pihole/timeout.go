@@ -0,0 +1,133 @@
+package pihole
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Timeouts limit the time of a request per operation, zero means no limit.
+type Timeouts struct {
+	Auth       time.Duration
+	Config     time.Duration
+	Teleporter time.Duration
+	Gravity    time.Duration
+	// Default applies to the operations without a timeout of their own.
+	Default time.Duration
+	// Idle aborts teleporter and gravity requests that send or receive nothing for this long.
+	Idle time.Duration
+}
+
+// TimeoutError is returned when a request exceeds its timeout, or makes no progress within the idle timeout.
+type TimeoutError struct {
+	Limit time.Duration
+	Idle  bool
+}
+
+func (err *TimeoutError) Error() string {
+	if err.Idle {
+		return fmt.Sprintf("no progress for %s", err.Limit)
+	}
+	return fmt.Sprintf("timed out after %s", err.Limit)
+}
+
+// Timeout reports the error as a timeout, like net.Error.
+func (err *TimeoutError) Timeout() bool {
+	return true
+}
+
+// deadline cancels a request when its timeout passes, or when its idle timeout passes without progress.
+type deadline struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	total  *time.Timer
+	idle   *time.Timer
+	limit  time.Duration
+}
+
+func newDeadline(parent context.Context, timeout, idle time.Duration) *deadline {
+	ctx, cancel := context.WithCancelCause(parent)
+	d := &deadline{ctx: ctx, cancel: cancel, limit: idle}
+	if timeout > 0 {
+		d.total = time.AfterFunc(timeout, func() { cancel(&TimeoutError{Limit: timeout}) })
+	}
+	if idle > 0 {
+		d.idle = time.AfterFunc(idle, func() { cancel(&TimeoutError{Limit: idle, Idle: true}) })
+	}
+	return d
+}
+
+func (d *deadline) progress() {
+	if d.idle != nil {
+		d.idle.Reset(d.limit)
+	}
+}
+
+func (d *deadline) stop() {
+	if d.total != nil {
+		d.total.Stop()
+	}
+	if d.idle != nil {
+		d.idle.Stop()
+	}
+	d.cancel(nil)
+}
+
+// err replaces the error of a cancelled request with the timeout that cancelled it.
+func (d *deadline) err(err error) error {
+	var timeout *TimeoutError
+	if err != nil && errors.As(context.Cause(d.ctx), &timeout) {
+		return timeout
+	}
+	return err
+}
+
+// progressBody resets the idle timeout of a request whenever data is read, and releases it once done.
+type progressBody struct {
+	io.ReadCloser
+	deadline *deadline
+	release  bool
+}
+
+func (body *progressBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	if n > 0 {
+		body.deadline.progress()
+	}
+	if err == io.EOF && body.release {
+		body.deadline.stop()
+		return n, err
+	}
+	return n, body.deadline.err(err)
+}
+
+func (body *progressBody) Close() error {
+	err := body.ReadCloser.Close()
+	if body.release {
+		body.deadline.stop()
+	}
+	return err
+}
+
+// do sends the request with a timeout, and an idle timeout on the request and response bodies.
+// The timeouts keep running until the response body is read or closed.
+func (client *client) do(req *http.Request, timeout, idle time.Duration) (*http.Response, error) {
+	d := newDeadline(req.Context(), timeout, idle)
+	req = req.WithContext(d.ctx)
+	if req.Body != nil && idle > 0 {
+		req.Body = &progressBody{ReadCloser: req.Body, deadline: d}
+	}
+
+	response, err := client.httpClient.Do(req)
+	if err != nil {
+		err = d.err(err)
+		d.stop()
+		return nil, err
+	}
+
+	response.Body = &progressBody{ReadCloser: response.Body, deadline: d, release: true}
+	return response, nil
+}
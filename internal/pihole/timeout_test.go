@@ -0,0 +1,66 @@
+package pihole
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTimeoutClient(t *testing.T, handler http.HandlerFunc, timeouts Timeouts) Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c := NewClient(model.NewPiHole(server.URL, apiPassword), server.Client(), timeouts).(*client)
+	c.auth = auth{sid: "sid", valid: true}
+	return c
+}
+
+func TestClient_timeout(t *testing.T) {
+	c := newTimeoutClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}, Timeouts{Default: 50 * time.Millisecond})
+
+	_, err := c.GetVersion()
+
+	var timeout *TimeoutError
+	require.ErrorAs(t, err, &timeout)
+	assert.Equal(t, &TimeoutError{Limit: 50 * time.Millisecond}, timeout)
+}
+
+func TestClient_idleTimeout(t *testing.T) {
+	var stall atomic.Bool
+	c := newTimeoutClient(t, func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 5; i++ {
+			_, _ = w.Write([]byte("  [i] Building tree\n"))
+			w.(http.Flusher).Flush()
+			delay := 40 * time.Millisecond
+			if stall.Load() {
+				delay = time.Second
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+	}, Timeouts{Gravity: 0, Idle: 150 * time.Millisecond})
+
+	// a run longer than the idle timeout passes as long as output keeps coming
+	require.NoError(t, c.PostRunGravity())
+
+	stall.Store(true)
+	err := c.PostRunGravity()
+
+	var timeout *TimeoutError
+	require.ErrorAs(t, err, &timeout)
+	assert.True(t, timeout.Idle)
+}
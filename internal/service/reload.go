@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+)
+
+// awaitReload waits for SIGHUP, or a change of the env or config file if RELOAD_WATCH_FILES is set, and returns
+// the service of the reloaded config. A config that fails to load or validate is logged and the current config
+// is kept.
+func (service *Service) awaitReload(signals <-chan os.Signal) *Service {
+	var changes <-chan time.Time
+	if reload := service.conf.Sync.Reload; service.source != nil && reload != nil && reload.WatchFiles {
+		ticker := time.NewTicker(time.Duration(reload.Interval) * time.Second)
+		defer ticker.Stop()
+		changes = ticker.C
+	}
+
+	for {
+		select {
+		case <-signals:
+			log.Info().Msg("Received SIGHUP, reloading config")
+		case <-changes:
+			changed, err := service.source.Changed()
+			if err != nil {
+				log.Warn().Err(err).Msg("Failed to check config files for changes")
+				continue
+			}
+			if !changed {
+				continue
+			}
+			log.Info().Msg("Config file changed, reloading config")
+		}
+
+		next, err := service.reload()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to reload config, keeping the current config")
+			continue
+		}
+		return next
+	}
+}
+
+// reload loads and validates the config of the source, and returns a service with its target, webhook client
+// and journal.
+func (service *Service) reload() (*Service, error) {
+	conf, settings, err := service.source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := newService(*conf)
+	if err != nil {
+		return nil, err
+	}
+	next.settings = settings
+
+	if !next.scheduled() {
+		return nil, errors.New("reloaded config must keep CRON or WATCH_ENABLED")
+	}
+	if conf.Sync.Cron != nil {
+		if _, err := cron.ParseStandard(*conf.Sync.Cron); err != nil {
+			return nil, fmt.Errorf("cron: %w", err)
+		}
+	}
+	if validation := conf.Sync.Validation; validation != nil && validation.Enabled {
+		if err := next.target.ValidateConfig(conf.Sync); err != nil {
+			return nil, err
+		}
+	}
+
+	return next, nil
+}
+
+// swap replaces the config, target, fingerprinter, webhook client and journal with those of next, and logs the changed
+// settings. The new target inherits the breaker and drift state of unchanged replicas. It must only be called while
+// no sync runs.
+func (service *Service) swap(next *Service) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	changes := next.settings.Diff(service.settings)
+	if len(changes) == 0 {
+		log.Info().Msg("Config reloaded, no settings changed")
+	} else {
+		log.Info().Strs("changes", changes).Msg("Config reloaded")
+	}
+
+	next.target.Inherit(service.target)
+	service.conf = next.conf
+	service.target = next.target
+	service.fingerprinter = next.fingerprinter
+	service.webhook = next.webhook
+	service.journal = next.journal
+	service.settings = next.settings
+}
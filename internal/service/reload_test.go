@@ -0,0 +1,77 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	syncmock "github.com/lovelaze/nebula-sync/internal/mocks/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reloadConfig = `
+primary: http://ph1.example.com|password
+full_sync: true
+cron: "0 * * * *"
+sync:
+  config:
+    validate: false
+`
+
+func writeReloadConfig(t *testing.T, path, content string) {
+	require.NoError(t, os.WriteFile(path, []byte(reloadConfig+content), 0o600))
+}
+
+func TestService_reload(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+
+	path := filepath.Join(t.TempDir(), "nebula-sync.yaml")
+	writeReloadConfig(t, path, "replicas: [http://ph2.example.com|password]\n")
+
+	service, err := InitFrom(&config.Source{ConfigFile: path})
+	require.NoError(t, err)
+	require.Len(t, service.conf.Replicas, 1)
+
+	writeReloadConfig(t, path, "replicas: [http://ph2.example.com|password, http://ph3.example.com|password]\n")
+	next, err := service.reload()
+	require.NoError(t, err)
+	service.swap(next)
+
+	assert.Len(t, service.conf.Replicas, 2)
+	assert.Same(t, next.target, service.target)
+
+	writeReloadConfig(t, path, "replicas: [http://ph2.example.com|password]\nrollng:\n  enabled: true\n")
+	_, err = service.reload()
+	assert.Error(t, err, "unknown settings must fail the reload")
+	assert.Len(t, service.conf.Replicas, 2, "a failed reload must keep the current config")
+}
+
+func TestService_swap_inherit(t *testing.T) {
+	current := syncmock.NewTarget(t)
+	next := syncmock.NewTarget(t)
+	next.EXPECT().Inherit(current).Once()
+
+	service := &Service{target: current}
+	service.swap(&Service{target: next})
+
+	assert.Same(t, next, service.target)
+}
+
+func TestService_awaitReload(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+
+	path := filepath.Join(t.TempDir(), "nebula-sync.yaml")
+	writeReloadConfig(t, path, "replicas: [http://ph2.example.com|password]\n")
+
+	service, err := InitFrom(&config.Source{ConfigFile: path})
+	require.NoError(t, err)
+
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGHUP
+	assert.NotNil(t, service.awaitReload(signals))
+}
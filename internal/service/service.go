@@ -1,13 +1,18 @@
 package service
 
 import (
+	"errors"
 	"fmt"
-
-	"github.com/lovelaze/nebula-sync/internal/sync/retry"
+	"os"
+	"os/signal"
+	gosync "sync"
+	"syscall"
+	"time"
 
 	"github.com/lovelaze/nebula-sync/internal/config"
 	"github.com/lovelaze/nebula-sync/internal/pihole"
 	"github.com/lovelaze/nebula-sync/internal/sync"
+	"github.com/lovelaze/nebula-sync/internal/sync/journal"
 	"github.com/lovelaze/nebula-sync/internal/webhook"
 	"github.com/lovelaze/nebula-sync/version"
 	"github.com/robfig/cron/v3"
@@ -15,61 +20,161 @@ import (
 )
 
 type Service struct {
-	target  sync.Target
-	conf    config.Config
-	webhook webhook.WebhookClient
+	target        sync.Target
+	fingerprinter sync.Fingerprinter
+	conf          config.Config
+	webhook       webhook.WebhookClient
+	journal       *journal.Journal
+	lastSuccess   time.Time
+	mu            gosync.Mutex
+	source        *config.Source
+	settings      config.Settings
 }
 
+// Init initializes the service with the config of the env vars.
 func Init() (*Service, error) {
-	conf := config.Config{}
-	if err := conf.Load(); err != nil {
+	return InitFrom(&config.Source{})
+}
+
+// InitFrom initializes the service with the config of source. A scheduled service reloads it on SIGHUP, see
+// Run.
+func InitFrom(source *config.Source) (*Service, error) {
+	conf, settings, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := newService(*conf)
+	if err != nil {
 		return nil, err
 	}
+	service.source = source
+	service.settings = settings
+
+	if service.journal != nil {
+		lastSuccess, err := service.journal.LastSuccess()
+		if err != nil {
+			return nil, fmt.Errorf("read journal: %w", err)
+		}
+		service.lastSuccess = lastSuccess
+	}
 
+	return service, nil
+}
+
+// newService creates the target, webhook client and journal of conf.
+func newService(conf config.Config) (*Service, error) {
 	httpClient := conf.Client.NewHttpClient()
-	retry.Init(conf.Client)
+	timeouts := conf.Client.NewTimeouts()
+	retries, err := conf.Client.NewRetryPolicies()
+	if err != nil {
+		return nil, fmt.Errorf("retry policies: %w", err)
+	}
 
-	primary := pihole.NewClient(conf.Primary, httpClient)
+	primary := pihole.NewClient(conf.Primary, httpClient, timeouts)
 	var replicas []pihole.Client
 	for _, replica := range conf.Replicas {
-		replicas = append(replicas, pihole.NewClient(replica, httpClient))
+		replicas = append(replicas, pihole.NewClient(replica, httpClient, timeouts))
+	}
+
+	var sources []pihole.Client
+	if conf.Sync.Merge != nil && conf.Sync.Merge.Enabled {
+		for _, source := range conf.Sync.Merge.Sources {
+			sources = append(sources, pihole.NewClient(source, httpClient, timeouts))
+		}
 	}
 
-	return &Service{
-		target:  sync.NewTarget(primary, replicas),
-		conf:    conf,
-		webhook: webhook.NewWebhookClient(conf.Sync.WebhookSettings),
-	}, nil
+	service := &Service{
+		target:        sync.NewTarget(primary, replicas, retries, sources...),
+		fingerprinter: sync.NewFingerprinter(pihole.NewClient(conf.Primary, httpClient, timeouts)),
+		conf:          conf,
+		webhook:       webhook.NewWebhookClient(conf.Sync.WebhookSettings),
+	}
+
+	if conf.Sync.Journal != nil && conf.Sync.Journal.Enabled {
+		service.journal = journal.NewJournal(conf.Sync.Journal.Path, time.Duration(conf.Sync.Journal.Retention)*time.Hour)
+	}
+
+	return service, nil
+}
+
+// LastSuccess returns the end time of the latest successful sync, including syncs recorded in the journal
+// before a restart. It returns the zero time if no sync succeeded yet.
+func (service *Service) LastSuccess() time.Time {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	return service.lastSuccess
 }
 
 func (service *Service) Run() error {
 	log.Info().Msgf("Starting nebula-sync %s", version.Version)
 	log.Debug().Str("config", service.conf.String()).Msgf("Settings")
+	if !service.lastSuccess.IsZero() {
+		log.Info().Time("time", service.lastSuccess).Msg("Last successful sync")
+	}
+
+	if validation := service.conf.Sync.Validation; validation != nil && validation.Enabled {
+		if err := service.target.ValidateConfig(service.conf.Sync); err != nil {
+			return err
+		}
+	}
 
 	if err := service.doSync(service.target); err != nil {
-		return err
+		if !service.scheduled() || !errors.Is(err, sync.ErrDrift) {
+			return err
+		}
 	}
 
-	if service.conf.Sync.Cron != nil {
-		return service.startCron(func() {
-			if err := service.doSync(service.target); err != nil {
-				log.Error().Err(err).Msg("Sync failed")
-			}
-		})
+	if !service.scheduled() {
+		return nil
 	}
 
-	return nil
+	var signals chan os.Signal
+	if service.source != nil {
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGHUP)
+		defer signal.Stop(signals)
+	}
+
+	for {
+		stop, err := service.start()
+		if err != nil {
+			return err
+		}
+
+		next := service.awaitReload(signals)
+		stop()
+		service.swap(next)
+	}
+}
+
+func (service *Service) scheduled() bool {
+	return service.conf.Sync.Cron != nil || service.watching()
+}
+
+func (service *Service) watching() bool {
+	return service.conf.Sync.Watch != nil && service.conf.Sync.Watch.Enabled
 }
 
 func (service *Service) doSync(t sync.Target) (err error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if service.conf.Sync.DriftDetection {
+		return service.doDetectDrift(t)
+	}
+
 	if service.conf.Sync.FullSync {
 		err = t.FullSync(service.conf.Sync)
 	} else {
 		err = t.SelectiveSync(service.conf.Sync)
 	}
+	service.journalRun(t, err)
+	service.notifyBreakers(t)
 
 	if err != nil {
-		if err := service.webhook.Failure(); err != nil {
+		if err := service.webhook.Failure(err); err != nil {
 			log.Error().Err(err).Msg("Failed to send failure webhook")
 		}
 	} else {
@@ -83,13 +188,120 @@ func (service *Service) doSync(t sync.Target) (err error) {
 	return err
 }
 
-func (service *Service) startCron(cmd func()) error {
-	cron := cron.New()
+// journalRun appends the record of the latest run to the journal and prunes expired records. Journal
+// errors are logged and never fail the sync.
+func (service *Service) journalRun(t sync.Target, err error) {
+	if err == nil {
+		service.lastSuccess = time.Now()
+	}
+
+	if service.journal == nil {
+		return
+	}
+
+	record := t.LastRun()
+	if record == nil {
+		return
+	}
+	if record.Success {
+		service.lastSuccess = record.End
+	}
 
-	if _, err := cron.AddFunc(*service.conf.Sync.Cron, cmd); err != nil {
-		return fmt.Errorf("cron job: %w", err)
+	if err := service.journal.Append(record); err != nil {
+		log.Error().Err(err).Msg("Failed to append to journal")
+	}
+	if err := service.journal.Prune(time.Now()); err != nil {
+		log.Warn().Err(err).Msg("Failed to prune journal")
+	}
+}
+
+// notifyBreakers invokes the quarantine and recovery webhooks for the replicas whose circuit breaker opened or
+// closed during the latest sync.
+func (service *Service) notifyBreakers(t sync.Target) {
+	if service.conf.Sync.Breaker == nil || !service.conf.Sync.Breaker.Enabled {
+		return
+	}
+
+	for _, event := range t.BreakerEvents() {
+		var err error
+		if event.State == sync.BreakerOpen {
+			err = service.webhook.Quarantine(event.Replica)
+		} else {
+			err = service.webhook.Recovery(event.Replica)
+		}
+		if err != nil {
+			log.Error().Err(err).Str("replica", event.Replica).Msg("Failed to send breaker webhook")
+		}
+	}
+}
+
+func (service *Service) doDetectDrift(t sync.Target) error {
+	reports, err := t.DetectDrift(service.conf.Sync)
+	if err != nil {
+		if err := service.webhook.Failure(err); err != nil {
+			log.Error().Err(err).Msg("Failed to send failure webhook")
+		}
+		return err
+	}
+
+	if sync.Drifted(reports) {
+		if err := service.webhook.Drift(reports); err != nil {
+			log.Error().Err(err).Msg("Failed to send drift webhook")
+		}
+		return sync.ErrDrift
+	}
+
+	log.Info().Msg("No drift detected")
+	if err := service.webhook.Success(); err != nil {
+		log.Error().Err(err).Msg("Failed to send success webhook")
 	}
 
-	cron.Run()
 	return nil
 }
+
+// start starts the cron schedule and watch mode. stop stops both and waits for a running sync to complete.
+func (service *Service) start() (stop func(), err error) {
+	cmd := func() {
+		if err := service.doSync(service.target); err != nil {
+			log.Error().Err(err).Msg("Sync failed")
+		}
+	}
+
+	var c *cron.Cron
+	if service.conf.Sync.Cron != nil {
+		if c, err = service.newCron(cmd); err != nil {
+			return nil, err
+		}
+		c.Start()
+	}
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	if service.watching() {
+		go func() {
+			defer close(exited)
+			service.watch(cmd, done)
+		}()
+	} else {
+		close(exited)
+	}
+
+	stop = func() {
+		close(done)
+		<-exited
+		if c != nil {
+			<-c.Stop().Done()
+		}
+	}
+	return stop, nil
+}
+
+func (service *Service) newCron(cmd func()) (*cron.Cron, error) {
+	c := cron.New()
+
+	if _, err := c.AddFunc(*service.conf.Sync.Cron, cmd); err != nil {
+		return nil, fmt.Errorf("cron job: %w", err)
+	}
+
+	return c, nil
+}
@@ -2,12 +2,18 @@ package service
 
 import (
 	"errors"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/lovelaze/nebula-sync/internal/config"
 	syncmock "github.com/lovelaze/nebula-sync/internal/mocks/sync"
 	webhookmock "github.com/lovelaze/nebula-sync/internal/mocks/webhook"
 	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync"
+	"github.com/lovelaze/nebula-sync/internal/sync/journal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -65,6 +71,30 @@ func TestRun_selective(t *testing.T) {
 	target.AssertCalled(t, "SelectiveSync", conf.Sync)
 }
 
+func TestRun_validation(t *testing.T) {
+	conf := config.Config{
+		Primary:  model.PiHole{},
+		Replicas: []model.PiHole{},
+		Sync: &config.Sync{
+			FullSync:   false,
+			Validation: &config.ValidationSettings{Enabled: true, Strict: true},
+		},
+	}
+
+	target := syncmock.NewTarget(t)
+	target.On("ValidateConfig", conf.Sync).Return(sync.ErrInvalidConfigKeys)
+
+	service := Service{
+		target: target,
+		conf:   conf,
+	}
+
+	err := service.Run()
+	require.ErrorIs(t, err, sync.ErrInvalidConfigKeys)
+
+	target.AssertNotCalled(t, "SelectiveSync", conf.Sync)
+}
+
 func TestRun_webhook_success(t *testing.T) {
 	conf := config.Config{
 		Primary:  model.PiHole{},
@@ -110,7 +140,7 @@ func TestRun_webhook_failure(t *testing.T) {
 	webhook := webhookmock.NewWebhookClient(t)
 
 	target.On("SelectiveSync", conf.Sync).Return(syncErr)
-	webhook.On("Failure").Return(nil)
+	webhook.On("Failure", syncErr).Return(nil)
 
 	service := Service{
 		target:  target,
@@ -122,7 +152,7 @@ func TestRun_webhook_failure(t *testing.T) {
 	require.ErrorIs(t, err, syncErr)
 
 	target.AssertCalled(t, "SelectiveSync", conf.Sync)
-	webhook.AssertCalled(t, "Failure")
+	webhook.AssertCalled(t, "Failure", syncErr)
 	webhook.AssertNotCalled(t, "Success")
 }
 
@@ -154,3 +184,130 @@ func TestRun_webhook_error_does_not_affect_result(t *testing.T) {
 	target.AssertCalled(t, "FullSync", conf.Sync)
 	webhook.AssertCalled(t, "Success")
 }
+
+func TestRun_drift(t *testing.T) {
+	conf := config.Config{
+		Primary:  model.PiHole{},
+		Replicas: []model.PiHole{},
+		Sync: &config.Sync{
+			DriftDetection: true,
+			Cron:           nil,
+		},
+	}
+
+	target := syncmock.NewTarget(t)
+	webhook := webhookmock.NewWebhookClient(t)
+
+	reports := []sync.Report{{Replica: "replica", Divergences: []sync.Divergence{{Key: "dns.upstreams"}}}}
+	target.On("DetectDrift", conf.Sync).Return(reports, nil)
+	webhook.On("Drift", reports).Return(nil)
+
+	service := Service{
+		target:  target,
+		conf:    conf,
+		webhook: webhook,
+	}
+
+	err := service.Run()
+	require.ErrorIs(t, err, sync.ErrDrift)
+
+	target.AssertNotCalled(t, "FullSync", conf.Sync)
+	target.AssertNotCalled(t, "SelectiveSync", conf.Sync)
+	webhook.AssertCalled(t, "Drift", reports)
+	webhook.AssertNotCalled(t, "Success")
+}
+
+func TestRun_no_drift(t *testing.T) {
+	conf := config.Config{
+		Primary:  model.PiHole{},
+		Replicas: []model.PiHole{},
+		Sync: &config.Sync{
+			DriftDetection: true,
+			Cron:           nil,
+		},
+	}
+
+	target := syncmock.NewTarget(t)
+	webhook := webhookmock.NewWebhookClient(t)
+
+	target.On("DetectDrift", conf.Sync).Return([]sync.Report{{Replica: "replica"}}, nil)
+	webhook.On("Success").Return(nil)
+
+	service := Service{
+		target:  target,
+		conf:    conf,
+		webhook: webhook,
+	}
+
+	err := service.Run()
+	require.NoError(t, err)
+
+	webhook.AssertNotCalled(t, "Drift", mock.Anything)
+}
+
+func TestRun_journal(t *testing.T) {
+	conf := config.Config{
+		Primary:  model.PiHole{},
+		Replicas: []model.PiHole{},
+		Sync: &config.Sync{
+			FullSync: true,
+			Journal:  &config.JournalSettings{Enabled: true},
+		},
+	}
+
+	end := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	record := &journal.Record{Start: end.Add(-time.Minute), End: end, Mode: "full", Success: true}
+
+	target := syncmock.NewTarget(t)
+	webhook := webhookmock.NewWebhookClient(t)
+	target.On("FullSync", conf.Sync).Return(nil)
+	target.On("LastRun").Return(record)
+	webhook.On("Success").Return(nil)
+
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	service := Service{
+		target:  target,
+		conf:    conf,
+		webhook: webhook,
+		journal: journal.NewJournal(path, 0),
+	}
+
+	err := service.Run()
+	require.NoError(t, err)
+	assert.Equal(t, end, service.LastSuccess())
+
+	lastSuccess, err := journal.NewJournal(path, 0).LastSuccess()
+	require.NoError(t, err)
+	assert.Equal(t, end, lastSuccess.UTC())
+}
+
+func TestRun_breakerWebhooks(t *testing.T) {
+	conf := config.Config{
+		Sync: &config.Sync{
+			FullSync: true,
+			Breaker:  &config.BreakerSettings{Enabled: true},
+		},
+	}
+
+	target := syncmock.NewTarget(t)
+	webhook := webhookmock.NewWebhookClient(t)
+	target.On("FullSync", conf.Sync).Return(nil)
+	target.On("BreakerEvents").Return([]sync.BreakerEvent{
+		{Replica: "http://ph2.lan", State: sync.BreakerOpen, Failures: 3},
+		{Replica: "http://ph3.lan", State: sync.BreakerClosed, Failures: 5},
+	})
+	webhook.On("Quarantine", "http://ph2.lan").Return(errors.New("unreachable"))
+	webhook.On("Recovery", "http://ph3.lan").Return(nil)
+	webhook.On("Success").Return(nil)
+
+	service := Service{
+		target:  target,
+		conf:    conf,
+		webhook: webhook,
+	}
+
+	require.NoError(t, service.Run())
+
+	webhook.AssertCalled(t, "Quarantine", "http://ph2.lan")
+	webhook.AssertCalled(t, "Recovery", "http://ph3.lan")
+}
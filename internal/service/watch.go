@@ -0,0 +1,67 @@
+package service
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type watchState struct {
+	fingerprint string
+	changedAt   time.Time
+}
+
+// watch syncs with cmd whenever the primary changed, until done is closed. If the primary cannot be fingerprinted
+// at first, for example while it restarts, watching starts from an empty fingerprint, so the first successful
+// poll counts as a change.
+func (service *Service) watch(cmd func(), done <-chan struct{}) {
+	interval := time.Duration(service.conf.Sync.Watch.Interval) * time.Second
+	log.Info().Dur("interval", interval).Msg("Watching primary for changes")
+	defer service.fingerprinter.Close()
+
+	fingerprint, err := service.fingerprinter.Fingerprint()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to fingerprint primary")
+	}
+
+	state := watchState{fingerprint: fingerprint}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			if service.poll(&state, now) {
+				cmd()
+			}
+		}
+	}
+}
+
+// poll fingerprints the primary and reports whether a sync is due, which is the case once a change
+// has been observed and the primary has stayed unchanged for the debounce period.
+func (service *Service) poll(state *watchState, now time.Time) bool {
+	debounce := time.Duration(service.conf.Sync.Watch.Debounce) * time.Second
+
+	fingerprint, err := service.fingerprinter.Fingerprint()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to fingerprint primary")
+		return false
+	}
+
+	if fingerprint != state.fingerprint {
+		log.Info().Msg("Change detected on primary")
+		state.fingerprint = fingerprint
+		state.changedAt = now
+	}
+
+	if state.changedAt.IsZero() || now.Sub(state.changedAt) < debounce {
+		return false
+	}
+
+	state.changedAt = time.Time{}
+	return true
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	syncmock "github.com/lovelaze/nebula-sync/internal/mocks/sync"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_poll(t *testing.T) {
+	fingerprinter := syncmock.NewFingerprinter(t)
+
+	service := Service{
+		fingerprinter: fingerprinter,
+		conf: config.Config{
+			Sync: &config.Sync{
+				Watch: &config.WatchSettings{Enabled: true, Interval: 1, Debounce: 5},
+			},
+		},
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := watchState{fingerprint: "a"}
+
+	fingerprinter.EXPECT().Fingerprint().Once().Return("a", nil)
+	assert.False(t, service.poll(&state, start), "unchanged primary must not trigger a sync")
+
+	fingerprinter.EXPECT().Fingerprint().Once().Return("b", nil)
+	assert.False(t, service.poll(&state, start.Add(time.Second)), "change must be debounced")
+
+	fingerprinter.EXPECT().Fingerprint().Once().Return("c", nil)
+	assert.False(t, service.poll(&state, start.Add(3*time.Second)), "new change must restart the debounce")
+
+	fingerprinter.EXPECT().Fingerprint().Once().Return("c", nil)
+	assert.False(t, service.poll(&state, start.Add(7*time.Second)))
+
+	fingerprinter.EXPECT().Fingerprint().Once().Return("c", nil)
+	assert.True(t, service.poll(&state, start.Add(8*time.Second)), "stable primary must trigger a sync")
+
+	fingerprinter.EXPECT().Fingerprint().Once().Return("c", nil)
+	assert.False(t, service.poll(&state, start.Add(20*time.Second)), "sync must only trigger once per change")
+}
+
+func TestService_poll_error(t *testing.T) {
+	fingerprinter := syncmock.NewFingerprinter(t)
+
+	service := Service{
+		fingerprinter: fingerprinter,
+		conf: config.Config{
+			Sync: &config.Sync{
+				Watch: &config.WatchSettings{Enabled: true, Interval: 1, Debounce: 0},
+			},
+		},
+	}
+
+	state := watchState{fingerprint: "a"}
+
+	fingerprinter.EXPECT().Fingerprint().Once().Return("", errors.New("unreachable"))
+	assert.False(t, service.poll(&state, time.Now()))
+	assert.Equal(t, "a", state.fingerprint)
+
+	fingerprinter.EXPECT().Fingerprint().Once().Return("b", nil)
+	assert.True(t, service.poll(&state, time.Now()))
+}
+
+func TestService_watch_primaryDown(t *testing.T) {
+	fingerprinter := syncmock.NewFingerprinter(t)
+
+	service := Service{
+		fingerprinter: fingerprinter,
+		conf: config.Config{
+			Sync: &config.Sync{
+				Watch: &config.WatchSettings{Enabled: true, Interval: 1, Debounce: 0},
+			},
+		},
+	}
+
+	fingerprinter.EXPECT().Fingerprint().Once().Return("", errors.New("unreachable"))
+	fingerprinter.EXPECT().Close().Once()
+
+	done := make(chan struct{})
+	close(done)
+	service.watch(func() { t.Fatal("unexpected sync") }, done)
+}
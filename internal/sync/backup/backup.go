@@ -0,0 +1,301 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	archiveExt = ".zip"
+	timeFormat = "20060102T150405Z"
+)
+
+// Backup is a stored teleporter archive of the primary. Its id is the time it was taken.
+type Backup struct {
+	ID          string
+	Time        time.Time
+	Fingerprint string
+	Size        int64
+	path        string
+}
+
+// Retention decides which backups Prune keeps. A backup is kept if it is one of the Last newest backups,
+// or the newest backup of one of the Daily, Weekly or Monthly newest days, iso weeks or months that have
+// backups. If every field is zero, all backups are kept.
+type Retention struct {
+	Last    int
+	Daily   int
+	Weekly  int
+	Monthly int
+}
+
+type Store struct {
+	dir       string
+	retention Retention
+}
+
+func NewStore(dir string, retention Retention) *Store {
+	return &Store{
+		dir:       dir,
+		retention: retention,
+	}
+}
+
+// Fingerprint hashes the names and contents of the archive's entries, in name order. The timestamps of the
+// entries are left out, since the teleporter export stamps every entry with the export time. An archive that
+// cannot be read as a zip is hashed as a whole.
+func Fingerprint(archive []byte) string {
+	if fingerprint, err := entriesFingerprint(archive); err == nil {
+		return fingerprint
+	}
+
+	sum := sha256.Sum256(archive)
+	return hex.EncodeToString(sum[:])
+}
+
+func entriesFingerprint(archive []byte) (string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return "", err
+	}
+
+	files := slices.Clone(reader.File)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	hash := sha256.New()
+	for _, file := range files {
+		fmt.Fprintf(hash, "%s\x00%d\x00", file.Name, file.UncompressedSize64)
+		if err := hashEntry(hash, file); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func hashEntry(w io.Writer, file *zip.File) error {
+	entry, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer entry.Close()
+
+	_, err = io.Copy(w, entry)
+	return err
+}
+
+// Save stores the archive unless it is identical to the latest backup. It returns the backup holding the
+// archive and whether it was created.
+func (store *Store) Save(archive []byte, now time.Time) (*Backup, bool, error) {
+	fingerprint := Fingerprint(archive)
+
+	backups, err := store.List()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(backups) > 0 && backups[0].Fingerprint == fingerprint {
+		return &backups[0], false, nil
+	}
+
+	if err := os.MkdirAll(store.dir, 0o700); err != nil {
+		return nil, false, fmt.Errorf("create backup dir: %w", err)
+	}
+
+	id := now.UTC().Format(timeFormat)
+	path := filepath.Join(store.dir, fmt.Sprintf("%s_%s%s", id, fingerprint, archiveExt))
+	if err := os.WriteFile(path, archive, 0o600); err != nil {
+		return nil, false, fmt.Errorf("write backup: %w", err)
+	}
+
+	return &Backup{
+		ID:          id,
+		Time:        now.UTC().Truncate(time.Second),
+		Fingerprint: fingerprint,
+		Size:        int64(len(archive)),
+		path:        path,
+	}, true, nil
+}
+
+// List returns the stored backups, newest first.
+func (store *Store) List() ([]Backup, error) {
+	entries, err := os.ReadDir(store.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read backup dir: %w", err)
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, archiveExt) {
+			continue
+		}
+
+		id, fingerprint, found := strings.Cut(strings.TrimSuffix(name, archiveExt), "_")
+		if !found {
+			continue
+		}
+		created, err := time.Parse(timeFormat, id)
+		if err != nil {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("stat backup: %w", err)
+		}
+
+		backups = append(backups, Backup{
+			ID:          id,
+			Time:        created,
+			Fingerprint: fingerprint,
+			Size:        info.Size(),
+			path:        filepath.Join(store.dir, name),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+func (store *Store) Get(id string) (*Backup, error) {
+	backups, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, backup := range backups {
+		if backup.ID == id {
+			return &backup, nil
+		}
+	}
+
+	return nil, fmt.Errorf("backup %q not found", id)
+}
+
+func (store *Store) Load(backup *Backup) ([]byte, error) {
+	archive, err := os.ReadFile(backup.path)
+	if err != nil {
+		return nil, fmt.Errorf("read backup: %w", err)
+	}
+
+	if fingerprint := Fingerprint(archive); fingerprint != backup.Fingerprint {
+		return nil, fmt.Errorf("backup %s is corrupt: fingerprint %s", backup.ID, fingerprint)
+	}
+
+	return archive, nil
+}
+
+// Prune removes the backups not kept by the retention rules.
+func (store *Store) Prune() error {
+	backups, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	keep := store.retention.keep(backups)
+	for i, backup := range backups {
+		if keep[i] {
+			continue
+		}
+
+		log.Debug().Str("backup", backup.ID).Msg("Removing expired backup")
+		if err := os.Remove(backup.path); err != nil {
+			return fmt.Errorf("remove backup: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// keep reports for each backup, newest first, whether it is retained.
+func (retention Retention) keep(backups []Backup) []bool {
+	keep := make([]bool, len(backups))
+	if retention == (Retention{}) {
+		for i := range keep {
+			keep[i] = true
+		}
+		return keep
+	}
+
+	for i := 0; i < len(backups) && i < retention.Last; i++ {
+		keep[i] = true
+	}
+
+	buckets := []struct {
+		count  int
+		period func(t time.Time) string
+	}{
+		{retention.Daily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{retention.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		}},
+		{retention.Monthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+
+	for _, bucket := range buckets {
+		seen := map[string]bool{}
+		for i, backup := range backups {
+			if len(seen) >= bucket.count {
+				break
+			}
+			period := bucket.period(backup.Time)
+			if seen[period] {
+				continue
+			}
+			seen[period] = true
+			keep[i] = true
+		}
+	}
+
+	return keep
+}
+
+// Restore imports the backup on the client with the given teleporter import options.
+func (store *Store) Restore(id string, client pihole.Client, request *model.PostTeleporterRequest) error {
+	backup, err := store.Get(id)
+	if err != nil {
+		return err
+	}
+
+	archive, err := store.Load(backup)
+	if err != nil {
+		return err
+	}
+
+	if err := client.PostAuth(); err != nil {
+		return fmt.Errorf("authenticate: %w", err)
+	}
+	defer func() {
+		if err := client.DeleteSession(); err != nil {
+			log.Warn().Msgf("Failed to invalidate session for target: %s", client.String())
+		}
+	}()
+
+	if err := client.PostTeleporter(archive, request); err != nil {
+		return fmt.Errorf("import teleporter: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Save(t *testing.T) {
+	store := NewStore(t.TempDir(), Retention{})
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	first, created, err := store.Save([]byte("a"), now)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "20250102T030405Z", first.ID)
+	assert.Equal(t, Fingerprint([]byte("a")), first.Fingerprint)
+
+	same, created, err := store.Save([]byte("a"), now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, first.ID, same.ID)
+
+	_, created, err = store.Save([]byte("b"), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	backups, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, "20250102T050405Z", backups[0].ID)
+	assert.Equal(t, int64(1), backups[0].Size)
+	assert.Equal(t, first.ID, backups[1].ID)
+
+	archive, err := store.Load(&backups[1])
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), archive)
+}
+
+func TestStore_Get_missing(t *testing.T) {
+	_, err := NewStore(t.TempDir(), Retention{}).Get("20250102T030405Z")
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestRetention_keep(t *testing.T) {
+	day := func(d, h int) Backup {
+		return Backup{Time: time.Date(2025, 3, d, h, 0, 0, 0, time.UTC)}
+	}
+	backups := []Backup{day(20, 12), day(20, 6), day(19, 12), day(18, 12), day(10, 12), day(1, 12)}
+
+	assert.Equal(t, []bool{true, true, false, false, false, false}, Retention{Last: 2}.keep(backups))
+	assert.Equal(t, []bool{true, false, true, true, false, false}, Retention{Daily: 3}.keep(backups))
+	assert.Equal(t, []bool{true, false, false, false, true, false}, Retention{Weekly: 2}.keep(backups))
+	assert.Equal(t, []bool{true, false, false, false, false, false}, Retention{Monthly: 2}.keep(backups))
+	assert.Equal(t, []bool{true, true, true, true, true, true}, Retention{}.keep(backups))
+}
+
+func TestStore_Prune(t *testing.T) {
+	store := NewStore(t.TempDir(), Retention{Last: 1})
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	_, _, err := store.Save([]byte("a"), now)
+	require.NoError(t, err)
+	latest, _, err := store.Save([]byte("b"), now.Add(time.Hour))
+	require.NoError(t, err)
+
+	require.NoError(t, store.Prune())
+
+	backups, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, latest.ID, backups[0].ID)
+}
+
+func TestStore_Restore(t *testing.T) {
+	store := NewStore(t.TempDir(), Retention{})
+	saved, _, err := store.Save([]byte("zip"), time.Now())
+	require.NoError(t, err)
+
+	request := &model.PostTeleporterRequest{Config: true}
+	client := piholemock.NewClient(t)
+	client.EXPECT().PostAuth().Once().Return(nil)
+	client.EXPECT().PostTeleporter([]byte("zip"), request).Once().Return(nil)
+	client.EXPECT().DeleteSession().Once().Return(nil)
+
+	require.NoError(t, store.Restore(saved.ID, client, request))
+}
+
+func zipArchive(t *testing.T, modified time.Time, entries ...string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for i := 0; i < len(entries); i += 2 {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: entries[i], Method: zip.Deflate, Modified: modified})
+		require.NoError(t, err)
+		_, err = f.Write([]byte(entries[i+1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestFingerprint(t *testing.T) {
+	exported := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	archive := zipArchive(t, exported, "etc/pihole/pihole.toml", "[dns]", "etc/pihole/gravity.db", "db")
+
+	later := zipArchive(t, exported.Add(time.Hour), "etc/pihole/gravity.db", "db", "etc/pihole/pihole.toml", "[dns]")
+	assert.NotEqual(t, archive, later)
+	assert.Equal(t, Fingerprint(archive), Fingerprint(later), "export time and entry order must not matter")
+
+	changed := zipArchive(t, exported, "etc/pihole/pihole.toml", "[dhcp]", "etc/pihole/gravity.db", "db")
+	assert.NotEqual(t, Fingerprint(archive), Fingerprint(changed))
+
+	assert.NotEqual(t, Fingerprint([]byte("a")), Fingerprint([]byte("b")), "not a zip")
+}
@@ -0,0 +1,157 @@
+package sync
+
+import (
+	"slices"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/rs/zerolog/log"
+)
+
+type BreakerState int
+
+const (
+	// BreakerClosed replicas are synced.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen replicas are quarantined and skipped.
+	BreakerOpen
+	// BreakerHalfOpen replicas are quarantined replicas that are probed by syncing them once.
+	BreakerHalfOpen
+)
+
+func (state BreakerState) String() string {
+	var s string
+	switch state {
+	case BreakerClosed:
+		s = "closed"
+	case BreakerOpen:
+		s = "open"
+	case BreakerHalfOpen:
+		s = "half-open"
+	}
+	return s
+}
+
+// BreakerEvent is a replica that was quarantined, with State BreakerOpen, or that recovered, with State
+// BreakerClosed, during the latest sync.
+type BreakerEvent struct {
+	Replica  string
+	State    BreakerState
+	Failures int
+}
+
+// breaker tracks the consecutive failed runs of a replica.
+type breaker struct {
+	state    BreakerState
+	failures int
+	probes   int
+	retryAt  time.Time
+}
+
+func (target *target) BreakerEvents() []BreakerEvent {
+	return target.breakerEvents
+}
+
+// admit returns the replicas that are synced: replicas with a closed breaker, and quarantined replicas whose
+// next probe is due, which become half-open.
+func (target *target) admit(now time.Time) []pihole.Client {
+	if target.breakers == nil {
+		target.breakers = make(map[string]*breaker)
+	}
+
+	var admitted []pihole.Client
+	for _, replica := range target.Replicas {
+		b := target.breakers[replica.String()]
+		switch {
+		case b == nil || b.state == BreakerClosed:
+		case now.Before(b.retryAt):
+			log.Info().Str("replica", replica.String()).Time("until", b.retryAt).Msg("Skipping quarantined replica")
+			continue
+		default:
+			log.Info().Str("replica", replica.String()).Msg("Probing quarantined replica")
+			b.state = BreakerHalfOpen
+		}
+		admitted = append(admitted, replica)
+	}
+	return admitted
+}
+
+// trackBreakers updates the breakers of the synced replicas with the outcome of the run. A replica failed if
+// a step or its authentication failed on it, and succeeded if the run succeeded or every step it ran
+// succeeded. Replicas the run did not reach are left unchanged.
+func (target *target) trackBreakers(settings *config.BreakerSettings, replicas []pihole.Client, err error, now time.Time) {
+	for _, replica := range replicas {
+		failed, ran := target.outcome(replica)
+		switch {
+		case failed:
+			target.breakerFailed(settings, replica, now)
+		case err == nil || ran:
+			target.breakerSucceeded(replica)
+		}
+	}
+}
+
+func (target *target) outcome(replica pihole.Client) (failed bool, ran bool) {
+	failed = slices.Contains(target.failed, replica)
+	for _, result := range target.results {
+		for _, r := range result.Replicas {
+			if r.Replica == replica {
+				ran = true
+				failed = failed || r.Err != nil
+			}
+		}
+	}
+	return failed, ran
+}
+
+func (target *target) breakerFailed(settings *config.BreakerSettings, replica pihole.Client, now time.Time) {
+	name := replica.String()
+	b := target.breakers[name]
+	if b == nil {
+		b = &breaker{}
+		target.breakers[name] = b
+	}
+	b.failures++
+
+	switch b.state {
+	case BreakerClosed:
+		if b.failures < settings.Threshold {
+			return
+		}
+		b.state = BreakerOpen
+		b.probes = 0
+		b.retryAt = now.Add(breakerBackoff(settings, b.probes))
+		log.Warn().Str("replica", name).Int("failures", b.failures).Time("until", b.retryAt).Msg("Replica quarantined")
+		target.breakerEvents = append(target.breakerEvents, BreakerEvent{Replica: name, State: BreakerOpen, Failures: b.failures})
+	case BreakerHalfOpen:
+		b.state = BreakerOpen
+		b.probes++
+		b.retryAt = now.Add(breakerBackoff(settings, b.probes))
+		log.Warn().Str("replica", name).Int("failures", b.failures).Time("until", b.retryAt).Msg("Quarantined replica still failing")
+	}
+}
+
+func (target *target) breakerSucceeded(replica pihole.Client) {
+	name := replica.String()
+	b := target.breakers[name]
+	if b == nil {
+		return
+	}
+
+	if b.state != BreakerClosed {
+		log.Info().Str("replica", name).Int("failures", b.failures).Msg("Replica recovered")
+		target.breakerEvents = append(target.breakerEvents, BreakerEvent{Replica: name, State: BreakerClosed, Failures: b.failures})
+	}
+	delete(target.breakers, name)
+}
+
+// breakerBackoff returns the time until the next probe after the given number of failed probes.
+func breakerBackoff(settings *config.BreakerSettings, probes int) time.Duration {
+	backoff := time.Duration(settings.Backoff) * time.Second
+	maxBackoff := time.Duration(settings.MaxBackoff) * time.Second
+	for i := 0; i < probes && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
@@ -0,0 +1,124 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarget_sync_breaker(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+	target := NewTarget(primary, []pihole.Client{replica}, nil).(*target)
+
+	primary.EXPECT().PostAuth().Return(nil)
+	primary.EXPECT().DeleteSession().Return(nil)
+	replica.EXPECT().String().Return("http://ph2.lan")
+	replica.EXPECT().DeleteSession().Return(nil)
+
+	authErr := errors.New("connection refused")
+	replica.EXPECT().PostAuth().RunAndReturn(func() error { return authErr })
+
+	conf := &config.Sync{Breaker: &config.BreakerSettings{Enabled: true, Threshold: 2, Backoff: 60, MaxBackoff: 600}}
+	var synced []int
+	syncFunc := func(*pipelineRun) error {
+		synced = append(synced, len(target.Replicas))
+		return nil
+	}
+
+	require.Error(t, target.sync(syncFunc, "test", conf))
+	assert.Empty(t, target.BreakerEvents())
+
+	require.Error(t, target.sync(syncFunc, "test", conf))
+	assert.Equal(t, []BreakerEvent{{Replica: "http://ph2.lan", State: BreakerOpen, Failures: 2}}, target.BreakerEvents())
+
+	require.NoError(t, target.sync(syncFunc, "test", conf), "quarantined replica is skipped")
+	assert.Empty(t, target.BreakerEvents())
+	assert.Equal(t, []int{0}, synced)
+	assert.Len(t, target.Replicas, 1, "replicas are restored")
+
+	target.breakers["http://ph2.lan"].retryAt = time.Now().Add(-time.Second)
+	authErr = nil
+
+	require.NoError(t, target.sync(syncFunc, "test", conf), "probe succeeds")
+	assert.Equal(t, []BreakerEvent{{Replica: "http://ph2.lan", State: BreakerClosed, Failures: 2}}, target.BreakerEvents())
+	assert.Equal(t, []int{0, 1}, synced)
+	assert.Empty(t, target.breakers)
+}
+
+func TestTarget_trackBreakers_failedProbe(t *testing.T) {
+	replica := piholemock.NewClient(t)
+	replica.EXPECT().String().Return("http://ph2.lan")
+
+	settings := &config.BreakerSettings{Enabled: true, Threshold: 1, Backoff: 60, MaxBackoff: 150}
+	target := &target{Replicas: []pihole.Client{replica}, breakers: map[string]*breaker{}}
+	now := time.Now()
+
+	target.failed = []pihole.Client{replica}
+	target.trackBreakers(settings, target.Replicas, errors.New("failed"), now)
+	require.Len(t, target.BreakerEvents(), 1)
+	assert.Equal(t, now.Add(time.Minute), target.breakers["http://ph2.lan"].retryAt)
+
+	for _, backoff := range []time.Duration{120 * time.Second, 150 * time.Second, 150 * time.Second} {
+		assert.Len(t, target.admit(now.Add(-time.Second)), 0)
+		assert.Len(t, target.admit(now.Add(time.Hour)), 1)
+		assert.Equal(t, BreakerHalfOpen, target.breakers["http://ph2.lan"].state)
+
+		target.trackBreakers(settings, target.Replicas, errors.New("failed"), now)
+		assert.Equal(t, BreakerOpen, target.breakers["http://ph2.lan"].state)
+		assert.Equal(t, now.Add(backoff), target.breakers["http://ph2.lan"].retryAt)
+	}
+	assert.Len(t, target.BreakerEvents(), 1, "failed probes are not notified")
+}
+
+func TestTarget_trackBreakers_stepResults(t *testing.T) {
+	failing := piholemock.NewClient(t)
+	healthy := piholemock.NewClient(t)
+	unreached := piholemock.NewClient(t)
+	failing.EXPECT().String().Return("http://ph2.lan")
+	healthy.EXPECT().String().Return("http://ph3.lan")
+
+	settings := &config.BreakerSettings{Enabled: true, Threshold: 3, Backoff: 60, MaxBackoff: 600}
+	target := &target{breakers: map[string]*breaker{"http://ph3.lan": {failures: 2}}}
+	target.results = []StepResult{{Replicas: []ReplicaResult{
+		{Replica: healthy},
+		{Replica: failing, Err: errors.New("patch failed")},
+	}}}
+
+	target.trackBreakers(settings, []pihole.Client{failing, healthy, unreached}, errors.New("patch failed"), time.Now())
+
+	assert.Equal(t, 1, target.breakers["http://ph2.lan"].failures)
+	assert.NotContains(t, target.breakers, "http://ph3.lan", "success resets the failures")
+	assert.Empty(t, target.BreakerEvents())
+}
+
+func TestTarget_Inherit(t *testing.T) {
+	kept := piholemock.NewClient(t)
+	removed := piholemock.NewClient(t)
+	added := piholemock.NewClient(t)
+	kept.EXPECT().String().Return("http://ph2.lan")
+	added.EXPECT().String().Return("http://ph4.lan")
+
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	previous := NewTarget(nil, []pihole.Client{kept, removed}, nil).(*target)
+	previous.breakers = map[string]*breaker{
+		"http://ph2.lan": {state: BreakerOpen, failures: 3},
+		"http://ph3.lan": {state: BreakerOpen, failures: 5},
+	}
+	previous.driftSince = map[string]map[string]time.Time{
+		"http://ph2.lan": {"dns.upstreams": since},
+		"http://ph3.lan": {"dns.hosts": since},
+	}
+
+	next := NewTarget(nil, []pihole.Client{kept, added}, nil).(*target)
+	next.Inherit(previous)
+
+	assert.Equal(t, map[string]*breaker{"http://ph2.lan": {state: BreakerOpen, failures: 3}}, next.breakers)
+	assert.Equal(t, map[string]map[string]time.Time{"http://ph2.lan": {"dns.upstreams": since}}, next.driftSince)
+}
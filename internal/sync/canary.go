@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/sync/probe"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrCanaryFailed = errors.New("canary failed")
+
+type prober interface {
+	Run(probes []probe.Probe) []probe.Result
+}
+
+var newProber = func(server string, timeout time.Duration) prober {
+	return probe.NewProber(server, timeout)
+}
+
+// probeCanary probes the DNS resolution of the canary, which the pipeline executed on before the remaining
+// replicas, see pipelineRun.phases.
+func probeCanary(canary pihole.Client, settings *config.CanarySettings) error {
+	server, err := canaryDNSServer(canary, settings.DNSServer)
+	if err != nil {
+		return err
+	}
+
+	probes, err := canaryProbes(settings)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Str("server", server).Int("probes", len(probes)).Msg("Probing canary...")
+	results := newProber(server, time.Duration(settings.Timeout)*time.Second).Run(probes)
+
+	for _, result := range results {
+		event := log.Info()
+		if !result.Passed {
+			event = log.Warn()
+		}
+		event.Str("replica", canary.String()).
+			Str("probe", result.Probe.String()).
+			Strs("answers", result.Answers).
+			Bool("passed", result.Passed).
+			Str("reason", result.Reason).
+			Msg("Canary probe")
+	}
+
+	failed := probe.Failed(results)
+	if len(failed) == 0 {
+		return nil
+	}
+
+	report := make([]string, 0, len(failed))
+	for _, result := range failed {
+		report = append(report, result.String())
+	}
+
+	return fmt.Errorf("%w: %d of %d probes failed on %s: %s", ErrCanaryFailed, len(failed), len(results), canary.String(), strings.Join(report, "; "))
+}
+
+// splitCanary returns the first replica whose url contains name, and the remaining replicas.
+func splitCanary(replicas []pihole.Client, name string) (pihole.Client, []pihole.Client, error) {
+	for i, replica := range replicas {
+		if strings.Contains(replica.String(), name) {
+			others := make([]pihole.Client, 0, len(replicas)-1)
+			others = append(others, replicas[:i]...)
+			others = append(others, replicas[i+1:]...)
+			return replica, others, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("canary replica %q not found", name)
+}
+
+// canaryDNSServer returns the configured DNS server, or port 53 on the canary's host.
+func canaryDNSServer(canary pihole.Client, server string) (string, error) {
+	if server != "" {
+		return server, nil
+	}
+
+	u, err := url.Parse(canary.String())
+	if err != nil {
+		return "", fmt.Errorf("parse canary url: %w", err)
+	}
+	return net.JoinHostPort(u.Hostname(), "53"), nil
+}
+
+func canaryProbes(settings *config.CanarySettings) ([]probe.Probe, error) {
+	var probes []probe.Probe
+	for _, domain := range settings.AllowDomains {
+		probes = append(probes, probe.Probe{Kind: probe.Allow, Domain: domain})
+	}
+	for _, domain := range settings.BlockDomains {
+		probes = append(probes, probe.Probe{Kind: probe.Block, Domain: domain})
+	}
+
+	local, err := settings.LocalProbes()
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make([]string, 0, len(local))
+	for domain := range local {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	for _, domain := range domains {
+		probes = append(probes, probe.Probe{Kind: probe.Local, Domain: domain, Expected: local[domain]})
+	}
+
+	return probes, nil
+}
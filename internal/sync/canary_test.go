@@ -0,0 +1,190 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/sync/probe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProber struct {
+	server  string
+	probes  []probe.Probe
+	results func(probes []probe.Probe) []probe.Result
+}
+
+func (p *fakeProber) Run(probes []probe.Probe) []probe.Result {
+	p.probes = probes
+	return p.results(probes)
+}
+
+func useFakeProber(t *testing.T, passed bool) *fakeProber {
+	fake := &fakeProber{results: func(probes []probe.Probe) []probe.Result {
+		results := make([]probe.Result, 0, len(probes))
+		for _, p := range probes {
+			result := probe.Result{Probe: p, Passed: passed}
+			if !passed {
+				result.Reason = "not blocked: 1.2.3.4"
+			}
+			results = append(results, result)
+		}
+		return results
+	}}
+
+	previous := newProber
+	newProber = func(server string, _ time.Duration) prober {
+		fake.server = server
+		return fake
+	}
+	t.Cleanup(func() { newProber = previous })
+
+	return fake
+}
+
+// canarySyncExpectations expects the primary to be read once, and every replica to be synced with its archive.
+func canarySyncExpectations(primary *piholemock.Client, replicas ...*piholemock.Client) {
+	primary.EXPECT().GetTeleporter().Once().Return([]byte("archive"), nil)
+	primary.EXPECT().GetConfig().Once().Return(emptyConfigResponse(), nil)
+	for _, replica := range replicas {
+		replica.EXPECT().PostTeleporter([]byte("archive"), mock.Anything).Once().Return(nil)
+		replica.EXPECT().PatchConfig(mock.Anything).Once().Return(nil)
+	}
+}
+
+func TestTarget_FullSync_canary(t *testing.T) {
+	fake := useFakeProber(t, true)
+
+	primary := piholemock.NewClient(t)
+	first := piholemock.NewClient(t)
+	canary := piholemock.NewClient(t)
+
+	primary.EXPECT().PostAuth().Once().Return(nil)
+	primary.EXPECT().DeleteSession().Once().Return(nil)
+	for _, replica := range []*piholemock.Client{first, canary} {
+		replica.EXPECT().PostAuth().Once().Return(nil)
+		replica.EXPECT().DeleteSession().Once().Return(nil)
+	}
+	canarySyncExpectations(primary, first, canary)
+	first.EXPECT().String().Maybe().Return("http://ph1.lan")
+	canary.EXPECT().String().Maybe().Return("http://ph2.lan:8080")
+
+	target := NewTarget(primary, []pihole.Client{first, canary}, nil)
+	err := target.FullSync(&config.Sync{
+		FullSync: true,
+		Canary: &config.CanarySettings{
+			Enabled:      true,
+			Replica:      "ph2",
+			BlockDomains: []string{"doubleclick.net"},
+			LocalRecords: []string{"nas.lan=192.168.1.10"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ph2.lan:53", fake.server)
+	assert.Equal(t, []probe.Probe{
+		{Kind: probe.Block, Domain: "doubleclick.net"},
+		{Kind: probe.Local, Domain: "nas.lan", Expected: []string{"192.168.1.10"}},
+	}, fake.probes)
+}
+
+func TestTarget_FullSync_canaryQuarantined(t *testing.T) {
+	fake := useFakeProber(t, true)
+
+	primary := piholemock.NewClient(t)
+	other := piholemock.NewClient(t)
+	canary := piholemock.NewClient(t)
+
+	primary.EXPECT().PostAuth().Once().Return(nil)
+	primary.EXPECT().DeleteSession().Once().Return(nil)
+	other.EXPECT().PostAuth().Once().Return(nil)
+	other.EXPECT().DeleteSession().Once().Return(nil)
+	canarySyncExpectations(primary, other)
+	canary.EXPECT().String().Maybe().Return("http://canary.lan")
+	other.EXPECT().String().Maybe().Return("http://other.lan")
+
+	target := NewTarget(primary, []pihole.Client{canary, other}, nil).(*target)
+	target.breakers = map[string]*breaker{
+		"http://canary.lan": {state: BreakerOpen, failures: 3, retryAt: time.Now().Add(time.Hour)},
+	}
+
+	err := target.FullSync(&config.Sync{
+		FullSync: true,
+		Breaker:  &config.BreakerSettings{Enabled: true, Threshold: 3, Backoff: 60, MaxBackoff: 600},
+		Canary: &config.CanarySettings{
+			Enabled:      true,
+			Replica:      "canary",
+			BlockDomains: []string{"doubleclick.net"},
+		},
+	})
+	require.NoError(t, err, "a quarantined canary must not fail the remaining replicas")
+	assert.Nil(t, fake.probes, "the canary phase is skipped")
+	assert.Empty(t, target.BreakerEvents())
+}
+
+func TestTarget_FullSync_canaryFailed(t *testing.T) {
+	useFakeProber(t, false)
+
+	primary := piholemock.NewClient(t)
+	other := piholemock.NewClient(t)
+	canary := piholemock.NewClient(t)
+
+	primary.EXPECT().PostAuth().Once().Return(nil)
+	primary.EXPECT().DeleteSession().Once().Return(nil)
+	for _, replica := range []*piholemock.Client{other, canary} {
+		replica.EXPECT().PostAuth().Once().Return(nil)
+		replica.EXPECT().DeleteSession().Once().Return(nil)
+	}
+	canarySyncExpectations(primary, canary)
+	canary.EXPECT().String().Maybe().Return("http://canary.lan")
+	other.EXPECT().String().Maybe().Return("http://other.lan")
+
+	target := NewTarget(primary, []pihole.Client{canary, other}, nil)
+	err := target.FullSync(&config.Sync{
+		FullSync: true,
+		Canary: &config.CanarySettings{
+			Enabled:      true,
+			Replica:      "canary",
+			DNSServer:    "127.0.0.1:5353",
+			BlockDomains: []string{"doubleclick.net"},
+		},
+	})
+	require.ErrorIs(t, err, ErrCanaryFailed)
+	assert.Contains(t, err.Error(), "block doubleclick.net: not blocked: 1.2.3.4")
+}
+
+func Test_splitCanary(t *testing.T) {
+	a, b := piholemock.NewClient(t), piholemock.NewClient(t)
+	a.EXPECT().String().Return("http://a.lan")
+	b.EXPECT().String().Return("http://b.lan")
+
+	canary, others, err := splitCanary([]pihole.Client{a, b}, "b.lan")
+	require.NoError(t, err)
+	assert.Equal(t, b, canary)
+	assert.Equal(t, []pihole.Client{a}, others)
+
+	_, _, err = splitCanary([]pihole.Client{a, b}, "c.lan")
+	assert.Error(t, err)
+}
+
+func Test_target_runPipeline_canary(t *testing.T) {
+	useFakeProber(t, true)
+
+	other := newNamedReplica(t, "http://other.lan")
+	canary := newNamedReplica(t, "http://canary.lan")
+	target := target{Primary: piholemock.NewClient(t), Replicas: []pihole.Client{other, canary}}
+
+	step := &fakeStep{name: "step"}
+	err := target.runPipeline(&pipelineRun{canary: &config.CanarySettings{Enabled: true, Replica: "canary"}}, step)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, step.started)
+	assert.Equal(t, []pihole.Client{canary, other}, step.executed)
+	require.Len(t, target.results, 1)
+	assert.Len(t, target.results[0].Replicas, 2)
+}
@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/rs/zerolog/log"
+)
+
+// minMajorVersion is the first Pi-hole FTL major version with the api used to sync.
+const minMajorVersion = 6
+
+// CheckOnline authenticates to the primary, the replicas and the merge sources, and checks their FTL versions
+// and the configured config keys against the primary's config schema, without changing anything. It returns
+// every problem found. Replicas running another minor version than the primary are only logged.
+func CheckOnline(primary pihole.Client, replicas, sources []pihole.Client, conf *config.Sync) []error {
+	var problems []error
+
+	primaryVersion, primaryErr := checkInstance(primary)
+	if primaryErr != nil {
+		problems = append(problems, fmt.Errorf("primary %s: %w", primary.String(), primaryErr))
+	}
+
+	check := func(role string, client pihole.Client) {
+		version, err := checkInstance(client)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s %s: %w", role, client.String(), err))
+			return
+		}
+		if primaryVersion == "" || version == primaryVersion {
+			return
+		}
+		if majorVersion(version) != majorVersion(primaryVersion) {
+			problems = append(problems, fmt.Errorf("%s %s: runs FTL %s, the primary runs %s", role, client.String(), version, primaryVersion))
+			return
+		}
+		log.Warn().Str(role, client.String()).Str("version", version).Str("primary", primaryVersion).Msg("FTL version differs from the primary")
+	}
+	for _, replica := range replicas {
+		check("replica", replica)
+	}
+	for _, source := range sources {
+		check("source", source)
+	}
+
+	// the config keys are checked against the primary's schema
+	if primaryErr != nil {
+		return problems
+	}
+
+	selectors, err := keySelectors(conf)
+	if err != nil {
+		return append(problems, err)
+	}
+	if len(selectors) == 0 {
+		return problems
+	}
+
+	schema, err := configSchema(primary)
+	if err != nil {
+		return append(problems, fmt.Errorf("primary %s: config schema: %w", primary.String(), err))
+	}
+
+	issues, _ := validateKeys(selectors, schema.Keys())
+	for _, issue := range issues {
+		problems = append(problems, errors.New(issue.String()))
+	}
+	return problems
+}
+
+// checkInstance authenticates to the Pi-hole and returns its FTL version.
+func checkInstance(client pihole.Client) (string, error) {
+	if err := client.PostAuth(); err != nil {
+		return "", fmt.Errorf("authenticate: %w", err)
+	}
+
+	defer func() {
+		if err := client.DeleteSession(); err != nil {
+			log.Warn().Msgf("Failed to invalidate session for target: %s", client.String())
+		}
+	}()
+
+	response, err := client.GetVersion()
+	if err != nil {
+		return "", fmt.Errorf("version: %w", err)
+	}
+
+	version := response.Version.Ftl.Local.Version
+	if major := majorVersion(version); major >= 0 && major < minMajorVersion {
+		return version, fmt.Errorf("FTL %s is not supported, v%d or later is required", version, minMajorVersion)
+	}
+	return version, nil
+}
+
+// majorVersion returns the major version of a version like v6.0.4, or -1 for development builds and other
+// versions it cannot parse.
+func majorVersion(version string) int {
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCheckedInstance(t *testing.T, name, version string) *piholemock.Client {
+	client := newNamedReplica(t, name)
+	response := &model.VersionResponse{}
+	response.Version.Ftl.Local.Version = version
+
+	client.EXPECT().PostAuth().Return(nil)
+	client.EXPECT().GetVersion().Return(response, nil)
+	client.EXPECT().DeleteSession().Return(nil)
+	return client
+}
+
+func TestCheckOnline(t *testing.T) {
+	primary := newCheckedInstance(t, "http://primary", "v6.0.4")
+	primary.EXPECT().GetConfigSchema().Return(loadSchema(t), nil)
+	minor := newCheckedInstance(t, "http://minor", "v6.1.0")
+	old := newCheckedInstance(t, "http://old", "v5.25.2")
+	down := newNamedReplica(t, "http://down")
+	down.EXPECT().PostAuth().Return(errors.New("connection refused"))
+
+	problems := CheckOnline(primary, []pihole.Client{minor, old, down}, nil, validationConf(t, false))
+
+	var messages []string
+	for _, problem := range problems {
+		messages = append(messages, problem.Error())
+	}
+	assert.Equal(t, []string{
+		"replica http://old: FTL v5.25.2 is not supported, v6 or later is required",
+		"replica http://down: authenticate: connection refused",
+		"SYNC_CONFIG_DNS_INCLUDE: upstream: no such key, did you mean upstreams?",
+		"SYNC_CONFIG_DNS_INCLUDE: Port: no such key, did you mean port?",
+		"SYNC_CONFIG_ELEMENTS: dhcp.router: expected an array value, got IPv4 address",
+		"SYNC_CONFIG_REWRITE: dns.port: expected a string or array value, got unsigned integer (16 bit)",
+	}, messages)
+}
+
+func TestCheckOnline_majorVersionMismatch(t *testing.T) {
+	primary := newCheckedInstance(t, "http://primary", "v7.0.0")
+	replica := newCheckedInstance(t, "http://replica", "v6.0.4")
+
+	problems := CheckOnline(primary, []pihole.Client{replica}, nil, &config.Sync{})
+
+	require.Len(t, problems, 1)
+	assert.EqualError(t, problems[0], "replica http://replica: runs FTL v6.0.4, the primary runs v7.0.0")
+}
+
+func TestCheckOnline_primaryDown(t *testing.T) {
+	primary := newNamedReplica(t, "http://primary")
+	primary.EXPECT().PostAuth().Return(errors.New("unauthorized"))
+	source := newCheckedInstance(t, "http://source", "v6.0.4")
+
+	problems := CheckOnline(primary, nil, []pihole.Client{source}, validationConf(t, false))
+
+	require.Len(t, problems, 1)
+	assert.EqualError(t, problems[0], "primary http://primary: authenticate: unauthorized")
+}
+
+func Test_majorVersion(t *testing.T) {
+	assert.Equal(t, 6, majorVersion("v6.0.4"))
+	assert.Equal(t, 5, majorVersion("v5.25"))
+	assert.Equal(t, -1, majorVersion("vDev-abc123"))
+	assert.Equal(t, -1, majorVersion(""))
+}
@@ -0,0 +1,254 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/sync/exclude"
+	"github.com/lovelaze/nebula-sync/internal/sync/retry"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrDrift = errors.New("drift detected")
+
+func (target *target) DetectDrift(conf *config.Sync) (reports []Report, err error) {
+	log.Info().Int("replicas", len(target.Replicas)).Msg("Detecting drift")
+
+	defer func() {
+		if err != nil {
+			log.Error().Err(err).Stringer("class", retry.Classify(err)).Msg("Error during drift detection")
+		}
+		target.deleteSessions()
+	}()
+
+	if err := target.authenticate(); err != nil {
+		return nil, fmt.Errorf("authenticate: %w", err)
+	}
+
+	gravitySettings := conf.GravitySettings
+	configSettings := conf.ConfigSettings
+	if conf.FullSync {
+		gravitySettings = newFullSyncGravitySettings()
+		configSettings = newFullSyncConfigSettings()
+	}
+
+	rules, err := conf.Exclude.Rules()
+	if err != nil {
+		return nil, fmt.Errorf("exclude rules: %w", err)
+	}
+
+	primaryConfig, err := target.Primary.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	expected := selectPatchConfigElements(createPatchConfigRequest(configSettings, primaryConfig), configSettings.Elements)
+
+	expectedEntities, err := gravityEntities(target.Primary, gravitySettings, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.DriftStatePath != "" {
+		if err := target.loadDriftState(conf.DriftStatePath); err != nil {
+			log.Warn().Err(err).Msg("Failed to load drift state")
+		}
+		defer func() {
+			if err == nil {
+				if err := target.saveDriftState(conf.DriftStatePath); err != nil {
+					log.Warn().Err(err).Msg("Failed to save drift state")
+				}
+			}
+		}()
+	}
+
+	now := time.Now()
+	for _, replica := range target.Replicas {
+		actual, err := replica.GetConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		actualEntities, err := gravityEntities(replica, gravitySettings, rules)
+		if err != nil {
+			return nil, err
+		}
+
+		rewritten, _ := rewritePatchConfigRequest(expected, conf.Rewrites, replica)
+		rewritten = mergePatchConfigElements(rewritten, configSettings.Elements, actual)
+		divergences := append(compareConfig(rewritten, actual), compareEntities(expectedEntities, actualEntities)...)
+		target.trackDrift(replica.String(), divergences, now)
+
+		for _, divergence := range divergences {
+			log.Warn().
+				Str("replica", replica.String()).
+				Str("key", divergence.Key).
+				Interface("expected", divergence.Expected).
+				Interface("actual", divergence.Actual).
+				Time("since", divergence.Since).
+				Msg("Drift detected")
+		}
+
+		reports = append(reports, Report{Replica: replica.String(), Divergences: divergences})
+	}
+
+	return reports, nil
+}
+
+// trackDrift sets the time each divergence was first observed and forgets divergences that have been resolved.
+func (target *target) trackDrift(replica string, divergences []Divergence, now time.Time) {
+	if target.driftSince == nil {
+		target.driftSince = make(map[string]map[string]time.Time)
+	}
+
+	previous := target.driftSince[replica]
+	current := make(map[string]time.Time, len(divergences))
+
+	for i := range divergences {
+		since, exists := previous[divergences[i].Key]
+		if !exists {
+			since = now
+		}
+		current[divergences[i].Key] = since
+		divergences[i].Since = since
+	}
+
+	target.driftSince[replica] = current
+}
+
+// loadDriftState replaces the tracked first-seen times with the ones saved at path, so they survive restarts and
+// single runs. A missing file leaves them as they are.
+func (target *target) loadDriftState(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	state := make(map[string]map[string]time.Time)
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	target.driftSince = state
+	return nil
+}
+
+// saveDriftState writes the tracked first-seen times to path, through a temporary file so that a crash leaves
+// either the old or the new state behind.
+func (target *target) saveDriftState(path string) error {
+	data, err := json.Marshal(target.driftSince)
+	if err != nil {
+		return fmt.Errorf("marshal drift state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create drift state dir: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write drift state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace drift state: %w", err)
+	}
+	return nil
+}
+
+func gravityEntities(client pihole.Client, gravitySettings *config.GravitySettings, rules *exclude.Rules) (map[string]string, error) {
+	entities := make(map[string]string)
+
+	if gravitySettings == nil {
+		return entities, nil
+	}
+
+	if gravitySettings.Group {
+		groups, err := client.GetGroups()
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			entities[fmt.Sprintf("gravity.groups[%s]", group.Name)] = enabledState(group.Enabled)
+		}
+	}
+
+	if gravitySettings.Adlist {
+		lists, err := getLists(client, rules)
+		if err != nil {
+			return nil, err
+		}
+		for _, list := range lists {
+			entities[fmt.Sprintf("gravity.lists[%s:%s]", list.Type, list.Address)] = enabledState(list.Enabled)
+		}
+	}
+
+	if gravitySettings.Domainlist {
+		domains, err := getDomains(client, rules)
+		if err != nil {
+			return nil, err
+		}
+		for _, domain := range domains {
+			entities[fmt.Sprintf("gravity.domains[%s/%s:%s]", domain.Type, domain.Kind, domain.Domain)] = enabledState(domain.Enabled)
+		}
+	}
+
+	if gravitySettings.Client {
+		clients, err := client.GetClients()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range clients {
+			entities[fmt.Sprintf("gravity.clients[%s]", c.Client)] = "present"
+		}
+	}
+
+	return entities, nil
+}
+
+func compareEntities(expected, actual map[string]string) []Divergence {
+	var divergences []Divergence
+
+	for key, expectedState := range expected {
+		if actualState, exists := actual[key]; !exists {
+			divergences = append(divergences, Divergence{Key: key, Expected: expectedState, Actual: nil})
+		} else if actualState != expectedState {
+			divergences = append(divergences, Divergence{Key: key, Expected: expectedState, Actual: actualState})
+		}
+	}
+
+	for key, actualState := range actual {
+		if _, exists := expected[key]; !exists {
+			divergences = append(divergences, Divergence{Key: key, Expected: nil, Actual: actualState})
+		}
+	}
+
+	sort.Slice(divergences, func(i, j int) bool {
+		return divergences[i].Key < divergences[j].Key
+	})
+
+	return divergences
+}
+
+func enabledState(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+func Drifted(reports []Report) bool {
+	for _, report := range reports {
+		if len(report.Divergences) > 0 {
+			return true
+		}
+	}
+	return false
+}
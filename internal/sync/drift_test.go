@@ -0,0 +1,113 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarget_DetectDrift(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+
+	target := NewTarget(primary, []pihole.Client{replica}, nil)
+
+	primary.EXPECT().PostAuth().Once().Return(nil)
+	replica.EXPECT().PostAuth().Once().Return(nil)
+
+	primaryConfig := emptyConfigResponse()
+	primaryConfig.Config["dns"] = map[string]interface{}{"upstreams": []interface{}{"1.1.1.1"}}
+	replicaConfig := emptyConfigResponse()
+	replicaConfig.Config["dns"] = map[string]interface{}{"upstreams": []interface{}{"8.8.8.8"}}
+
+	primary.EXPECT().GetConfig().Once().Return(primaryConfig, nil)
+	replica.EXPECT().GetConfig().Once().Return(replicaConfig, nil)
+
+	primary.EXPECT().GetDomains().Once().Return([]model.Domain{{Domain: "example.com", Type: "allow", Kind: "exact", Enabled: true}}, nil)
+	replica.EXPECT().GetDomains().Once().Return([]model.Domain{}, nil)
+
+	replica.EXPECT().String().Return("http://replica")
+
+	primary.EXPECT().DeleteSession().Once().Return(nil)
+	replica.EXPECT().DeleteSession().Once().Return(nil)
+
+	reports, err := target.DetectDrift(&config.Sync{
+		GravitySettings: &config.GravitySettings{Domainlist: true},
+		ConfigSettings: &config.ConfigSettings{
+			Sections: map[string]*config.ConfigSetting{
+				"dns": config.NewConfigSetting(true, nil, nil),
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.True(t, Drifted(reports))
+
+	divergences := reports[0].Divergences
+	require.Len(t, divergences, 2)
+	assert.Equal(t, "dns.upstreams", divergences[0].Key)
+	assert.Equal(t, "gravity.domains[allow/exact:example.com]", divergences[1].Key)
+	assert.Equal(t, "enabled", divergences[1].Expected)
+	assert.Nil(t, divergences[1].Actual)
+}
+
+func Test_target_trackDrift(t *testing.T) {
+	target := target{}
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	divergences := []Divergence{{Key: "dns.upstreams"}}
+	target.trackDrift("replica", divergences, first)
+	assert.Equal(t, first, divergences[0].Since)
+
+	divergences = []Divergence{{Key: "dns.upstreams"}, {Key: "dns.port"}}
+	target.trackDrift("replica", divergences, second)
+	assert.Equal(t, first, divergences[0].Since)
+	assert.Equal(t, second, divergences[1].Since)
+
+	target.trackDrift("replica", nil, second)
+	divergences = []Divergence{{Key: "dns.upstreams"}}
+	target.trackDrift("replica", divergences, second)
+	assert.Equal(t, second, divergences[0].Since)
+}
+
+func Test_target_driftState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "drift.json")
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	before := target{}
+	require.NoError(t, before.loadDriftState(path), "missing state")
+	before.trackDrift("replica", []Divergence{{Key: "dns.upstreams"}}, first)
+	require.NoError(t, before.saveDriftState(path))
+
+	// a new target, as after a restart, keeps the first-seen time
+	after := target{}
+	require.NoError(t, after.loadDriftState(path))
+	divergences := []Divergence{{Key: "dns.upstreams"}}
+	after.trackDrift("replica", divergences, first.Add(time.Hour))
+	assert.Equal(t, first, divergences[0].Since)
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	assert.Error(t, after.loadDriftState(path))
+}
+
+func Test_compareEntities(t *testing.T) {
+	divergences := compareEntities(
+		map[string]string{"a": "enabled", "b": "enabled"},
+		map[string]string{"b": "disabled", "c": "enabled"},
+	)
+
+	assert.Equal(t, []Divergence{
+		{Key: "a", Expected: "enabled", Actual: nil},
+		{Key: "b", Expected: "enabled", Actual: "disabled"},
+		{Key: "c", Expected: nil, Actual: "enabled"},
+	}, divergences)
+}
@@ -0,0 +1,103 @@
+package sync
+
+import (
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync/exclude"
+	"github.com/rs/zerolog/log"
+)
+
+func excludeLists(rules *exclude.Rules, lists []model.List, groups []model.Group) ([]model.List, []exclude.Excluded) {
+	return excludeEntities(lists, "adlist", rules.Adlist, func(l model.List) exclude.Item {
+		return newExcludeItem(l.Address, l.Comment, l.Groups, groups)
+	})
+}
+
+func excludeDomains(rules *exclude.Rules, domains []model.Domain, groups []model.Group) ([]model.Domain, []exclude.Excluded) {
+	return excludeEntities(domains, "domain", rules.Domain, func(d model.Domain) exclude.Item {
+		return newExcludeItem(d.Domain, d.Comment, d.Groups, groups)
+	})
+}
+
+func excludeEntities[T any](entities []T, kind string, match func(exclude.Item) string, item func(T) exclude.Item) ([]T, []exclude.Excluded) {
+	kept := make([]T, 0, len(entities))
+	var excluded []exclude.Excluded
+
+	for _, entity := range entities {
+		entityItem := item(entity)
+		if reason := match(entityItem); reason != "" {
+			excluded = append(excluded, exclude.Excluded{Kind: kind, Value: entityItem.Value, Reason: reason})
+			continue
+		}
+		kept = append(kept, entity)
+	}
+
+	return kept, excluded
+}
+
+func newExcludeItem(value string, comment *string, groupIds []int, groups []model.Group) exclude.Item {
+	item := exclude.Item{Value: value, Groups: groupNames(groupIds, groups)}
+	if comment != nil {
+		item.Comment = *comment
+	}
+	return item
+}
+
+func groupNames(ids []int, groups []model.Group) []string {
+	var names []string
+	for _, id := range ids {
+		for _, group := range groups {
+			if group.Id == id {
+				names = append(names, group.Name)
+			}
+		}
+	}
+	return names
+}
+
+// getLists returns the client's adlists that are not excluded by the rules.
+func getLists(client pihole.Client, rules *exclude.Rules) ([]model.List, error) {
+	lists, err := client.GetLists()
+	if err != nil || rules.Empty() {
+		return lists, err
+	}
+
+	groups, err := client.GetGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	lists, _ = excludeLists(rules, lists, groups)
+	return lists, nil
+}
+
+// getDomains returns the client's domains that are not excluded by the rules.
+func getDomains(client pihole.Client, rules *exclude.Rules) ([]model.Domain, error) {
+	domains, err := client.GetDomains()
+	if err != nil || rules.Empty() {
+		return domains, err
+	}
+
+	groups, err := client.GetGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	domains, _ = excludeDomains(rules, domains, groups)
+	return domains, nil
+}
+
+// logExcluded summarizes the gravity entities that the exclusion rules kept out of the run.
+func logExcluded(results []StepResult) {
+	count := 0
+	for _, result := range results {
+		for _, item := range result.Excluded {
+			log.Info().Str("step", result.Step).Str("kind", item.Kind).Str("value", item.Value).Str("reason", item.Reason).Msg("Excluded from sync")
+			count++
+		}
+	}
+
+	if count > 0 {
+		log.Info().Int("excluded", count).Msg("Excluded gravity entities")
+	}
+}
@@ -0,0 +1,139 @@
+package exclude
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Pattern matches a value either as a glob, where `*` matches any sequence of characters and `?` a single
+// character, or as a regular expression when wrapped in slashes, e.g. `/^debug\./`. Globs are case-insensitive.
+type Pattern struct {
+	raw   string
+	regex *regexp.Regexp
+}
+
+func ParsePattern(raw string) (*Pattern, error) {
+	var expr string
+	if len(raw) > 1 && strings.HasPrefix(raw, "/") && strings.HasSuffix(raw, "/") {
+		expr = raw[1 : len(raw)-1]
+	} else {
+		expr = "(?i)^" + globToRegex(raw) + "$"
+	}
+
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", raw, err)
+	}
+
+	return &Pattern{raw: raw, regex: regex}, nil
+}
+
+func (p *Pattern) Match(value string) bool {
+	return p.regex.MatchString(value)
+}
+
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+func globToRegex(glob string) string {
+	var sb strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return sb.String()
+}
+
+// Item is a gravity entity as seen by the exclusion rules.
+type Item struct {
+	Value   string
+	Comment string
+	Groups  []string
+}
+
+type Excluded struct {
+	Kind   string
+	Value  string
+	Reason string
+}
+
+type Rules struct {
+	domains    []*Pattern
+	adlists    []*Pattern
+	commentTag string
+	groups     []string
+}
+
+func NewRules(domains, adlists []string, commentTag string, groups []string) (*Rules, error) {
+	rules := &Rules{
+		commentTag: commentTag,
+		groups:     groups,
+	}
+
+	for _, raw := range domains {
+		pattern, err := ParsePattern(raw)
+		if err != nil {
+			return nil, err
+		}
+		rules.domains = append(rules.domains, pattern)
+	}
+
+	for _, raw := range adlists {
+		pattern, err := ParsePattern(raw)
+		if err != nil {
+			return nil, err
+		}
+		rules.adlists = append(rules.adlists, pattern)
+	}
+
+	return rules, nil
+}
+
+func (rules *Rules) Empty() bool {
+	return rules == nil || (len(rules.domains) == 0 && len(rules.adlists) == 0 && rules.commentTag == "" && len(rules.groups) == 0)
+}
+
+// Domain returns why the domain item is excluded, or an empty string if it is not.
+func (rules *Rules) Domain(item Item) string {
+	if rules == nil {
+		return ""
+	}
+	return rules.match(rules.domains, item)
+}
+
+// Adlist returns why the adlist item is excluded, or an empty string if it is not.
+func (rules *Rules) Adlist(item Item) string {
+	if rules == nil {
+		return ""
+	}
+	return rules.match(rules.adlists, item)
+}
+
+func (rules *Rules) match(patterns []*Pattern, item Item) string {
+	for _, pattern := range patterns {
+		if pattern.Match(item.Value) {
+			return "pattern " + pattern.String()
+		}
+	}
+
+	if rules.commentTag != "" && strings.Contains(item.Comment, rules.commentTag) {
+		return "comment tag " + rules.commentTag
+	}
+
+	for _, group := range rules.groups {
+		if slices.Contains(item.Groups, group) {
+			return "group " + group
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,62 @@
+package exclude
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePattern_glob(t *testing.T) {
+	pattern, err := ParsePattern("*.test.example.com")
+	require.NoError(t, err)
+
+	assert.True(t, pattern.Match("debug.test.example.com"))
+	assert.True(t, pattern.Match("A.TEST.example.com"))
+	assert.False(t, pattern.Match("test.example.com"))
+	assert.False(t, pattern.Match("debug.test.example.com.evil"))
+}
+
+func TestParsePattern_globUrl(t *testing.T) {
+	pattern, err := ParsePattern("https://internal.example.com/*")
+	require.NoError(t, err)
+
+	assert.True(t, pattern.Match("https://internal.example.com/lists/debug.txt"))
+	assert.False(t, pattern.Match("https://example.com/lists/debug.txt"))
+}
+
+func TestParsePattern_regex(t *testing.T) {
+	pattern, err := ParsePattern(`/^debug\d+\./`)
+	require.NoError(t, err)
+
+	assert.True(t, pattern.Match("debug1.example.com"))
+	assert.False(t, pattern.Match("debug.example.com"))
+}
+
+func TestParsePattern_invalid(t *testing.T) {
+	_, err := ParsePattern("/(/")
+	assert.Error(t, err)
+}
+
+func TestRules(t *testing.T) {
+	rules, err := NewRules([]string{"*.test"}, []string{"*internal*"}, "#nosync", []string{"Testing"})
+	require.NoError(t, err)
+	assert.False(t, rules.Empty())
+
+	assert.Equal(t, "pattern *.test", rules.Domain(Item{Value: "a.test"}))
+	assert.Equal(t, "", rules.Domain(Item{Value: "https://internal.example.com"}))
+	assert.Equal(t, "pattern *internal*", rules.Adlist(Item{Value: "https://internal.example.com"}))
+	assert.Equal(t, "comment tag #nosync", rules.Domain(Item{Value: "example.com", Comment: "temporary #nosync"}))
+	assert.Equal(t, "group Testing", rules.Adlist(Item{Value: "https://example.com", Groups: []string{"Default", "Testing"}}))
+	assert.Equal(t, "", rules.Domain(Item{Value: "example.com", Comment: "keep", Groups: []string{"Default"}}))
+}
+
+func TestRules_Empty(t *testing.T) {
+	var rules *Rules
+	assert.True(t, rules.Empty())
+	assert.Equal(t, "", rules.Domain(Item{Value: "example.com"}))
+
+	rules, err := NewRules(nil, nil, "", nil)
+	require.NoError(t, err)
+	assert.True(t, rules.Empty())
+}
@@ -0,0 +1,174 @@
+package exclude
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	gravityDatabase = "etc/pihole/gravity.db"
+	groupSeparator  = "\x1f"
+)
+
+// FilterTeleporter returns a copy of the teleporter archive where excluded domains and adlists have been
+// removed from the gravity database, along with the excluded entities.
+func (rules *Rules) FilterTeleporter(archive []byte) ([]byte, []Excluded, error) {
+	if rules.Empty() {
+		return archive, nil, nil
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("read archive: %w", err)
+	}
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+
+	var excluded []Excluded
+	for _, file := range reader.File {
+		if file.Name != gravityDatabase {
+			if err := writer.Copy(file); err != nil {
+				return nil, nil, fmt.Errorf("copy %s: %w", file.Name, err)
+			}
+			continue
+		}
+
+		database, err := readFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		filtered, gravityExcluded, err := rules.filterGravity(database)
+		if err != nil {
+			return nil, nil, fmt.Errorf("filter gravity: %w", err)
+		}
+		excluded = append(excluded, gravityExcluded...)
+
+		fileWriter, err := writer.CreateHeader(&zip.FileHeader{
+			Name:     file.Name,
+			Method:   file.Method,
+			Modified: file.Modified,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("create %s: %w", file.Name, err)
+		}
+		if _, err := fileWriter.Write(filtered); err != nil {
+			return nil, nil, fmt.Errorf("write %s: %w", file.Name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, nil, fmt.Errorf("close archive: %w", err)
+	}
+
+	return buffer.Bytes(), excluded, nil
+}
+
+func readFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+func (rules *Rules) filterGravity(database []byte) ([]byte, []Excluded, error) {
+	tmp, err := os.CreateTemp("", "nebula-sync-gravity-*.db")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(database); err != nil {
+		tmp.Close()
+		return nil, nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	db, err := sql.Open("sqlite", tmp.Name())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	domains, err := rules.deleteExcluded(db, "domain", rules.Domain, "domainlist", "domain", "domainlist_by_group", "domainlist_id")
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	adlists, err := rules.deleteExcluded(db, "adlist", rules.Adlist, "adlist", "address", "adlist_by_group", "adlist_id")
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	if err := db.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	filtered, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return filtered, append(domains, adlists...), nil
+}
+
+func (rules *Rules) deleteExcluded(db *sql.DB, kind string, match func(Item) string, table, column, groupTable, groupColumn string) ([]Excluded, error) {
+	rows, err := db.Query(fmt.Sprintf(
+		`SELECT t.id, t.%[2]s, COALESCE(t.comment, ''), COALESCE(GROUP_CONCAT(g.name, char(31)), '')
+		FROM %[1]s t
+		LEFT JOIN %[3]s tg ON tg.%[4]s = t.id
+		LEFT JOIN "group" g ON g.id = tg.group_id
+		GROUP BY t.id`, table, column, groupTable, groupColumn))
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", table, err)
+	}
+
+	var ids []int64
+	var excluded []Excluded
+	for rows.Next() {
+		var id int64
+		var item Item
+		var groups string
+		if err := rows.Scan(&id, &item.Value, &item.Comment, &groups); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan %s: %w", table, err)
+		}
+		if groups != "" {
+			item.Groups = strings.Split(groups, groupSeparator)
+		}
+
+		if reason := match(item); reason != "" {
+			ids = append(ids, id)
+			excluded = append(excluded, Excluded{Kind: kind, Value: item.Value, Reason: reason})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", table, err)
+	}
+
+	for _, id := range ids {
+		if _, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", groupTable, groupColumn), id); err != nil {
+			return nil, fmt.Errorf("delete from %s: %w", groupTable, err)
+		}
+		if _, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = ?", table), id); err != nil {
+			return nil, fmt.Errorf("delete from %s: %w", table, err)
+		}
+	}
+
+	return excluded, nil
+}
@@ -0,0 +1,120 @@
+package exclude
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gravitySchema = `
+CREATE TABLE "group" (id INTEGER PRIMARY KEY AUTOINCREMENT, enabled BOOLEAN NOT NULL DEFAULT 1, name TEXT UNIQUE NOT NULL);
+CREATE TABLE domainlist (id INTEGER PRIMARY KEY AUTOINCREMENT, type INTEGER NOT NULL DEFAULT 0, domain TEXT NOT NULL, enabled BOOLEAN NOT NULL DEFAULT 1, comment TEXT);
+CREATE TABLE domainlist_by_group (domainlist_id INTEGER NOT NULL, group_id INTEGER NOT NULL, PRIMARY KEY (domainlist_id, group_id));
+CREATE TABLE adlist (id INTEGER PRIMARY KEY AUTOINCREMENT, address TEXT UNIQUE NOT NULL, enabled BOOLEAN NOT NULL DEFAULT 1, comment TEXT);
+CREATE TABLE adlist_by_group (adlist_id INTEGER NOT NULL, group_id INTEGER NOT NULL, PRIMARY KEY (adlist_id, group_id));
+INSERT INTO "group" (id, name) VALUES (0, 'Default'), (1, 'Testing');
+INSERT INTO domainlist (id, domain, comment) VALUES (1, 'keep.example.com', NULL), (2, 'debug.test', NULL), (3, 'tagged.example.com', 'tmp #nosync'), (4, 'grouped.example.com', NULL);
+INSERT INTO domainlist_by_group VALUES (1, 0), (2, 0), (3, 0), (4, 1);
+INSERT INTO adlist (id, address) VALUES (1, 'https://lists.example.com/ads.txt'), (2, 'https://internal.example.com/debug.txt');
+INSERT INTO adlist_by_group VALUES (1, 0), (2, 0);
+`
+
+func TestRules_FilterTeleporter(t *testing.T) {
+	archive := createArchive(t)
+
+	rules, err := NewRules([]string{"*.test"}, []string{"https://internal.example.com/*"}, "#nosync", []string{"Testing"})
+	require.NoError(t, err)
+
+	filtered, excluded, err := rules.FilterTeleporter(archive)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []Excluded{
+		{Kind: "domain", Value: "debug.test", Reason: "pattern *.test"},
+		{Kind: "domain", Value: "tagged.example.com", Reason: "comment tag #nosync"},
+		{Kind: "domain", Value: "grouped.example.com", Reason: "group Testing"},
+		{Kind: "adlist", Value: "https://internal.example.com/debug.txt", Reason: "pattern https://internal.example.com/*"},
+	}, excluded)
+
+	reader, err := zip.NewReader(bytes.NewReader(filtered), int64(len(filtered)))
+	require.NoError(t, err)
+	require.Len(t, reader.File, 2)
+	assert.Equal(t, "etc/pihole/pihole.toml", reader.File[0].Name)
+
+	database, err := readFile(reader.File[1])
+	require.NoError(t, err)
+	db := openDatabase(t, database)
+
+	assert.Equal(t, []string{"keep.example.com"}, queryStrings(t, db, "SELECT domain FROM domainlist"))
+	assert.Equal(t, []string{"1"}, queryStrings(t, db, "SELECT domainlist_id FROM domainlist_by_group"))
+	assert.Equal(t, []string{"https://lists.example.com/ads.txt"}, queryStrings(t, db, "SELECT address FROM adlist"))
+	assert.Equal(t, []string{"1"}, queryStrings(t, db, "SELECT adlist_id FROM adlist_by_group"))
+}
+
+func TestRules_FilterTeleporter_empty(t *testing.T) {
+	rules, err := NewRules(nil, nil, "", nil)
+	require.NoError(t, err)
+
+	filtered, excluded, err := rules.FilterTeleporter([]byte("not a zip"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("not a zip"), filtered)
+	assert.Empty(t, excluded)
+}
+
+func createArchive(t *testing.T) []byte {
+	path := filepath.Join(t.TempDir(), "gravity.db")
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = db.Exec(gravitySchema)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	database, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+
+	toml, err := writer.Create("etc/pihole/pihole.toml")
+	require.NoError(t, err)
+	_, err = toml.Write([]byte("[dns]\n"))
+	require.NoError(t, err)
+
+	gravity, err := writer.Create(gravityDatabase)
+	require.NoError(t, err)
+	_, err = gravity.Write(database)
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func openDatabase(t *testing.T, database []byte) *sql.DB {
+	path := filepath.Join(t.TempDir(), "filtered.db")
+	require.NoError(t, os.WriteFile(path, database, 0o600))
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func queryStrings(t *testing.T, db *sql.DB, query string) []string {
+	rows, err := db.Query(query)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		require.NoError(t, rows.Scan(&value))
+		values = append(values, value)
+	}
+	require.NoError(t, rows.Err())
+	return values
+}
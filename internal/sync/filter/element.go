@@ -0,0 +1,286 @@
+package filter
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type ElementKind int
+
+const (
+	ElementContains ElementKind = iota
+	ElementRegex
+	ElementIP
+	ElementHost
+	ElementMAC
+)
+
+func (kind ElementKind) String() string {
+	var s string
+	switch kind {
+	case ElementContains:
+		s = "contains"
+	case ElementRegex:
+		s = "regex"
+	case ElementIP:
+		s = "ip"
+	case ElementHost:
+		s = "host"
+	case ElementMAC:
+		s = "mac"
+	}
+	return s
+}
+
+// macAddress matches a hardware address, e.g. in "00:11:22:33:44:55,192.168.1.10,nas".
+var macAddress = regexp.MustCompile(`^[0-9A-Fa-f]{2}([:-][0-9A-Fa-f]{2}){5}$`)
+
+// ElementFilter keeps, or drops, the string elements of the config arrays at the paths matched by its selector
+// that match a predicate:
+//   - contains: the element contains the value
+//   - regex: the element matches the regular expression
+//   - ip: an ip address in the element is in the network, or equal to the address
+//   - host: a hostname in the element matches the glob, case insensitive
+//   - mac: a hardware address in the element starts with the value, case insensitive
+type ElementFilter struct {
+	Drop     bool
+	Path     string
+	Kind     ElementKind
+	Value    string
+	selector *Selector
+	pattern  *regexp.Regexp
+	network  *net.IPNet
+}
+
+// ParseElementFilter parses a filter of the form [!]path=kind:value, where a leading ! drops the matching
+// elements instead of keeping them. For example "dns.hosts=host:*.lan" or "!dhcp.hosts=mac:00:11:22".
+func ParseElementFilter(rule string) (*ElementFilter, error) {
+	target, predicate, found := strings.Cut(strings.TrimSpace(rule), "=")
+	if !found {
+		return nil, fmt.Errorf("element filter %q: expected [!]path=kind:value", rule)
+	}
+
+	ef := &ElementFilter{}
+	ef.Path, ef.Drop = strings.CutPrefix(target, "!")
+	selector, err := ParseSelector(ef.Path)
+	if err != nil {
+		return nil, fmt.Errorf("element filter %q: %w", rule, err)
+	}
+	ef.selector = selector
+
+	kind, value, found := strings.Cut(predicate, ":")
+	if !found || value == "" {
+		return nil, fmt.Errorf("element filter %q: expected kind:value", rule)
+	}
+	ef.Value = value
+
+	switch kind {
+	case ElementContains.String():
+		ef.Kind = ElementContains
+	case ElementRegex.String():
+		ef.Kind = ElementRegex
+		if ef.pattern, err = regexp.Compile(value); err != nil {
+			return nil, fmt.Errorf("element filter %q: %w", rule, err)
+		}
+	case ElementIP.String():
+		ef.Kind = ElementIP
+		if ef.network, err = parseNetwork(value); err != nil {
+			return nil, fmt.Errorf("element filter %q: %w", rule, err)
+		}
+	case ElementHost.String():
+		ef.Kind = ElementHost
+		ef.Value = strings.ToLower(value)
+		if _, err := path.Match(ef.Value, ""); err != nil {
+			return nil, fmt.Errorf("element filter %q: %w", rule, err)
+		}
+	case ElementMAC.String():
+		ef.Kind = ElementMAC
+		ef.Value = normalizeMAC(value)
+	default:
+		return nil, fmt.Errorf("element filter %q: unknown kind %q, expected contains, regex, ip, host or mac", rule, kind)
+	}
+
+	return ef, nil
+}
+
+func parseNetwork(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		return network, err
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid ip address %q", value)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}, nil
+}
+
+func normalizeMAC(value string) string {
+	return strings.ToLower(strings.ReplaceAll(value, "-", ":"))
+}
+
+func (ef *ElementFilter) String() string {
+	s := fmt.Sprintf("%s=%s:%s", ef.Path, ef.Kind, ef.Value)
+	if ef.Drop {
+		s = "!" + s
+	}
+	return s
+}
+
+func (ef *ElementFilter) matches(element string) bool {
+	switch ef.Kind {
+	case ElementContains:
+		return strings.Contains(element, ef.Value)
+	case ElementRegex:
+		return ef.pattern.MatchString(element)
+	case ElementIP:
+		for _, token := range ipToken.FindAllString(element, -1) {
+			if ip := net.ParseIP(token); ip != nil && ef.network.Contains(ip) {
+				return true
+			}
+		}
+	case ElementHost:
+		for _, token := range fields(element) {
+			if isHostname(token) {
+				if matched, _ := path.Match(ef.Value, strings.ToLower(token)); matched {
+					return true
+				}
+			}
+		}
+	case ElementMAC:
+		for _, token := range fields(element) {
+			if macAddress.MatchString(token) && strings.HasPrefix(normalizeMAC(token), ef.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fields splits a config array element, e.g. "192.168.1.10 nas.lan" or "true,192.168.1.0/24,192.168.1.1#53,lan".
+func fields(element string) []string {
+	return strings.FieldsFunc(element, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ',' || r == '#' || r == '/'
+	})
+}
+
+func isHostname(token string) bool {
+	if net.ParseIP(token) != nil || macAddress.MatchString(token) || strings.Contains(token, ":") {
+		return false
+	}
+	return strings.ContainsFunc(token, func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+	}) && token != "true" && token != "false"
+}
+
+// SelectElements returns a copy of json, whose keys are below prefix, where each array filtered by the filters
+// only holds its selected elements. An element is selected if it matches any of the keep filters for its array,
+// or there are none, and none of its drop filters. Non-string elements are always selected. Selecting the
+// primary's elements before rewriting them lets the filters match the primary's values, e.g. its subnet.
+func SelectElements(filters []*ElementFilter, prefix string, json map[string]interface{}) map[string]interface{} {
+	return walkElements(filters, prefix, json, nil, false)
+}
+
+// MergeElements returns a copy of json, whose keys are below prefix, where each array filtered by the filters
+// holds the elements of the array at the same path in current that the filters do not select, followed by the
+// elements of the array in json that are not already present. The arrays in json are expected to hold only the
+// selected elements, see SelectElements. This way only the selected part of an array is synced and the rest of
+// current is kept. The elements of current are matched against the replica's own values.
+func MergeElements(filters []*ElementFilter, prefix string, json, current map[string]interface{}) map[string]interface{} {
+	return walkElements(filters, prefix, json, current, true)
+}
+
+// walkElements returns a copy of json where each array filtered by the filters holds its selected elements, or,
+// when merging, is merged with the array at the same path in current.
+func walkElements(filters []*ElementFilter, prefix string, json, current map[string]interface{}, merge bool) map[string]interface{} {
+	if json == nil || len(filters) == 0 {
+		return json
+	}
+
+	var segments []string
+	if prefix != "" {
+		segments = strings.Split(prefix, ".")
+	}
+	return walkElementsMap(filters, segments, json, current, merge)
+}
+
+func walkElementsMap(filters []*ElementFilter, parent []string, json, current map[string]interface{}, merge bool) map[string]interface{} {
+	result := make(map[string]interface{}, len(json))
+	for key, value := range json {
+		segments := append(parent[:len(parent):len(parent)], key)
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			nested, _ := current[key].(map[string]interface{})
+			result[key] = walkElementsMap(filters, segments, v, nested, merge)
+		case []interface{}:
+			nested, _ := current[key].([]interface{})
+			result[key] = walkElementsArray(filters, segments, v, nested, merge)
+		default:
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func walkElementsArray(filters []*ElementFilter, segments []string, array, current []interface{}, merge bool) []interface{} {
+	var applicable []*ElementFilter
+	for _, ef := range filters {
+		if ef.selector.Matches(segments) {
+			applicable = append(applicable, ef)
+		}
+	}
+	if len(applicable) == 0 {
+		return array
+	}
+
+	result := make([]interface{}, 0, len(array)+len(current))
+	if !merge {
+		for _, element := range array {
+			if selectsElement(applicable, element) {
+				result = append(result, element)
+			}
+		}
+		return result
+	}
+
+	for _, element := range current {
+		if !selectsElement(applicable, element) {
+			result = append(result, element)
+		}
+	}
+	for _, element := range array {
+		if s, ok := element.(string); !ok || !slices.Contains(result, interface{}(s)) {
+			result = append(result, element)
+		}
+	}
+	return result
+}
+
+func selectsElement(filters []*ElementFilter, element interface{}) bool {
+	s, ok := element.(string)
+	return !ok || keepElement(filters, s)
+}
+
+func keepElement(filters []*ElementFilter, element string) bool {
+	keep, kept := false, false
+	for _, ef := range filters {
+		if ef.Drop {
+			if ef.matches(element) {
+				return false
+			}
+			continue
+		}
+		keep = true
+		kept = kept || ef.matches(element)
+	}
+	return !keep || kept
+}
@@ -0,0 +1,91 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseElementFilter(t *testing.T) {
+	ef, err := ParseElementFilter("!dhcp.hosts=mac:00-11-22")
+	require.NoError(t, err)
+	assert.True(t, ef.Drop)
+	assert.Equal(t, "dhcp.hosts", ef.Path)
+	assert.Equal(t, ElementMAC, ef.Kind)
+	assert.Equal(t, "!dhcp.hosts=mac:00:11:22", ef.String())
+
+	for _, rule := range []string{
+		"dns.hosts",
+		"=host:*.lan",
+		"dns.hosts=host",
+		"dns.hosts=host:",
+		"dns.hosts=suffix:.lan",
+		"dns.hosts=regex:(",
+		"dns.hosts=host:[a",
+		"dns.hosts=ip:192.168.1",
+		"dns.hosts=ip:192.168.1.0/33",
+	} {
+		_, err := ParseElementFilter(rule)
+		assert.Error(t, err, rule)
+	}
+}
+
+func TestElementFilter_matches(t *testing.T) {
+	tests := []struct {
+		rule    string
+		element string
+		matches bool
+	}{
+		{"dns.hosts=contains:nas", "192.168.1.10 nas.lan", true},
+		{"dns.hosts=regex:^192\\.168\\.", "10.0.0.1 router.lan", false},
+		{"dns.hosts=ip:192.168.1.0/24", "192.168.1.10 nas.lan", true},
+		{"dns.revServers=ip:192.168.1.1", "true,192.168.1.0/24,192.168.1.1#53,lan", true},
+		{"dns.upstreams=ip:fd00::/8", "fd00::1#5353", true},
+		{"dns.hosts=host:*.lan", "192.168.1.10 NAS.lan nas", true},
+		{"dns.hosts=host:*.lan", "192.168.1.10 nas.home", false},
+		{"dns.cnameRecords=host:www.*", "www.example.com,example.com,300", true},
+		{"dhcp.hosts=host:true", "00:11:22:33:44:55,192.168.1.10,true", false},
+		{"dhcp.hosts=mac:00:11:22", "00-11-22-33-44-55,192.168.1.10,printer", true},
+		{"dhcp.hosts=mac:AA:BB", "00:11:22:33:44:55,192.168.1.10,printer", false},
+	}
+
+	for _, test := range tests {
+		ef, err := ParseElementFilter(test.rule)
+		require.NoError(t, err, test.rule)
+		assert.Equal(t, test.matches, ef.matches(test.element), "%s matches %q", test.rule, test.element)
+	}
+}
+
+func TestMergeElements(t *testing.T) {
+	filters := make([]*ElementFilter, 0, 3)
+	for _, rule := range []string{"dns.hosts=host:*.lan", "!dns.hosts=contains:tv.lan", "!dhcp.hosts=mac:00:11:22"} {
+		ef, err := ParseElementFilter(rule)
+		require.NoError(t, err)
+		filters = append(filters, ef)
+	}
+
+	dns := map[string]interface{}{
+		"hosts":     []interface{}{"192.168.1.10 nas.lan", "192.168.1.20 tv.lan", "192.168.1.30 printer.home"},
+		"upstreams": []interface{}{"1.1.1.1"},
+		"port":      53.0,
+	}
+	current := map[string]interface{}{
+		"hosts":     []interface{}{"10.0.0.5 own.home", "10.0.0.6 old.lan", "10.0.0.7 tv.lan", "192.168.1.10 nas.lan"},
+		"upstreams": []interface{}{"9.9.9.9"},
+	}
+
+	selected := SelectElements(filters, "dns", dns)
+	assert.Equal(t, []interface{}{"192.168.1.10 nas.lan"}, selected["hosts"])
+
+	result := MergeElements(filters, "dns", selected, current)
+	assert.Equal(t, []interface{}{"10.0.0.5 own.home", "10.0.0.7 tv.lan", "192.168.1.10 nas.lan"}, result["hosts"])
+	assert.Equal(t, []interface{}{"1.1.1.1"}, result["upstreams"])
+	assert.Equal(t, 53.0, result["port"])
+	assert.Len(t, dns["hosts"], 3, "input is not modified")
+
+	dhcp := MergeElements(filters, "dhcp", SelectElements(filters, "dhcp", map[string]interface{}{
+		"hosts": []interface{}{"00:11:22:33:44:55,192.168.1.40,phone", "aa:bb:cc:dd:ee:ff,192.168.1.50,laptop"},
+	}), nil)
+	assert.Equal(t, []interface{}{"aa:bb:cc:dd:ee:ff,192.168.1.50,laptop"}, dhcp["hosts"])
+}
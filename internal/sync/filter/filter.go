@@ -24,113 +24,89 @@ func (ft Type) String() string {
 	return s
 }
 
+func (ft Type) negate() Type {
+	if ft == Include {
+		return Exclude
+	}
+	return Include
+}
+
+// ByType returns a copy of json with only the keys matched by the selectors included, or excluded.
 func ByType(filter Type, keys []string, json map[string]interface{}) (map[string]interface{}, error) {
+	var rules Rules
+	var err error
 	switch filter {
 	case Include:
-		return includeKeys(json, keys), nil
+		rules, err = NewRules(keys, nil)
 	case Exclude:
-		return excludeKeys(json, keys), nil
+		rules, err = NewRules(nil, keys)
 	default:
 		return nil, fmt.Errorf("unknown filter type: %v", filter)
 	}
+	if err != nil {
+		return nil, err
+	}
+	return rules.Apply(json), nil
 }
 
-func includeKeys(jsonData map[string]interface{}, keys []string) map[string]interface{} {
-	result := make(map[string]interface{})
+// Apply returns a copy of json with the keys kept by the rules. Maps left empty by the rules are dropped.
+func (rules Rules) Apply(json map[string]interface{}) map[string]interface{} {
+	matched := make([]bool, len(rules))
+	result := Keep(json, func(segments []string) bool {
+		for i, rule := range rules {
+			matched[i] = matched[i] || rule.Selector.Matches(segments)
+		}
+		return rules.Keeps(segments)
+	})
 
-	for _, key := range keys {
-		value := getNestedValue(jsonData, key)
-		if value != nil {
-			setNestedValue(result, key, value)
-		} else {
-			log.Warn().Str("key", key).Msg("Attempted to include missing config")
+	for i, rule := range rules {
+		if !matched[i] {
+			log.Warn().Str("key", rule.Selector.String()).Msgf("Attempted to %s missing config", strings.ToLower(rule.Type.String()))
 		}
 	}
-
 	return result
 }
 
-func excludeKeys(jsonData map[string]interface{}, keys []string) map[string]interface{} {
-	result := deepCopy(jsonData)
-
-	for _, key := range keys {
-		removeNestedKey(result, strings.Split(key, "."))
-	}
-
-	return result
+// Keep returns a copy of json with the leaf keys, given by their path segments, for which keep returns true.
+// Maps left empty are dropped.
+func Keep(json map[string]interface{}, keep func(segments []string) bool) map[string]interface{} {
+	return keepMap(nil, json, keep)
 }
 
-func getNestedValue(data map[string]interface{}, key string) interface{} {
-	keys := strings.Split(key, ".")
-	current := data
-	for i, k := range keys {
-		if next, ok := current[k].(map[string]interface{}); ok {
-			current = next
-			if i == len(keys)-1 {
-				return next
+func keepMap(parent []string, json map[string]interface{}, keep func(segments []string) bool) map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, value := range json {
+		segments := append(parent[:len(parent):len(parent)], key)
+
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			if kept := keepMap(segments, nested, keep); len(kept) > 0 {
+				result[key] = kept
 			}
-		} else if value, ok := current[k]; ok {
-			return value
-		} else {
-			return nil
+		} else if keep(segments) {
+			result[key] = value
 		}
 	}
-	return current
+	return result
 }
 
-func setNestedValue(target map[string]interface{}, key string, value interface{}) {
-	keys := strings.Split(key, ".")
-	current := target
-
-	for _, k := range keys[:len(keys)-1] {
-		if _, exists := current[k]; !exists {
-			current[k] = make(map[string]interface{})
-		}
-		if next, ok := current[k].(map[string]interface{}); ok {
-			current = next
-		}
-	}
-
-	lastKey := keys[len(keys)-1]
-	current[lastKey] = value
+// Flatten returns the leaf values of json keyed by their dotted path.
+func Flatten(json map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	flatten("", json, result)
+	return result
 }
 
-func removeNestedKey(target map[string]interface{}, keys []string) {
-	if len(keys) == 0 {
-		return
-	}
-
-	currentKey := keys[0]
-	remainingKeys := keys[1:]
-
-	_, exists := target[currentKey]
-	if !exists {
-		log.Warn().Str("key", strings.Join(keys, ".")).Msg("Attempted to exclude missing config")
-		return
-	}
-
-	if len(remainingKeys) == 0 {
-		delete(target, currentKey)
-		return
-	}
-
-	if nested, exists := target[currentKey].(map[string]interface{}); exists {
-		removeNestedKey(nested, remainingKeys)
-		if len(nested) == 0 {
-			delete(target, currentKey)
+func flatten(prefix string, json map[string]interface{}, result map[string]interface{}) {
+	for key, value := range json {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
 		}
-	}
-}
 
-func deepCopy(original map[string]interface{}) map[string]interface{} {
-	copied := make(map[string]interface{})
-	for key, value := range original {
-		switch v := value.(type) {
-		case map[string]interface{}:
-			copied[key] = deepCopy(v)
-		default:
-			copied[key] = v
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flatten(path, nested, result)
+		} else {
+			result[path] = value
 		}
 	}
-	return copied
 }
@@ -82,7 +82,8 @@ func TestFilter_IncludeKeys(t *testing.T) {
 	}
 
 	keys := []string{"a", "b.c", "e"}
-	result := includeKeys(data, keys)
+	result, err := ByType(Include, keys, data)
+	assert.NoError(t, err)
 
 	assert.Equal(t, 1, result["a"])
 	assert.Equal(t, 2, result["b"].(map[string]interface{})["c"])
@@ -94,7 +95,8 @@ func TestFilter_IncludeKeys(t *testing.T) {
 func TestFilter_IncludeKeys_MissingKey(t *testing.T) {
 	data := map[string]interface{}{"a": 1}
 	keys := []string{"b"}
-	result := includeKeys(data, keys)
+	result, err := ByType(Include, keys, data)
+	assert.NoError(t, err)
 
 	assert.Empty(t, result)
 }
@@ -107,7 +109,8 @@ func TestFilter_ExcludeKeys(t *testing.T) {
 	}
 
 	keys := []string{"a", "b.c"}
-	result := excludeKeys(data, keys)
+	result, err := ByType(Exclude, keys, data)
+	assert.NoError(t, err)
 
 	assert.NotContains(t, result, "a")
 	assert.NotContains(t, result["b"].(map[string]interface{}), "c")
@@ -118,7 +121,23 @@ func TestFilter_ExcludeKeys(t *testing.T) {
 func TestFilter_ExcludeKeys_NonExistentKey(t *testing.T) {
 	data := map[string]interface{}{"a": 1}
 	keys := []string{"b"}
-	result := excludeKeys(data, keys)
+	result, err := ByType(Exclude, keys, data)
+	assert.NoError(t, err)
 
 	assert.Equal(t, data, result)
 }
+
+func TestFilter_Flatten(t *testing.T) {
+	data := map[string]interface{}{
+		"a": 1,
+		"b": map[string]interface{}{"c": 2, "d": map[string]interface{}{"e": 3}},
+		"f": map[string]interface{}{},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"a":     1,
+		"b.c":   2,
+		"b.d.e": 3,
+		"f":     map[string]interface{}{},
+	}, Flatten(data))
+}
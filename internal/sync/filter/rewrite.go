@@ -0,0 +1,212 @@
+package filter
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+type RewriteKind int
+
+const (
+	Literal RewriteKind = iota
+	Regex
+	CIDR
+)
+
+func (kind RewriteKind) String() string {
+	var s string
+	switch kind {
+	case Literal:
+		s = "literal"
+	case Regex:
+		s = "regex"
+	case CIDR:
+		s = "cidr"
+	}
+	return s
+}
+
+// ipToken matches candidates for ip addresses in a value, e.g. in "192.168.1.1#53" or "fe80::1 router.lan".
+var ipToken = regexp.MustCompile(`[0-9A-Fa-f:.]*[:.][0-9A-Fa-f:.]*`)
+
+// Rewrite replaces parts of the string values at a dotted config path, and below it, on replicas whose url
+// contains Replica. A rule without Replica applies to every replica.
+type Rewrite struct {
+	Replica string
+	Path    string
+	Kind    RewriteKind
+	From    string
+	To      string
+	pattern *regexp.Regexp
+	fromNet *net.IPNet
+	toNet   *net.IPNet
+}
+
+// Rewritten describes a value changed by a rewrite rule.
+type Rewritten struct {
+	Path string
+	From string
+	To   string
+}
+
+// ParseRewrite parses a rule of the form [replica@]path=kind:from>to, where kind is literal, regex or cidr.
+// For example "ph3@dhcp.router=literal:192.168.1.1>10.0.3.1" or "dns.hosts=cidr:192.168.1.0/24>10.0.2.0/24".
+func ParseRewrite(rule string) (*Rewrite, error) {
+	target, replacement, found := strings.Cut(strings.TrimSpace(rule), "=")
+	if !found {
+		return nil, fmt.Errorf("rewrite %q: expected [replica@]path=kind:from>to", rule)
+	}
+
+	rewrite := &Rewrite{Path: target}
+	if replica, path, found := strings.Cut(target, "@"); found {
+		rewrite.Replica, rewrite.Path = replica, path
+	}
+	if rewrite.Path == "" {
+		return nil, fmt.Errorf("rewrite %q: missing path", rule)
+	}
+
+	kind, fromTo, found := strings.Cut(replacement, ":")
+	if !found {
+		return nil, fmt.Errorf("rewrite %q: missing kind", rule)
+	}
+	if rewrite.From, rewrite.To, found = strings.Cut(fromTo, ">"); !found || rewrite.From == "" {
+		return nil, fmt.Errorf("rewrite %q: expected from>to", rule)
+	}
+
+	switch kind {
+	case Literal.String():
+		rewrite.Kind = Literal
+	case Regex.String():
+		rewrite.Kind = Regex
+		pattern, err := regexp.Compile(rewrite.From)
+		if err != nil {
+			return nil, fmt.Errorf("rewrite %q: %w", rule, err)
+		}
+		rewrite.pattern = pattern
+	case CIDR.String():
+		rewrite.Kind = CIDR
+		if err := rewrite.parseNets(); err != nil {
+			return nil, fmt.Errorf("rewrite %q: %w", rule, err)
+		}
+	default:
+		return nil, fmt.Errorf("rewrite %q: unknown kind %q, expected literal, regex or cidr", rule, kind)
+	}
+
+	return rewrite, nil
+}
+
+func (rewrite *Rewrite) parseNets() (err error) {
+	if _, rewrite.fromNet, err = net.ParseCIDR(rewrite.From); err != nil {
+		return err
+	}
+	if _, rewrite.toNet, err = net.ParseCIDR(rewrite.To); err != nil {
+		return err
+	}
+
+	fromOnes, fromBits := rewrite.fromNet.Mask.Size()
+	toOnes, toBits := rewrite.toNet.Mask.Size()
+	if fromOnes != toOnes || fromBits != toBits {
+		return fmt.Errorf("networks %s and %s differ in size", rewrite.From, rewrite.To)
+	}
+	return nil
+}
+
+func (rewrite *Rewrite) String() string {
+	s := fmt.Sprintf("%s=%s:%s>%s", rewrite.Path, rewrite.Kind, rewrite.From, rewrite.To)
+	if rewrite.Replica != "" {
+		s = rewrite.Replica + "@" + s
+	}
+	return s
+}
+
+// AppliesTo reports whether the rule applies to the replica with the given url.
+func (rewrite *Rewrite) AppliesTo(replica string) bool {
+	return rewrite.Replica == "" || strings.Contains(replica, rewrite.Replica)
+}
+
+func (rewrite *Rewrite) matches(path string) bool {
+	return path == rewrite.Path || strings.HasPrefix(path, rewrite.Path+".")
+}
+
+func (rewrite *Rewrite) replace(value string) string {
+	switch rewrite.Kind {
+	case Literal:
+		return strings.ReplaceAll(value, rewrite.From, rewrite.To)
+	case Regex:
+		return rewrite.pattern.ReplaceAllString(value, rewrite.To)
+	case CIDR:
+		return ipToken.ReplaceAllStringFunc(value, func(token string) string {
+			ip := net.ParseIP(token)
+			if ip == nil || !rewrite.fromNet.Contains(ip) {
+				return token
+			}
+			return translate(ip, rewrite.fromNet, rewrite.toNet).String()
+		})
+	}
+	return value
+}
+
+// translate moves ip from one network to another of the same size, keeping its host part.
+func translate(ip net.IP, from, to *net.IPNet) net.IP {
+	if v4 := ip.To4(); v4 != nil && len(from.IP) == net.IPv4len {
+		ip = v4
+	}
+
+	translated := make(net.IP, len(ip))
+	for i := range ip {
+		translated[i] = to.IP[i]&from.Mask[i] | ip[i]&^from.Mask[i]
+	}
+	return translated
+}
+
+// Apply returns a copy of json, whose keys are below prefix, with the rules applied to its string values and
+// the string elements of its arrays. Rules are applied in order.
+func Apply(rules []*Rewrite, prefix string, json map[string]interface{}) (map[string]interface{}, []Rewritten) {
+	if json == nil {
+		return nil, nil
+	}
+
+	var rewritten []Rewritten
+	result := applyMap(rules, prefix, json, &rewritten)
+	return result, rewritten
+}
+
+func applyMap(rules []*Rewrite, prefix string, json map[string]interface{}, rewritten *[]Rewritten) map[string]interface{} {
+	result := make(map[string]interface{}, len(json))
+	for key, value := range json {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		result[key] = applyValue(rules, path, value, rewritten)
+	}
+	return result
+}
+
+func applyValue(rules []*Rewrite, path string, value interface{}, rewritten *[]Rewritten) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return applyMap(rules, path, v, rewritten)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, element := range v {
+			result[i] = applyValue(rules, path, element, rewritten)
+		}
+		return result
+	case string:
+		result := v
+		for _, rule := range rules {
+			if rule.matches(path) {
+				result = rule.replace(result)
+			}
+		}
+		if result != v {
+			*rewritten = append(*rewritten, Rewritten{Path: path, From: v, To: result})
+		}
+		return result
+	default:
+		return value
+	}
+}
@@ -0,0 +1,74 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRewrite(t *testing.T) {
+	rewrite, err := ParseRewrite("ph3@dhcp.router=literal:192.168.1.1>10.0.3.1")
+	require.NoError(t, err)
+	assert.Equal(t, "ph3", rewrite.Replica)
+	assert.Equal(t, "dhcp.router", rewrite.Path)
+	assert.Equal(t, Literal, rewrite.Kind)
+	assert.Equal(t, "ph3@dhcp.router=literal:192.168.1.1>10.0.3.1", rewrite.String())
+	assert.True(t, rewrite.AppliesTo("http://ph3.lan"))
+	assert.False(t, rewrite.AppliesTo("http://ph2.lan"))
+
+	for _, rule := range []string{
+		"dns.hosts",
+		"=literal:a>b",
+		"dns.hosts=literal",
+		"dns.hosts=literal:a",
+		"dns.hosts=sed:a>b",
+		"dns.hosts=regex:(>b",
+		"dns.hosts=cidr:192.168.1.0/24>10.0.0.0/16",
+		"dns.hosts=cidr:192.168.1.0>10.0.0.0/24",
+	} {
+		_, err := ParseRewrite(rule)
+		assert.Error(t, err, rule)
+	}
+}
+
+func TestApply(t *testing.T) {
+	rules := make([]*Rewrite, 0, 3)
+	for _, rule := range []string{
+		"dns.hosts=cidr:192.168.1.0/24>10.0.2.0/24",
+		"dns.revServers=regex:192\\.168\\.1\\.>10.0.2.",
+		"dhcp.router=literal:192.168.1.1>10.0.2.254",
+	} {
+		rewrite, err := ParseRewrite(rule)
+		require.NoError(t, err)
+		rules = append(rules, rewrite)
+	}
+
+	dns := map[string]interface{}{
+		"hosts":      []interface{}{"192.168.1.10 nas.lan", "192.168.2.10 other.lan"},
+		"revServers": []interface{}{"true,192.168.1.0/24,192.168.1.1#53,lan"},
+		"upstreams":  []interface{}{"192.168.1.1"},
+		"port":       53.0,
+	}
+
+	result, rewritten := Apply(rules, "dns", dns)
+	assert.Equal(t, []interface{}{"10.0.2.10 nas.lan", "192.168.2.10 other.lan"}, result["hosts"])
+	assert.Equal(t, []interface{}{"true,10.0.2.0/24,10.0.2.1#53,lan"}, result["revServers"])
+	assert.Equal(t, []interface{}{"192.168.1.1"}, result["upstreams"])
+	assert.Equal(t, 53.0, result["port"])
+	assert.Len(t, rewritten, 2)
+
+	assert.Equal(t, []interface{}{"192.168.1.10 nas.lan", "192.168.2.10 other.lan"}, dns["hosts"], "input is not modified")
+
+	dhcp, rewritten := Apply(rules, "dhcp", map[string]interface{}{"router": "192.168.1.1"})
+	assert.Equal(t, "10.0.2.254", dhcp["router"])
+	assert.Equal(t, []Rewritten{{Path: "dhcp.router", From: "192.168.1.1", To: "10.0.2.254"}}, rewritten)
+}
+
+func TestRewrite_cidrIPv6(t *testing.T) {
+	rewrite, err := ParseRewrite("dns.hosts=cidr:fd00:1::/64>fd00:2::/64")
+	require.NoError(t, err)
+
+	result, _ := Apply([]*Rewrite{rewrite}, "dns", map[string]interface{}{"hosts": []interface{}{"fd00:1::10 nas.lan"}})
+	assert.Equal(t, []interface{}{"fd00:2::10 nas.lan"}, result["hosts"])
+}
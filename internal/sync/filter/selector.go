@@ -0,0 +1,186 @@
+package filter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Selector matches dotted config paths. A selector is either a dotted path whose segments may contain the glob
+// patterns of path.Match, with a "**" segment matching any number of segments, or a regular expression enclosed
+// in slashes that must match the whole dotted path. A selector also matches every key below the paths it
+// matches, so "reply" selects "reply.host.IPv4".
+type Selector struct {
+	raw      string
+	segments []string
+	pattern  *regexp.Regexp
+}
+
+// ParseSelector parses a glob or /regex/ selector.
+func ParseSelector(s string) (*Selector, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("empty selector")
+	}
+
+	selector := &Selector{raw: s}
+	if len(s) > 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		pattern, err := regexp.Compile("^(?:" + s[1:len(s)-1] + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("selector %q: %w", s, err)
+		}
+		selector.pattern = pattern
+		return selector, nil
+	}
+
+	selector.segments = strings.Split(s, ".")
+	for _, segment := range selector.segments {
+		if segment == "" {
+			return nil, fmt.Errorf("selector %q: empty path segment", s)
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("selector %q: %w", s, err)
+		}
+	}
+	return selector, nil
+}
+
+func (selector *Selector) String() string {
+	return selector.raw
+}
+
+// Matches reports whether the selector matches the path given by its segments, or one of its parents.
+func (selector *Selector) Matches(segments []string) bool {
+	for i := 1; i <= len(segments); i++ {
+		if selector.matchesExactly(segments[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (selector *Selector) matchesExactly(segments []string) bool {
+	if selector.pattern != nil {
+		return selector.pattern.MatchString(strings.Join(segments, "."))
+	}
+	return matchSegments(selector.segments, segments)
+}
+
+func matchSegments(patterns, segments []string) bool {
+	if len(patterns) == 0 {
+		return len(segments) == 0
+	}
+
+	if patterns[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(patterns[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+	matched, _ := path.Match(patterns[0], segments[0])
+	return matched && matchSegments(patterns[1:], segments[1:])
+}
+
+// Rule includes or excludes the keys matched by its selector.
+type Rule struct {
+	Type     Type
+	Selector *Selector
+}
+
+func (rule Rule) String() string {
+	if rule.Type == Include {
+		return "+" + rule.Selector.String()
+	}
+	return "-" + rule.Selector.String()
+}
+
+// Rules is an ordered list of include and exclude rules. The last rule matching a key decides whether it is kept.
+// Keys matched by no rule are dropped if the first rule is an include rule, and kept otherwise.
+type Rules []Rule
+
+// NewRules returns the rules for include and exclude selectors, with the exclude rules ordered after the include
+// rules so that they take precedence. A selector prefixed with "!" is negated: it excludes keys when included and
+// includes keys when excluded, e.g. include "reply.**,!reply.blocking" keeps all reply keys except reply.blocking.
+func NewRules(include, exclude []string) (Rules, error) {
+	rules := make(Rules, 0, len(include)+len(exclude))
+	for _, list := range []struct {
+		filterType Type
+		selectors  []string
+	}{{Include, include}, {Exclude, exclude}} {
+		for _, s := range list.selectors {
+			rule := Rule{Type: list.filterType}
+			if negated, found := strings.CutPrefix(strings.TrimSpace(s), "!"); found {
+				rule.Type, s = rule.Type.negate(), negated
+			}
+
+			selector, err := ParseSelector(s)
+			if err != nil {
+				return nil, err
+			}
+			rule.Selector = selector
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// ParseRules parses an ordered list of rules, each a selector prefixed with "+" to include or "-" to exclude the
+// keys it matches, e.g. "+reply.**,-reply.blocking,+reply.blocking.IPv4". Unlike NewRules, the rules keep the
+// given order.
+func ParseRules(list []string) (Rules, error) {
+	rules := make(Rules, 0, len(list))
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		rule := Rule{}
+		switch {
+		case strings.HasPrefix(s, "+"):
+			rule.Type = Include
+		case strings.HasPrefix(s, "-"):
+			rule.Type = Exclude
+		default:
+			return nil, fmt.Errorf("rule %q: expected a + or - prefix", s)
+		}
+
+		selector, err := ParseSelector(s[1:])
+		if err != nil {
+			return nil, err
+		}
+		rule.Selector = selector
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (rules Rules) String() string {
+	s := make([]string, len(rules))
+	for i, rule := range rules {
+		s[i] = rule.String()
+	}
+	return strings.Join(s, ",")
+}
+
+// Keeps reports whether the key given by its path segments is kept.
+func (rules Rules) Keeps(segments []string) bool {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].Selector.Matches(segments) {
+			return rules[i].Type == Include
+		}
+	}
+	return len(rules) == 0 || rules[0].Type != Include
+}
+
+// Includes reports whether the key given by its path segments is kept by an include rule, rather than because
+// no rule matches it.
+func (rules Rules) Includes(segments []string) bool {
+	return rules.Keeps(segments) && slices.ContainsFunc(rules, func(rule Rule) bool {
+		return rule.Type == Include && rule.Selector.Matches(segments)
+	})
+}
@@ -0,0 +1,141 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelector_Matches(t *testing.T) {
+	tests := []struct {
+		selector string
+		path     string
+		matches  bool
+	}{
+		// exact paths match the key and everything below it
+		{"upstreams", "upstreams", true},
+		{"reply", "reply.host.IPv4", true},
+		{"reply.host", "reply.blocking.IPv4", false},
+		{"reply.host.IPv4", "reply.host", false},
+		// "*" and the other path.Match patterns match within a single segment
+		{"*.interface", "dhcp.interface", true},
+		{"*.interface", "a.b.interface", false},
+		{"reply.*.force?", "reply.host.force4", true},
+		{"cache.[st]*", "cache.size", true},
+		{"cache.[st]*", "cache.optimizer", false},
+		// "**" matches any number of segments, including none
+		{"**.interface", "interface", true},
+		{"**.interface", "a.b.interface", true},
+		{"reply.**", "reply.host.IPv6", true},
+		{"**.IPv4", "reply.host.IPv6", false},
+		// /regex/ matches the whole dotted path, or a parent path
+		{"/reply\\.(host|blocking)/", "reply.host.force4", true},
+		{"/.*force[46]/", "reply.blocking.force6", true},
+		{"/host/", "reply.host", false},
+	}
+
+	for _, test := range tests {
+		selector, err := ParseSelector(test.selector)
+		require.NoError(t, err, test.selector)
+		assert.Equal(t, test.matches, selector.Matches(strings.Split(test.path, ".")), "%s matches %s", test.selector, test.path)
+	}
+}
+
+func TestParseSelector_invalid(t *testing.T) {
+	for _, selector := range []string{"", " ", "reply..host", "reply.", "[a", "/(/"} {
+		_, err := ParseSelector(selector)
+		assert.Error(t, err, selector)
+	}
+}
+
+func TestRules_Apply(t *testing.T) {
+	data := loadDnsData()
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{
+			name:     "negated include keeps all but the negated keys",
+			include:  []string{"reply.**", "!reply.blocking"},
+			expected: []string{"reply.host.IPv4", "reply.host.IPv6", "reply.host.force4", "reply.host.force6"},
+		},
+		{
+			name:     "exclude takes precedence over include",
+			include:  []string{"reply"},
+			exclude:  []string{"**.IPv6"},
+			expected: []string{"reply.blocking.IPv4", "reply.blocking.force4", "reply.blocking.force6", "reply.host.IPv4", "reply.host.force4", "reply.host.force6"},
+		},
+		{
+			name:     "negated exclude includes keys again",
+			include:  []string{"reply.host"},
+			exclude:  []string{"reply", "!reply.*.IPv4"},
+			expected: []string{"reply.blocking.IPv4", "reply.host.IPv4"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules, err := NewRules(test.include, test.exclude)
+			require.NoError(t, err)
+
+			result := rules.Apply(data)
+			assert.ElementsMatch(t, test.expected, keys(Flatten(result)))
+		})
+	}
+}
+
+func TestRules_Apply_negatedOnly(t *testing.T) {
+	rules, err := NewRules([]string{"!**.force?", "!/.*IPv6/"}, nil)
+	require.NoError(t, err)
+
+	result := rules.Apply(map[string]interface{}{
+		"reply": map[string]interface{}{"force4": true, "IPv4": "", "IPv6": ""},
+		"port":  53.0,
+	})
+	assert.Equal(t, map[string]interface{}{
+		"reply": map[string]interface{}{"IPv4": ""},
+		"port":  53.0,
+	}, result)
+	assert.Equal(t, "-**.force?,-/.*IPv6/", rules.String())
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]string{"+reply.**", " -reply.blocking", "+reply.blocking.IPv4"})
+	require.NoError(t, err)
+
+	result := rules.Apply(map[string]interface{}{
+		"reply": map[string]interface{}{
+			"host":     map[string]interface{}{"IPv4": ""},
+			"blocking": map[string]interface{}{"IPv4": "", "IPv6": ""},
+		},
+		"port": 53.0,
+	})
+	assert.Equal(t, map[string]interface{}{
+		"reply": map[string]interface{}{
+			"host":     map[string]interface{}{"IPv4": ""},
+			"blocking": map[string]interface{}{"IPv4": ""},
+		},
+	}, result)
+	assert.Equal(t, "+reply.**,-reply.blocking,+reply.blocking.IPv4", rules.String())
+}
+
+func TestParseRules_Invalid(t *testing.T) {
+	_, err := ParseRules([]string{"reply"})
+	assert.EqualError(t, err, `rule "reply": expected a + or - prefix`)
+
+	_, err = ParseRules([]string{"+/(/"})
+	assert.Error(t, err)
+}
+
+func keys(m map[string]interface{}) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
@@ -7,42 +7,34 @@ import (
 )
 
 func (target *target) FullSync(conf *config.Sync) (err error) {
-	return target.sync(func() error {
-		return target.full(conf)
-	}, "full")
+	return target.sync(func(run *pipelineRun) error {
+		return target.full(conf, run)
+	}, "full", conf)
 }
 
-func (target *target) full(conf *config.Sync) error {
-	gravitySettings := newFullSyncGravitySettings()
-	configSettings := newFullSyncConfigSettings()
-
-	if err := target.syncTeleporters(gravitySettings); err != nil {
-		return fmt.Errorf("sync teleporters: %w", err)
+func (target *target) full(conf *config.Sync, run *pipelineRun) error {
+	rules, err := conf.Exclude.Rules()
+	if err != nil {
+		return fmt.Errorf("exclude rules: %w", err)
 	}
 
-	if err := target.syncConfigs(configSettings); err != nil {
-		return fmt.Errorf("sync configs: %w", err)
+	run.configSettings = newFullSyncConfigSettings()
+	steps := target.newSteps(conf, newFullSyncGravitySettings(), run.configSettings, rules)
+	pipeline, err := newPipeline(fullSyncSteps, conf.Pipeline, steps)
+	if err != nil {
+		return err
 	}
 
-	if conf.RunGravity {
-		if err := target.runGravity(); err != nil {
-			return fmt.Errorf("run gravity: %w", err)
-		}
-	}
-	return nil
+	return target.runPipeline(run, pipeline...)
 }
 
 func newFullSyncConfigSettings() *config.ConfigSettings {
 	return &config.ConfigSettings{
-		DNS:       config.NewConfigSetting(true, nil, nil),
-		DHCP:      config.NewConfigSetting(true, nil, nil),
-		NTP:       config.NewConfigSetting(true, nil, nil),
-		Resolver:  config.NewConfigSetting(true, nil, nil),
-		Database:  config.NewConfigSetting(true, nil, nil),
-		Webserver: config.NewConfigSetting(false, nil, nil),
-		Files:     config.NewConfigSetting(false, nil, nil),
-		Misc:      config.NewConfigSetting(true, nil, nil),
-		Debug:     config.NewConfigSetting(true, nil, nil),
+		Default: config.NewConfigSetting(true, nil, nil),
+		Sections: map[string]*config.ConfigSetting{
+			"webserver": config.NewConfigSetting(false, nil, nil),
+			"files":     config.NewConfigSetting(false, nil, nil),
+		},
 	}
 }
 
@@ -6,6 +6,8 @@ import (
 	"github.com/lovelaze/nebula-sync/internal/config"
 	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
 	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync/journal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -15,7 +17,7 @@ func TestTarget_FullSync(t *testing.T) {
 	primary := piholemock.NewClient(t)
 	replica := piholemock.NewClient(t)
 
-	target := NewTarget(primary, []pihole.Client{replica})
+	target := NewTarget(primary, []pihole.Client{replica}, nil)
 
 	primary.EXPECT().PostAuth().Once().Return(nil)
 	replica.EXPECT().PostAuth().Once().Return(nil)
@@ -55,12 +57,57 @@ func Test_newFullSyncConfigSettings(t *testing.T) {
 func Test_newFullSyncGravitySettings(t *testing.T) {
 	configSettings := newFullSyncConfigSettings()
 
-	assert.True(t, configSettings.DNS.Enabled)
-	assert.True(t, configSettings.DHCP.Enabled)
-	assert.True(t, configSettings.NTP.Enabled)
-	assert.True(t, configSettings.Database.Enabled)
-	assert.False(t, configSettings.Webserver.Enabled)
-	assert.False(t, configSettings.Files.Enabled)
-	assert.True(t, configSettings.Misc.Enabled)
-	assert.True(t, configSettings.Debug.Enabled)
+	assert.True(t, configSettings.Setting("dns").Enabled)
+	assert.True(t, configSettings.Setting("dhcp").Enabled)
+	assert.True(t, configSettings.Setting("ntp").Enabled)
+	assert.True(t, configSettings.Setting("database").Enabled)
+	assert.False(t, configSettings.Setting("webserver").Enabled)
+	assert.False(t, configSettings.Setting("files").Enabled)
+	assert.True(t, configSettings.Setting("misc").Enabled)
+	assert.True(t, configSettings.Setting("debug").Enabled)
+}
+
+func TestTarget_FullSync_journal(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+	primary.EXPECT().String().Return("http://ph1.lan")
+	replica.EXPECT().String().Return("http://ph2.lan")
+
+	target := NewTarget(primary, []pihole.Client{replica}, nil)
+
+	primary.EXPECT().PostAuth().Once().Return(nil)
+	replica.EXPECT().PostAuth().Once().Return(nil)
+
+	primary.EXPECT().GetTeleporter().Once().Return([]byte("zip"), nil)
+	replica.EXPECT().PostTeleporter(mock.Anything, mock.Anything).Once().Return(nil)
+
+	primary.EXPECT().GetConfig().Once().Return(&model.ConfigResponse{Config: map[string]interface{}{
+		"dns": map[string]interface{}{"upstreams": []interface{}{"1.1.1.1"}, "port": 53.0},
+	}}, nil)
+	replica.EXPECT().GetConfig().Once().Return(&model.ConfigResponse{Config: map[string]interface{}{
+		"dns": map[string]interface{}{"upstreams": []interface{}{"8.8.8.8"}, "port": 53.0},
+	}}, nil)
+	replica.EXPECT().PatchConfig(mock.Anything).Once().Return(nil)
+
+	primary.EXPECT().DeleteSession().Once().Return(nil)
+	replica.EXPECT().DeleteSession().Once().Return(nil)
+
+	err := target.FullSync(&config.Sync{
+		FullSync: true,
+		Journal:  &config.JournalSettings{Enabled: true, Path: "journal.jsonl"},
+	})
+	require.NoError(t, err)
+
+	record := target.LastRun()
+	require.NotNil(t, record)
+	assert.Equal(t, "full", record.Mode)
+	assert.Equal(t, "http://ph1.lan", record.Primary)
+	assert.True(t, record.Success)
+	assert.Equal(t, "4a70fe9aa6436e02c2dea340fbd1e352e4ef2d8ce6ca52ad25d4b95471fc8bf2", record.Teleporter)
+	assert.Equal(t, 1, record.ChangedKeys)
+
+	require.Len(t, record.Steps, 5)
+	assert.Equal(t, "configs", record.Steps[2].Name)
+	assert.Equal(t, []journal.Replica{{Target: "http://ph2.lan", Changed: 1}}, record.Steps[2].Replicas)
+	assert.True(t, record.Steps[3].Skipped)
 }
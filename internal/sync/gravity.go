@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+)
+
+// adlistStates returns the adlist state of every replica, so that it can be compared after the sync.
+func adlistStates(replicas []pihole.Client) (map[pihole.Client]string, error) {
+	states := make(map[pihole.Client]string, len(replicas))
+	for _, replica := range replicas {
+		state, err := adlistState(replica)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", replica.String(), err)
+		}
+		states[replica] = state
+	}
+	return states, nil
+}
+
+// adlistState describes the client's adlists by address, enabled flag and assigned group names.
+func adlistState(client pihole.Client) (string, error) {
+	lists, err := client.GetLists()
+	if err != nil {
+		return "", err
+	}
+
+	groups, err := client.GetGroups()
+	if err != nil {
+		return "", err
+	}
+
+	lines := make([]string, 0, len(lists))
+	for _, list := range lists {
+		names := groupNames(list.Groups, groups)
+		sort.Strings(names)
+		lines = append(lines, fmt.Sprintf("%s:%s enabled=%t groups=%s", list.Type, list.Address, list.Enabled, strings.Join(names, ",")))
+	}
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// gravityReason returns why gravity should run on the replica, or an empty string if it should not.
+func gravityReason(replica pihole.Client, adlistsBefore string, maxAgeHours int64, now time.Time) (string, error) {
+	adlistsAfter, err := adlistState(replica)
+	if err != nil {
+		return "", err
+	}
+
+	if adlistsAfter != adlistsBefore {
+		return "adlists changed", nil
+	}
+
+	if maxAgeHours <= 0 {
+		return "", nil
+	}
+
+	summary, err := replica.GetSummary()
+	if err != nil {
+		return "", err
+	}
+
+	maxAge := time.Duration(maxAgeHours) * time.Hour
+	if age := now.Sub(time.Unix(summary.Gravity.LastUpdate, 0)); age > maxAge {
+		return fmt.Sprintf("gravity older than %s", maxAge), nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,88 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_gravityStep_conditional(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	changed := piholemock.NewClient(t)
+	unchanged := piholemock.NewClient(t)
+	stale := piholemock.NewClient(t)
+
+	target := target{
+		Primary:  primary,
+		Replicas: []pihole.Client{changed, unchanged, stale},
+	}
+
+	groups := []model.Group{{Id: 0, Name: "Default"}, {Id: 1, Name: "Kids"}}
+	lists := []model.List{{Address: "https://ads.example.com", Type: "block", Groups: []int{0}, Enabled: true}}
+	for _, replica := range []*piholemock.Client{changed, unchanged, stale} {
+		replica.EXPECT().String().Maybe().Return("http://replica")
+		replica.EXPECT().GetGroups().Return(groups, nil)
+		replica.EXPECT().GetLists().Once().Return(lists, nil)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	fresh := &model.SummaryResponse{}
+	fresh.Gravity.LastUpdate = now.Add(-time.Hour).Unix()
+	old := &model.SummaryResponse{}
+	old.Gravity.LastUpdate = now.Add(-48 * time.Hour).Unix()
+
+	changed.EXPECT().GetLists().Once().Return([]model.List{{Address: "https://ads.example.com", Type: "block", Groups: []int{0, 1}, Enabled: true}}, nil)
+	changed.EXPECT().PostRunGravity().Once().Return(nil)
+
+	unchanged.EXPECT().GetLists().Once().Return(lists, nil)
+	unchanged.EXPECT().GetSummary().Once().Return(fresh, nil)
+
+	stale.EXPECT().GetLists().Once().Return(lists, nil)
+	stale.EXPECT().GetSummary().Once().Return(old, nil)
+	stale.EXPECT().PostRunGravity().Once().Return(nil)
+
+	step := newGravityStep(true, &config.GravityRunSettings{Mode: config.GravityRunChanged, MaxAge: 24})
+	step.now = func() time.Time { return now }
+
+	err := target.runPipeline(&pipelineRun{}, step)
+	require.NoError(t, err)
+}
+
+func Test_gravityStep_conditional_primary(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+
+	target := target{
+		Primary:  primary,
+		Replicas: []pihole.Client{replica},
+	}
+
+	primary.EXPECT().PostRunGravity().Once().Return(nil)
+	replica.EXPECT().String().Maybe().Return("http://replica")
+	replica.EXPECT().GetGroups().Return([]model.Group{}, nil)
+	replica.EXPECT().GetLists().Return([]model.List{}, nil)
+
+	err := target.runPipeline(&pipelineRun{}, newGravityStep(true, &config.GravityRunSettings{Mode: config.GravityRunChanged, Primary: true}))
+	require.NoError(t, err)
+}
+
+func Test_gravityStep_always(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+
+	target := target{
+		Primary:  primary,
+		Replicas: []pihole.Client{replica},
+	}
+
+	primary.EXPECT().PostRunGravity().Once().Return(nil)
+	replica.EXPECT().PostRunGravity().Once().Return(nil)
+
+	err := target.runPipeline(&pipelineRun{}, newGravityStep(true, &config.GravityRunSettings{Mode: config.GravityRunAlways}))
+	require.NoError(t, err)
+}
@@ -0,0 +1,275 @@
+package sync
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync/exclude"
+	"github.com/rs/zerolog/log"
+)
+
+type gravity struct {
+	groups  []model.Group
+	lists   []model.List
+	domains []model.Domain
+	clients []model.Client
+}
+
+func readGravity(client pihole.Client) (*gravity, error) {
+	groups, err := client.GetGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	lists, err := client.GetLists()
+	if err != nil {
+		return nil, err
+	}
+
+	domains, err := client.GetDomains()
+	if err != nil {
+		return nil, err
+	}
+
+	clients, err := client.GetClients()
+	if err != nil {
+		return nil, err
+	}
+
+	return &gravity{groups: groups, lists: lists, domains: domains, clients: clients}, nil
+}
+
+// reconcileGroups reconciles the named groups, and the adlists, domains and clients assigned to them,
+// from the primary onto the replica through the gravity entity API. Groups are matched by name
+// across instances.
+func reconcileGroups(primary *gravity, replica pihole.Client, names []string, rules *exclude.Rules) error {
+	replicaGroups, err := replica.GetGroups()
+	if err != nil {
+		return err
+	}
+
+	created := false
+	for _, name := range names {
+		primaryGroup := findGroup(primary.groups, name)
+		if primaryGroup == nil {
+			log.Warn().Str("group", name).Msg("Group not found on primary")
+			continue
+		}
+
+		replicaGroup := findGroup(replicaGroups, name)
+		if replicaGroup == nil {
+			if err := replica.PostGroup(primaryGroup); err != nil {
+				return fmt.Errorf("create group %s: %w", name, err)
+			}
+			created = true
+		} else if replicaGroup.Enabled != primaryGroup.Enabled || !equalComment(replicaGroup.Comment, primaryGroup.Comment) {
+			if err := replica.PutGroup(primaryGroup); err != nil {
+				return fmt.Errorf("update group %s: %w", name, err)
+			}
+		}
+	}
+
+	if created {
+		if replicaGroups, err = replica.GetGroups(); err != nil {
+			return err
+		}
+	}
+
+	replicaGravity, err := readGravity(replica)
+	if err != nil {
+		return err
+	}
+	replicaGravity.lists, _ = excludeLists(rules, replicaGravity.lists, replicaGroups)
+	replicaGravity.domains, _ = excludeDomains(rules, replicaGravity.domains, replicaGroups)
+
+	mapping := newGroupMapping(primary.groups, replicaGroups, names)
+
+	if err := reconcileEntities(mapping, primary.lists, replicaGravity.lists, entityOps[model.List]{
+		key:        func(l model.List) string { return l.Type + ":" + l.Address },
+		groups:     func(l model.List) []int { return l.Groups },
+		withGroups: func(l model.List, groups []int) model.List { l.Groups = groups; return l },
+		equal: func(a, b model.List) bool {
+			return a.Enabled == b.Enabled && equalComment(a.Comment, b.Comment)
+		},
+		post:   replica.PostList,
+		put:    replica.PutList,
+		delete: replica.DeleteList,
+	}); err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	if err := reconcileEntities(mapping, primary.domains, replicaGravity.domains, entityOps[model.Domain]{
+		key:        func(d model.Domain) string { return d.Type + "/" + d.Kind + ":" + d.Domain },
+		groups:     func(d model.Domain) []int { return d.Groups },
+		withGroups: func(d model.Domain, groups []int) model.Domain { d.Groups = groups; return d },
+		equal: func(a, b model.Domain) bool {
+			return a.Enabled == b.Enabled && equalComment(a.Comment, b.Comment)
+		},
+		post:   replica.PostDomain,
+		put:    replica.PutDomain,
+		delete: replica.DeleteDomain,
+	}); err != nil {
+		return fmt.Errorf("domains: %w", err)
+	}
+
+	if err := reconcileEntities(mapping, primary.clients, replicaGravity.clients, entityOps[model.Client]{
+		key:        func(c model.Client) string { return c.Client },
+		groups:     func(c model.Client) []int { return c.Groups },
+		withGroups: func(c model.Client, groups []int) model.Client { c.Groups = groups; return c },
+		equal: func(a, b model.Client) bool {
+			return equalComment(a.Comment, b.Comment)
+		},
+		post:   replica.PostClient,
+		put:    replica.PutClient,
+		delete: replica.DeleteClient,
+	}); err != nil {
+		return fmt.Errorf("clients: %w", err)
+	}
+
+	return nil
+}
+
+// groupMapping translates group ids of the selected groups between the primary and a replica.
+type groupMapping struct {
+	primaryToReplica map[int]int
+	replicaSelected  map[int]bool
+}
+
+func newGroupMapping(primaryGroups, replicaGroups []model.Group, names []string) *groupMapping {
+	mapping := &groupMapping{
+		primaryToReplica: make(map[int]int),
+		replicaSelected:  make(map[int]bool),
+	}
+
+	for _, name := range names {
+		primaryGroup := findGroup(primaryGroups, name)
+		replicaGroup := findGroup(replicaGroups, name)
+		if primaryGroup == nil || replicaGroup == nil {
+			continue
+		}
+		mapping.primaryToReplica[primaryGroup.Id] = replicaGroup.Id
+		mapping.replicaSelected[replicaGroup.Id] = true
+	}
+
+	return mapping
+}
+
+// selected returns the replica ids of the selected groups in the primary's group ids.
+func (mapping *groupMapping) selected(primaryIds []int) []int {
+	var ids []int
+	for _, id := range primaryIds {
+		if replicaId, ok := mapping.primaryToReplica[id]; ok {
+			ids = append(ids, replicaId)
+		}
+	}
+	return ids
+}
+
+// unselected returns the replica group ids that are not part of the selection.
+func (mapping *groupMapping) unselected(replicaIds []int) []int {
+	var ids []int
+	for _, id := range replicaIds {
+		if !mapping.replicaSelected[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+type entityOps[T any] struct {
+	key        func(T) string
+	groups     func(T) []int
+	withGroups func(T, []int) T
+	equal      func(a, b T) bool
+	post       func(*T) error
+	put        func(*T) error
+	delete     func(*T) error
+}
+
+// reconcileEntities makes the replica's assignments to the selected groups match the primary.
+// Entities assigned to a selected group on the primary are created or updated on the replica, keeping
+// any assignments to unselected replica groups. Entities that are only assigned to selected groups on
+// the replica are removed from those groups, and deleted when no other group is left.
+func reconcileEntities[T any](mapping *groupMapping, primary, replica []T, ops entityOps[T]) error {
+	replicaByKey := make(map[string]T, len(replica))
+	for _, entity := range replica {
+		replicaByKey[ops.key(entity)] = entity
+	}
+
+	handled := make(map[string]bool)
+	for _, entity := range primary {
+		selected := mapping.selected(ops.groups(entity))
+		if len(selected) == 0 {
+			continue
+		}
+
+		key := ops.key(entity)
+		handled[key] = true
+
+		existing, exists := replicaByKey[key]
+		if !exists {
+			desired := ops.withGroups(entity, sortedGroups(selected))
+			if err := ops.post(&desired); err != nil {
+				return err
+			}
+			continue
+		}
+
+		desired := ops.withGroups(entity, sortedGroups(append(mapping.unselected(ops.groups(existing)), selected...)))
+		if !ops.equal(existing, desired) || !slices.Equal(sortedGroups(ops.groups(existing)), ops.groups(desired)) {
+			if err := ops.put(&desired); err != nil {
+				return err
+			}
+		}
+	}
+
+	for key, entity := range replicaByKey {
+		if handled[key] {
+			continue
+		}
+
+		groups := ops.groups(entity)
+		remaining := mapping.unselected(groups)
+		if len(remaining) == len(groups) {
+			continue
+		}
+
+		if len(remaining) == 0 {
+			if err := ops.delete(&entity); err != nil {
+				return err
+			}
+			continue
+		}
+
+		desired := ops.withGroups(entity, sortedGroups(remaining))
+		if err := ops.put(&desired); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findGroup(groups []model.Group, name string) *model.Group {
+	for i := range groups {
+		if groups[i].Name == name {
+			return &groups[i]
+		}
+	}
+	return nil
+}
+
+func equalComment(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sortedGroups(groups []int) []int {
+	sorted := slices.Clone(groups)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
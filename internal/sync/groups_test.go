@@ -0,0 +1,186 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync/exclude"
+	"github.com/lovelaze/nebula-sync/internal/sync/journal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_groupsStep(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+
+	target := target{
+		Primary:  primary,
+		Replicas: []pihole.Client{replica},
+	}
+
+	primary.EXPECT().GetGroups().Return([]model.Group{
+		{Id: 0, Name: "Default", Enabled: true},
+		{Id: 1, Name: "Kids", Enabled: true},
+	}, nil)
+	primary.EXPECT().GetLists().Return([]model.List{
+		{Address: "https://kids.example.com", Type: "block", Groups: []int{1}, Enabled: true},
+		{Address: "https://default.example.com", Type: "block", Groups: []int{0}, Enabled: true},
+	}, nil)
+	primary.EXPECT().GetDomains().Return([]model.Domain{
+		{Domain: "games.example.com", Type: "deny", Kind: "exact", Groups: []int{0, 1}, Enabled: true},
+	}, nil)
+	primary.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	replica.EXPECT().GetGroups().Return([]model.Group{
+		{Id: 0, Name: "Default", Enabled: true},
+		{Id: 5, Name: "Local", Enabled: true},
+		{Id: 9, Name: "Kids", Enabled: true},
+	}, nil)
+	replica.EXPECT().GetLists().Return([]model.List{
+		{Address: "https://kids.example.com", Type: "block", Groups: []int{5}, Enabled: true},
+		{Address: "https://stale.example.com", Type: "block", Groups: []int{9}, Enabled: true},
+		{Address: "https://shared.example.com", Type: "block", Groups: []int{5, 9}, Enabled: true},
+		{Address: "https://local.example.com", Type: "block", Groups: []int{5}, Enabled: true},
+	}, nil)
+	replica.EXPECT().GetDomains().Return([]model.Domain{}, nil)
+	replica.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	replica.EXPECT().PutList(&model.List{Address: "https://kids.example.com", Type: "block", Groups: []int{5, 9}, Enabled: true}).Once().Return(nil)
+	replica.EXPECT().DeleteList(&model.List{Address: "https://stale.example.com", Type: "block", Groups: []int{9}, Enabled: true}).Once().Return(nil)
+	replica.EXPECT().PutList(&model.List{Address: "https://shared.example.com", Type: "block", Groups: []int{5}, Enabled: true}).Once().Return(nil)
+	replica.EXPECT().PostDomain(&model.Domain{Domain: "games.example.com", Type: "deny", Kind: "exact", Groups: []int{9}, Enabled: true}).Once().Return(nil)
+
+	err := target.runPipeline(&pipelineRun{}, newGroupsStep([]string{"Kids"}, nil))
+	require.NoError(t, err)
+}
+
+func Test_groupsStep_createGroup(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+
+	target := target{
+		Primary:  primary,
+		Replicas: []pihole.Client{replica},
+	}
+
+	kids := model.Group{Id: 1, Name: "Kids", Enabled: true}
+	primary.EXPECT().GetGroups().Return([]model.Group{{Id: 0, Name: "Default", Enabled: true}, kids}, nil)
+	primary.EXPECT().GetLists().Return([]model.List{}, nil)
+	primary.EXPECT().GetDomains().Return([]model.Domain{}, nil)
+	primary.EXPECT().GetClients().Return([]model.Client{
+		{Client: "10.0.0.2", Groups: []int{1}},
+	}, nil)
+
+	replica.EXPECT().GetGroups().Once().Return([]model.Group{{Id: 0, Name: "Default", Enabled: true}}, nil)
+	replica.EXPECT().PostGroup(&kids).Once().Return(nil)
+	replica.EXPECT().GetGroups().Return([]model.Group{{Id: 0, Name: "Default", Enabled: true}, {Id: 3, Name: "Kids", Enabled: true}}, nil)
+	replica.EXPECT().GetLists().Return([]model.List{}, nil)
+	replica.EXPECT().GetDomains().Return([]model.Domain{}, nil)
+	replica.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	replica.EXPECT().PostClient(&model.Client{Client: "10.0.0.2", Groups: []int{3}}).Once().Return(nil)
+
+	err := target.runPipeline(&pipelineRun{}, newGroupsStep([]string{"Kids", "Missing"}, nil))
+	require.NoError(t, err)
+}
+
+func TestTarget_SelectiveSync_groups(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+
+	target := NewTarget(primary, []pihole.Client{replica}, nil)
+
+	primary.EXPECT().PostAuth().Once().Return(nil)
+	replica.EXPECT().PostAuth().Once().Return(nil)
+
+	primary.EXPECT().GetTeleporter().Once().Return([]byte{}, nil)
+	replica.EXPECT().PostTeleporter(mock.Anything, mock.Anything).Once().Return(nil)
+
+	primary.EXPECT().GetGroups().Return([]model.Group{{Id: 0, Name: "Default", Enabled: true}}, nil)
+	primary.EXPECT().GetLists().Return([]model.List{}, nil)
+	primary.EXPECT().GetDomains().Return([]model.Domain{}, nil)
+	primary.EXPECT().GetClients().Return([]model.Client{}, nil)
+	replica.EXPECT().GetGroups().Return([]model.Group{{Id: 0, Name: "Default", Enabled: true}}, nil)
+	replica.EXPECT().GetLists().Return([]model.List{}, nil)
+	replica.EXPECT().GetDomains().Return([]model.Domain{}, nil)
+	replica.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	primary.EXPECT().GetConfig().Once().Return(emptyConfigResponse(), nil)
+	replica.EXPECT().PatchConfig(mock.Anything).Once().Return(nil)
+
+	primary.EXPECT().DeleteSession().Once().Return(nil)
+	replica.EXPECT().DeleteSession().Once().Return(nil)
+
+	err := target.SelectiveSync(&config.Sync{
+		GravitySettings: &config.GravitySettings{Groups: []string{"Default"}},
+		ConfigSettings:  newFullSyncConfigSettings(),
+	})
+	require.NoError(t, err)
+}
+
+func Test_groupMapping(t *testing.T) {
+	mapping := newGroupMapping(
+		[]model.Group{{Id: 0, Name: "Default"}, {Id: 1, Name: "Kids"}, {Id: 2, Name: "IoT"}},
+		[]model.Group{{Id: 0, Name: "Default"}, {Id: 4, Name: "IoT"}, {Id: 7, Name: "Kids"}},
+		[]string{"Kids", "IoT"},
+	)
+
+	assert.Equal(t, []int{7, 4}, mapping.selected([]int{0, 1, 2}))
+	assert.Equal(t, []int{0, 8}, mapping.unselected([]int{0, 4, 7, 8}))
+}
+
+func Test_groupsStep_exclude(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+
+	target := target{
+		Primary:  primary,
+		Replicas: []pihole.Client{replica},
+	}
+
+	rules, err := exclude.NewRules([]string{"*.test"}, nil, "#nosync", nil)
+	require.NoError(t, err)
+
+	nosync := "debug #nosync"
+	primary.EXPECT().GetGroups().Return([]model.Group{{Id: 0, Name: "Default", Enabled: true}}, nil)
+	primary.EXPECT().GetLists().Return([]model.List{
+		{Address: "https://debug.example.com", Type: "block", Comment: &nosync, Groups: []int{0}, Enabled: true},
+	}, nil)
+	primary.EXPECT().GetDomains().Return([]model.Domain{
+		{Domain: "debug.test", Type: "allow", Kind: "exact", Groups: []int{0}, Enabled: true},
+		{Domain: "ads.example.com", Type: "deny", Kind: "exact", Groups: []int{0}, Enabled: true},
+	}, nil)
+	primary.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	replica.EXPECT().GetGroups().Return([]model.Group{{Id: 0, Name: "Default", Enabled: true}}, nil)
+	replica.EXPECT().GetLists().Return([]model.List{}, nil)
+	replica.EXPECT().GetDomains().Return([]model.Domain{
+		{Domain: "local.test", Type: "allow", Kind: "exact", Groups: []int{0}, Enabled: true},
+	}, nil)
+	replica.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	replica.EXPECT().PostDomain(&model.Domain{Domain: "ads.example.com", Type: "deny", Kind: "exact", Groups: []int{0}, Enabled: true}).Once().Return(nil)
+
+	err = target.runPipeline(&pipelineRun{}, newGroupsStep([]string{"Default"}, rules))
+	require.NoError(t, err)
+
+	require.Len(t, target.results, 1)
+	assert.Equal(t, []exclude.Excluded{
+		{Kind: "adlist", Value: "https://debug.example.com", Reason: "comment tag #nosync"},
+		{Kind: "domain", Value: "debug.test", Reason: "pattern *.test"},
+	}, target.results[0].Excluded)
+
+	primary.EXPECT().String().Return("http://primary").Maybe()
+	replica.EXPECT().String().Return("http://replica").Maybe()
+	record := target.record("selective", time.Now(), nil)
+	assert.Equal(t, []journal.Excluded{
+		{Step: StepGroups, Kind: "adlist", Value: "https://debug.example.com", Reason: "comment tag #nosync"},
+		{Step: StepGroups, Kind: "domain", Value: "debug.test", Reason: "pattern *.test"},
+	}, record.Excluded)
+}
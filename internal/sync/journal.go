@@ -0,0 +1,64 @@
+package sync
+
+import (
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/sync/journal"
+)
+
+func (target *target) LastRun() *journal.Record {
+	return target.run
+}
+
+// record summarizes the results of the run for the journal.
+func (target *target) record(mode string, start time.Time, err error) *journal.Record {
+	record := &journal.Record{
+		Start:   start,
+		End:     time.Now(),
+		Mode:    mode,
+		Primary: target.Primary.String(),
+		Success: err == nil,
+		Error:   errorString(err),
+	}
+
+	for _, result := range target.results {
+		step := journal.Step{
+			Name:     result.Step,
+			Skipped:  result.Skipped,
+			Duration: result.Duration,
+			Error:    errorString(result.Err),
+		}
+
+		for _, replica := range result.Replicas {
+			step.Replicas = append(step.Replicas, journal.Replica{
+				Target:  replica.Replica.String(),
+				Error:   errorString(replica.Err),
+				Changed: replica.Changed,
+			})
+			record.ChangedKeys += replica.Changed
+		}
+
+		for _, excluded := range result.Excluded {
+			record.Excluded = append(record.Excluded, journal.Excluded{
+				Step:   result.Step,
+				Kind:   excluded.Kind,
+				Value:  excluded.Value,
+				Reason: excluded.Reason,
+			})
+		}
+
+		if result.Fingerprint != "" {
+			record.Teleporter = result.Fingerprint
+		}
+		record.Steps = append(record.Steps, step)
+	}
+
+	return record
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,221 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	gosync "sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxRecordSize is the size of the longest journal line that is read. Longer lines are skipped.
+const maxRecordSize = 1024 * 1024
+
+// Record describes a single sync run.
+type Record struct {
+	Start       time.Time  `json:"start"`
+	End         time.Time  `json:"end"`
+	Mode        string     `json:"mode"`
+	Primary     string     `json:"primary"`
+	Success     bool       `json:"success"`
+	Error       string     `json:"error,omitempty"`
+	Teleporter  string     `json:"teleporter,omitempty"`
+	ChangedKeys int        `json:"changed_keys"`
+	Excluded    []Excluded `json:"excluded,omitempty"`
+	Steps       []Step     `json:"steps"`
+}
+
+// Excluded is a gravity entity that the exclusion rules kept out of the run.
+type Excluded struct {
+	Step   string `json:"step"`
+	Kind   string `json:"kind"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+type Step struct {
+	Name     string        `json:"name"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	Replicas []Replica     `json:"replicas,omitempty"`
+}
+
+type Replica struct {
+	Target  string `json:"target"`
+	Error   string `json:"error,omitempty"`
+	Changed int    `json:"changed,omitempty"`
+}
+
+// Journal is an append-only file of records, one json object per line.
+type Journal struct {
+	path      string
+	retention time.Duration
+	mu        gosync.Mutex
+}
+
+// NewJournal returns a journal stored at path. Records older than retention are removed by Prune, a
+// retention of zero keeps every record.
+func NewJournal(path string, retention time.Duration) *Journal {
+	return &Journal{
+		path:      path,
+		retention: retention,
+	}
+}
+
+func (journal *Journal) Append(record *Record) error {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal record: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(journal.path), 0o700); err != nil {
+		return fmt.Errorf("create journal dir: %w", err)
+	}
+
+	file, err := os.OpenFile(journal.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("write journal: %w", err)
+	}
+
+	return file.Close()
+}
+
+// Records returns every record in the order they were appended. Lines that cannot be parsed, such as a
+// line cut short by a crash, are skipped.
+func (journal *Journal) Records() ([]Record, error) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	return journal.read()
+}
+
+// LastSuccess returns the end time of the latest successful run, or the zero time if there is none.
+func (journal *Journal) LastSuccess() (time.Time, error) {
+	records, err := journal.Records()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Success {
+			return records[i].End, nil
+		}
+	}
+
+	return time.Time{}, nil
+}
+
+// Prune removes records that started longer than the retention period before now.
+func (journal *Journal) Prune(now time.Time) error {
+	if journal.retention <= 0 {
+		return nil
+	}
+
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	records, err := journal.read()
+	if err != nil {
+		return err
+	}
+
+	kept := make([]Record, 0, len(records))
+	for _, record := range records {
+		if now.Sub(record.Start) <= journal.retention {
+			kept = append(kept, record)
+		}
+	}
+
+	if len(kept) == len(records) {
+		return nil
+	}
+
+	return journal.write(kept)
+}
+
+func (journal *Journal) read() ([]Record, error) {
+	file, err := os.Open(journal.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	defer file.Close()
+
+	// lines that are too long or not a record are skipped, so a single bad line does not lose the journal
+	var records []Record
+	reader := bufio.NewReaderSize(file, maxRecordSize)
+	for line := 1; ; line++ {
+		data, err := reader.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			log.Warn().Str("path", journal.path).Int("line", line).Msg("Skipping journal line that is too long")
+			for errors.Is(err, bufio.ErrBufferFull) {
+				_, err = reader.ReadSlice('\n')
+			}
+		} else if data = bytes.TrimSpace(data); len(data) > 0 {
+			var record Record
+			if jsonErr := json.Unmarshal(data, &record); jsonErr != nil {
+				log.Warn().Err(jsonErr).Str("path", journal.path).Int("line", line).Msg("Skipping malformed journal line")
+			} else {
+				records = append(records, record)
+			}
+		}
+
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read journal: %w", err)
+		}
+	}
+}
+
+// write replaces the journal with records, through a temporary file so that a crash leaves either the
+// old or the new journal behind.
+func (journal *Journal) write(records []Record) error {
+	tmp := journal.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("create journal: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for i := range records {
+		if err := encoder.Encode(&records[i]); err != nil {
+			file.Close()
+			return fmt.Errorf("write journal: %w", err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("write journal: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+
+	if err := os.Rename(tmp, journal.path); err != nil {
+		return fmt.Errorf("replace journal: %w", err)
+	}
+
+	return nil
+}
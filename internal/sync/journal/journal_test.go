@@ -0,0 +1,90 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal_AppendRecords(t *testing.T) {
+	journal := NewJournal(filepath.Join(t.TempDir(), "data", "journal.jsonl"), 0)
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	first := &Record{
+		Start:       start,
+		End:         start.Add(time.Minute),
+		Mode:        "full",
+		Primary:     "http://ph1.lan",
+		Success:     true,
+		Teleporter:  "abc",
+		ChangedKeys: 2,
+		Steps: []Step{{
+			Name:     "configs",
+			Duration: time.Second,
+			Replicas: []Replica{{Target: "http://ph2.lan", Changed: 2}},
+		}},
+	}
+	second := &Record{Start: start.Add(time.Hour), End: start.Add(time.Hour), Mode: "full", Error: "boom"}
+
+	require.NoError(t, journal.Append(first))
+	require.NoError(t, journal.Append(second))
+
+	records, err := journal.Records()
+	require.NoError(t, err)
+	assert.Equal(t, []Record{*first, *second}, records)
+
+	lastSuccess, err := journal.LastSuccess()
+	require.NoError(t, err)
+	assert.Equal(t, first.End, lastSuccess)
+}
+
+func TestJournal_Records_skipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"mode\":\"full\",\"success\":true}\n{\"mode\":"), 0o600))
+
+	records, err := NewJournal(path, 0).Records()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.True(t, records[0].Success)
+}
+
+func TestJournal_Records_skipsLongLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	long := "{\"mode\":\"" + strings.Repeat("x", 2*maxRecordSize) + "\"}\n"
+	require.NoError(t, os.WriteFile(path, []byte("{\"mode\":\"full\"}\n"+long+"{\"mode\":\"selective\"}\n"), 0o600))
+
+	records, err := NewJournal(path, 0).Records()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "full", records[0].Mode)
+	assert.Equal(t, "selective", records[1].Mode)
+}
+
+func TestJournal_Missing(t *testing.T) {
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal.jsonl"), time.Hour)
+
+	lastSuccess, err := journal.LastSuccess()
+	require.NoError(t, err)
+	assert.True(t, lastSuccess.IsZero())
+	assert.NoError(t, journal.Prune(time.Now()))
+}
+
+func TestJournal_Prune(t *testing.T) {
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal.jsonl"), 24*time.Hour)
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, journal.Append(&Record{Start: now.Add(-48 * time.Hour), Mode: "old"}))
+	require.NoError(t, journal.Append(&Record{Start: now.Add(-time.Hour), Mode: "recent"}))
+
+	require.NoError(t, journal.Prune(now))
+
+	records, err := journal.Records()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "recent", records[0].Mode)
+}
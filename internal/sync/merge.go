@@ -0,0 +1,299 @@
+package sync
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync/exclude"
+	"github.com/lovelaze/nebula-sync/internal/sync/retry"
+	"github.com/rs/zerolog/log"
+)
+
+var provenancePattern = regexp.MustCompile(`\s*\[nebula-sync: [^\]]*\]$`)
+
+// mergeStep unions the domains and adlists of the primary and the merge sources, and reconciles the
+// merged set onto the replicas through the gravity entity api. The primary has the highest priority,
+// followed by the sources in their configured order.
+type mergeStep struct {
+	baseStep
+	settings *config.MergeSettings
+	sources  []pihole.Client
+	rules    *exclude.Rules
+	lists    []merged[model.List]
+	domains  []merged[model.Domain]
+	excluded []exclude.Excluded
+}
+
+// merged is an entity of the merged set. Its groups are referenced by name, since group ids differ
+// between instances.
+type merged[T any] struct {
+	entity  T
+	groups  []string
+	sources []string
+}
+
+func newMergeStep(settings *config.MergeSettings, sources []pihole.Client, rules *exclude.Rules) *mergeStep {
+	return &mergeStep{settings: settings, sources: sources, rules: rules}
+}
+
+func (step *mergeStep) Name() string  { return StepMerge }
+func (step *mergeStep) Enabled() bool { return step.settings != nil && step.settings.Enabled }
+
+func (step *mergeStep) Start(primary pihole.Client, replicas []pihole.Client) ([]pihole.Client, error) {
+	log.Info().Int("sources", len(step.sources)+1).Str("conflict", step.settings.Conflict).Msg("Merging domains and adlists...")
+
+	var lists []merged[model.List]
+	var domains []merged[model.Domain]
+	step.excluded = nil
+	for _, source := range append([]pihole.Client{primary}, step.sources...) {
+		g, err := readGravity(source)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source.String(), err)
+		}
+
+		var excludedLists, excludedDomains []exclude.Excluded
+		g.lists, excludedLists = excludeLists(step.rules, g.lists, g.groups)
+		g.domains, excludedDomains = excludeDomains(step.rules, g.domains, g.groups)
+		step.excluded = append(step.excluded, excludedLists...)
+		step.excluded = append(step.excluded, excludedDomains...)
+
+		name := sourceName(source)
+		for _, list := range g.lists {
+			lists = append(lists, merged[model.List]{entity: list, groups: groupNames(list.Groups, g.groups), sources: []string{name}})
+		}
+		for _, domain := range g.domains {
+			domains = append(domains, merged[model.Domain]{entity: domain, groups: groupNames(domain.Groups, g.groups), sources: []string{name}})
+		}
+	}
+
+	step.lists = mergeEntities(lists, step.settings.Conflict, listMergeOps)
+	step.domains = mergeEntities(domains, step.settings.Conflict, domainMergeOps)
+	log.Info().Int("adlists", len(step.lists)).Int("domains", len(step.domains)).Msg("Merged")
+
+	return replicas, nil
+}
+
+func (step *mergeStep) Execute(replica pihole.Client) error {
+	return step.retries.Do(retry.Merge, replica, func() error {
+		return step.reconcile(replica)
+	})
+}
+
+// reconcile reads the replica's gravity and makes its domains and adlists match the merged set.
+func (step *mergeStep) Describe(result *StepResult) {
+	result.Excluded = step.excluded
+}
+
+func (step *mergeStep) reconcile(replica pihole.Client) error {
+	g, err := readGravity(replica)
+	if err != nil {
+		return err
+	}
+	g.lists, _ = excludeLists(step.rules, g.lists, g.groups)
+	g.domains, _ = excludeDomains(step.rules, g.domains, g.groups)
+
+	if err := reconcileMerged(step.lists, g.lists, g.groups, listMergeOps, entityOps[model.List]{
+		key:        listMergeOps.key,
+		groups:     func(l model.List) []int { return l.Groups },
+		withGroups: func(l model.List, groups []int) model.List { l.Groups = groups; return l },
+		equal: func(a, b model.List) bool {
+			return a.Enabled == b.Enabled && equalComment(a.Comment, b.Comment)
+		},
+		post:   replica.PostList,
+		put:    replica.PutList,
+		delete: replica.DeleteList,
+	}); err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	if err := reconcileMerged(step.domains, g.domains, g.groups, domainMergeOps, entityOps[model.Domain]{
+		key:        domainMergeOps.key,
+		groups:     func(d model.Domain) []int { return d.Groups },
+		withGroups: func(d model.Domain, groups []int) model.Domain { d.Groups = groups; return d },
+		equal: func(a, b model.Domain) bool {
+			return a.Enabled == b.Enabled && equalComment(a.Comment, b.Comment)
+		},
+		post:   replica.PostDomain,
+		put:    replica.PutDomain,
+		delete: replica.DeleteDomain,
+	}); err != nil {
+		return fmt.Errorf("domains: %w", err)
+	}
+
+	return nil
+}
+
+type mergeOps[T any] struct {
+	// identity identifies an entity regardless of whether it allows or denies.
+	identity func(T) string
+	// key identifies an entity on an instance, including its type.
+	key     func(T) string
+	denies  func(T) bool
+	comment func(T) *string
+	// withComment returns the entity with the comment replaced.
+	withComment func(T, *string) T
+}
+
+var listMergeOps = mergeOps[model.List]{
+	identity:    func(l model.List) string { return l.Address },
+	key:         func(l model.List) string { return l.Type + ":" + l.Address },
+	denies:      func(l model.List) bool { return l.Type != "allow" },
+	comment:     func(l model.List) *string { return l.Comment },
+	withComment: func(l model.List, comment *string) model.List { l.Comment = comment; return l },
+}
+
+var domainMergeOps = mergeOps[model.Domain]{
+	identity:    func(d model.Domain) string { return d.Kind + ":" + d.Domain },
+	key:         func(d model.Domain) string { return d.Type + "/" + d.Kind + ":" + d.Domain },
+	denies:      func(d model.Domain) bool { return d.Type != "allow" },
+	comment:     func(d model.Domain) *string { return d.Comment },
+	withComment: func(d model.Domain, comment *string) model.Domain { d.Comment = comment; return d },
+}
+
+// mergeEntities unions the entities, given in source priority order. Entities with the same identity but a
+// different type conflict and are resolved by the conflict rule. Entities with the same key are combined:
+// the highest priority source determines the entity's properties, and its groups and sources are unioned.
+func mergeEntities[T any](entities []merged[T], conflict string, ops mergeOps[T]) []merged[T] {
+	winners := make(map[string]string)
+	for _, m := range entities {
+		identity := ops.identity(m.entity)
+		if _, exists := winners[identity]; !exists {
+			winners[identity] = ops.key(m.entity)
+			continue
+		}
+
+		switch conflict {
+		case config.MergeDenyWins:
+			if ops.denies(m.entity) {
+				winners[identity] = ops.key(m.entity)
+			}
+		case config.MergeAllowWins:
+			if !ops.denies(m.entity) {
+				winners[identity] = ops.key(m.entity)
+			}
+		}
+	}
+
+	var result []merged[T]
+	index := make(map[string]int)
+	for _, m := range entities {
+		key := ops.key(m.entity)
+		if winners[ops.identity(m.entity)] != key {
+			log.Debug().Str("entity", key).Strs("sources", m.sources).Msg("Dropped conflicting entity")
+			continue
+		}
+
+		if i, exists := index[key]; exists {
+			result[i].groups = appendMissing(result[i].groups, m.groups...)
+			result[i].sources = appendMissing(result[i].sources, m.sources...)
+			continue
+		}
+
+		index[key] = len(result)
+		result = append(result, merged[T]{entity: m.entity, groups: slices.Clone(m.groups), sources: slices.Clone(m.sources)})
+	}
+
+	for i := range result {
+		comment := provenanceComment(ops.comment(result[i].entity), result[i].sources)
+		result[i].entity = ops.withComment(result[i].entity, comment)
+	}
+
+	return result
+}
+
+// reconcileMerged makes the replica's entities match the merged set: it posts and puts the merged entities, and
+// then deletes the replica's entities that are not merged. Group names missing on the replica are skipped, and
+// entities left without a group are assigned to the default group.
+func reconcileMerged[T any](entities []merged[T], replica []T, replicaGroups []model.Group, mops mergeOps[T], ops entityOps[T]) error {
+	desired := make(map[string]T, len(entities))
+	for _, m := range entities {
+		var ids []int
+		for _, name := range m.groups {
+			group := findGroup(replicaGroups, name)
+			if group == nil {
+				log.Warn().Str("group", name).Str("entity", mops.key(m.entity)).Msg("Group not found on replica")
+				continue
+			}
+			ids = append(ids, group.Id)
+		}
+		if len(ids) == 0 {
+			ids = []int{0}
+		}
+		desired[mops.key(m.entity)] = ops.withGroups(m.entity, sortedGroups(ids))
+	}
+
+	existing := make(map[string]T, len(replica))
+	for _, entity := range replica {
+		existing[ops.key(entity)] = entity
+	}
+
+	for _, m := range entities {
+		key := mops.key(m.entity)
+		entity := desired[key]
+
+		current, exists := existing[key]
+		if !exists {
+			if err := ops.post(&entity); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !ops.equal(current, entity) || !slices.Equal(sortedGroups(ops.groups(current)), ops.groups(entity)) {
+			if err := ops.put(&entity); err != nil {
+				return err
+			}
+		}
+	}
+
+	// deletes run last, so a failure part way only leaves extra entities behind and never removes an entity
+	// before its replacement exists
+	for _, entity := range replica {
+		if _, keep := desired[ops.key(entity)]; keep {
+			continue
+		}
+		if err := ops.delete(&entity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// provenanceComment appends the sources of a merged entity to its comment, replacing earlier provenance.
+func provenanceComment(comment *string, sources []string) *string {
+	provenance := fmt.Sprintf("[nebula-sync: %s]", strings.Join(sources, ", "))
+	if comment == nil {
+		return &provenance
+	}
+
+	stripped := provenancePattern.ReplaceAllString(*comment, "")
+	if stripped == "" {
+		return &provenance
+	}
+
+	result := stripped + " " + provenance
+	return &result
+}
+
+func sourceName(source pihole.Client) string {
+	if u, err := url.Parse(source.String()); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return source.String()
+}
+
+func appendMissing(values []string, more ...string) []string {
+	for _, value := range more {
+		if !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,145 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func comment(s string) *string {
+	return &s
+}
+
+func Test_mergeEntities(t *testing.T) {
+	domains := []merged[model.Domain]{
+		{entity: model.Domain{Domain: "ads.example.com", Type: "allow", Kind: "exact"}, groups: []string{"Default"}, sources: []string{"parents"}},
+		{entity: model.Domain{Domain: "ads.example.com", Type: "deny", Kind: "exact"}, groups: []string{"Kids"}, sources: []string{"it"}},
+		{entity: model.Domain{Domain: "work.example.com", Type: "allow", Kind: "exact", Comment: comment("vpn")}, groups: []string{"Default"}, sources: []string{"parents"}},
+		{entity: model.Domain{Domain: "work.example.com", Type: "allow", Kind: "exact"}, groups: []string{"Kids"}, sources: []string{"it"}},
+	}
+
+	types := func(result []merged[model.Domain]) map[string]string {
+		m := make(map[string]string)
+		for _, r := range result {
+			m[r.entity.Domain] = r.entity.Type
+		}
+		return m
+	}
+
+	assert.Equal(t, map[string]string{"ads.example.com": "deny", "work.example.com": "allow"}, types(mergeEntities(domains, config.MergeDenyWins, domainMergeOps)))
+	assert.Equal(t, map[string]string{"ads.example.com": "allow", "work.example.com": "allow"}, types(mergeEntities(domains, config.MergeAllowWins, domainMergeOps)))
+	assert.Equal(t, map[string]string{"ads.example.com": "allow", "work.example.com": "allow"}, types(mergeEntities(domains, config.MergePriority, domainMergeOps)))
+
+	result := mergeEntities(domains, config.MergeDenyWins, domainMergeOps)
+	require.Len(t, result, 2)
+	assert.Equal(t, []string{"it"}, result[0].sources)
+	assert.Equal(t, "[nebula-sync: it]", *result[0].entity.Comment)
+	assert.Equal(t, []string{"Default", "Kids"}, result[1].groups)
+	assert.Equal(t, []string{"parents", "it"}, result[1].sources)
+	assert.Equal(t, "vpn [nebula-sync: parents, it]", *result[1].entity.Comment)
+}
+
+func Test_provenanceComment(t *testing.T) {
+	assert.Equal(t, "[nebula-sync: a]", *provenanceComment(nil, []string{"a"}))
+	assert.Equal(t, "[nebula-sync: a, b]", *provenanceComment(comment("[nebula-sync: old]"), []string{"a", "b"}))
+	assert.Equal(t, "note [nebula-sync: a]", *provenanceComment(comment("note [nebula-sync: old]"), []string{"a"}))
+}
+
+func Test_mergeStep(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	source := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+	primary.EXPECT().String().Return("http://parents.lan")
+	source.EXPECT().String().Return("http://it.lan:8080")
+
+	target := target{Primary: primary, Replicas: []pihole.Client{replica}, Sources: []pihole.Client{source}}
+
+	primary.EXPECT().GetGroups().Return([]model.Group{{Id: 0, Name: "Default"}, {Id: 1, Name: "Kids"}}, nil)
+	primary.EXPECT().GetLists().Return([]model.List{
+		{Address: "https://block.example.com", Type: "block", Groups: []int{1}, Enabled: true},
+	}, nil)
+	primary.EXPECT().GetDomains().Return([]model.Domain{
+		{Domain: "games.example.com", Type: "deny", Kind: "exact", Groups: []int{1}, Enabled: true},
+	}, nil)
+	primary.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	source.EXPECT().GetGroups().Return([]model.Group{{Id: 0, Name: "Default"}}, nil)
+	source.EXPECT().GetLists().Return([]model.List{}, nil)
+	source.EXPECT().GetDomains().Return([]model.Domain{
+		{Domain: "games.example.com", Type: "allow", Kind: "exact", Groups: []int{0}, Enabled: true},
+		{Domain: "intranet.example.com", Type: "allow", Kind: "exact", Groups: []int{0}, Enabled: true},
+	}, nil)
+	source.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	replica.EXPECT().GetGroups().Return([]model.Group{{Id: 0, Name: "Default"}, {Id: 3, Name: "Kids"}}, nil)
+	replica.EXPECT().GetLists().Return([]model.List{
+		{Address: "https://block.example.com", Type: "block", Groups: []int{3}, Enabled: true, Comment: comment("[nebula-sync: parents.lan]")},
+		{Address: "https://stale.example.com", Type: "block", Groups: []int{0}, Enabled: true},
+	}, nil)
+	replica.EXPECT().GetDomains().Return([]model.Domain{
+		{Domain: "games.example.com", Type: "allow", Kind: "exact", Groups: []int{0}, Enabled: true},
+	}, nil)
+	replica.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	replica.EXPECT().DeleteList(&model.List{Address: "https://stale.example.com", Type: "block", Groups: []int{0}, Enabled: true}).Once().Return(nil)
+	replica.EXPECT().DeleteDomain(&model.Domain{Domain: "games.example.com", Type: "allow", Kind: "exact", Groups: []int{0}, Enabled: true}).Once().Return(nil)
+	replica.EXPECT().PostDomain(&model.Domain{Domain: "games.example.com", Type: "deny", Kind: "exact", Groups: []int{3}, Enabled: true, Comment: comment("[nebula-sync: parents.lan]")}).Once().Return(nil)
+	replica.EXPECT().PostDomain(&model.Domain{Domain: "intranet.example.com", Type: "allow", Kind: "exact", Groups: []int{0}, Enabled: true, Comment: comment("[nebula-sync: it.lan:8080]")}).Once().Return(nil)
+
+	err := target.runPipeline(&pipelineRun{}, newMergeStep(&config.MergeSettings{Enabled: true, Conflict: config.MergeDenyWins}, target.Sources, nil))
+	require.NoError(t, err)
+}
+
+func Test_mergeStep_retryAndOrder(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+	primary.EXPECT().String().Return("http://parents.lan")
+	replica.EXPECT().String().Maybe().Return("http://ph2.lan")
+
+	target := target{Primary: primary, Replicas: []pihole.Client{replica}}
+
+	primary.EXPECT().GetGroups().Return([]model.Group{{Id: 0, Name: "Default"}}, nil)
+	primary.EXPECT().GetLists().Return([]model.List{}, nil)
+	primary.EXPECT().GetDomains().Return([]model.Domain{
+		{Domain: "games.example.com", Type: "deny", Kind: "exact", Groups: []int{0}, Enabled: true},
+	}, nil)
+	primary.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	// the first read of the replica fails transiently and is retried
+	replica.EXPECT().GetGroups().Once().Return(nil, errors.New("connection reset by peer"))
+	replica.EXPECT().GetGroups().Once().Return([]model.Group{{Id: 0, Name: "Default"}}, nil)
+	replica.EXPECT().GetLists().Return([]model.List{}, nil)
+	replica.EXPECT().GetDomains().Return([]model.Domain{
+		{Domain: "games.example.com", Type: "allow", Kind: "exact", Groups: []int{0}, Enabled: true},
+	}, nil)
+	replica.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	// the replacement is posted before the replaced domain is deleted
+	mock.InOrder(
+		replica.EXPECT().PostDomain(mock.MatchedBy(func(d *model.Domain) bool { return d.Type == "deny" })).Once().Return(nil),
+		replica.EXPECT().DeleteDomain(mock.MatchedBy(func(d *model.Domain) bool { return d.Type == "allow" })).Once().Return(nil),
+	)
+
+	err := target.runPipeline(&pipelineRun{}, newMergeStep(&config.MergeSettings{Enabled: true, Conflict: config.MergeDenyWins}, nil, nil))
+	require.NoError(t, err)
+}
+
+func Test_target_newSteps_merge(t *testing.T) {
+	target := target{}
+	conf := &config.Sync{Merge: &config.MergeSettings{Enabled: true, Conflict: config.MergeDenyWins}}
+
+	steps := target.newSteps(conf, newFullSyncGravitySettings(), newFullSyncConfigSettings(), nil)
+
+	teleporters := steps[StepTeleporters].(*teleporterStep)
+	assert.False(t, teleporters.gravitySettings.Adlist)
+	assert.False(t, teleporters.gravitySettings.Domainlist)
+	assert.True(t, teleporters.gravitySettings.Group)
+	assert.True(t, steps[StepMerge].Enabled())
+}
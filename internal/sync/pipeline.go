@@ -0,0 +1,379 @@
+package sync
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/sync/backup"
+	"github.com/lovelaze/nebula-sync/internal/sync/exclude"
+	"github.com/lovelaze/nebula-sync/internal/sync/retry"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	StepTeleporters = "teleporters"
+	StepGroups      = "groups"
+	StepMerge       = "merge"
+	StepConfigs     = "configs"
+	StepGravity     = "gravity"
+	StepRestartDNS  = "restartdns"
+	StepVerify      = "verify"
+)
+
+var (
+	fullSyncSteps      = []string{StepTeleporters, StepMerge, StepConfigs, StepGravity, StepVerify}
+	selectiveSyncSteps = []string{StepTeleporters, StepGroups, StepMerge, StepConfigs, StepGravity, StepVerify}
+)
+
+// Step is a single stage of a sync. A step is started once against the primary, for example to read
+// what should be synced, and then executed for each replica it applies to.
+type Step interface {
+	Name() string
+	// Enabled reports whether the step's preconditions are met. Disabled steps are skipped.
+	Enabled() bool
+	// Disruptive reports whether executing the step interrupts DNS on a replica, so that it is rolled out.
+	Disruptive() bool
+	// Prepare runs before any step of the pipeline has executed, to record the replicas' state.
+	Prepare(replicas []pihole.Client) error
+	// Start runs once, before the step first executes, and returns the replicas it applies to.
+	Start(primary pihole.Client, replicas []pihole.Client) ([]pihole.Client, error)
+	Execute(replica pihole.Client) error
+	// Finish runs after the step executed on the replicas of a phase: every replica, or the canary and then
+	// the remaining replicas.
+	Finish() error
+}
+
+// baseStep provides defaults for the optional parts of a Step, and the retry policies of its replica
+// operations.
+type baseStep struct {
+	retries *retry.Policies
+}
+
+func (step *baseStep) setRetries(retries *retry.Policies) { step.retries = retries }
+
+// retrying is implemented by steps that retry replica operations.
+type retrying interface {
+	setRetries(retries *retry.Policies)
+}
+
+func (baseStep) Enabled() bool                          { return true }
+func (baseStep) Disruptive() bool                       { return false }
+func (baseStep) Prepare(replicas []pihole.Client) error { return nil }
+func (baseStep) Finish() error                          { return nil }
+
+func (baseStep) Start(primary pihole.Client, replicas []pihole.Client) ([]pihole.Client, error) {
+	return replicas, nil
+}
+
+// describer is implemented by steps that add details of their execution to the step result.
+type describer interface {
+	Describe(result *StepResult)
+}
+
+type StepResult struct {
+	Step     string
+	Skipped  bool
+	Replicas []ReplicaResult
+	Duration time.Duration
+	Err      error
+	// Fingerprint is the sha256 of the primary's teleporter archive, if the step imported it.
+	Fingerprint string
+	// Excluded are the gravity entities of the primary, or the merge sources, that the exclusion rules kept out of the step.
+	Excluded []exclude.Excluded
+}
+
+// ReplicaResult is the outcome of executing a step on a replica.
+type ReplicaResult struct {
+	Replica pihole.Client
+	Err     error
+	// Changed is the number of config keys the step changed, if the step counts them.
+	Changed int
+}
+
+// pipelineRun holds the settings of a single sync that apply to every step of the pipeline.
+type pipelineRun struct {
+	rolling *config.RollingSettings
+	canary  *config.CanarySettings
+	// quarantined holds the replicas the circuit breaker left out of the run.
+	quarantined []pihole.Client
+	// configSettings are the config sections the run syncs, which a rollback restores.
+	configSettings *config.ConfigSettings
+	// written holds the replicas a step executed on, successfully or not, in the order they were first written.
+	written []pihole.Client
+	mu      gosync.Mutex
+}
+
+func (run *pipelineRun) write(replica pihole.Client) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if !slices.Contains(run.written, replica) {
+		run.written = append(run.written, replica)
+	}
+}
+
+// phases returns the replicas the pipeline executes on in turn: the canary and then the remaining replicas if a
+// canary is configured, or else every replica at once. If the circuit breaker quarantined the canary, the canary
+// phase is skipped so that the remaining replicas still sync.
+func (run *pipelineRun) phases(replicas []pihole.Client) ([][]pihole.Client, error) {
+	if run.canary == nil || !run.canary.Enabled {
+		return [][]pihole.Client{replicas}, nil
+	}
+
+	canary, others, err := splitCanary(replicas, run.canary.Replica)
+	if err != nil {
+		if quarantined, _, qErr := splitCanary(run.quarantined, run.canary.Replica); qErr == nil {
+			log.Warn().Str("replica", quarantined.String()).Msg("Canary replica is quarantined, skipping the canary phase")
+			return [][]pihole.Client{replicas}, nil
+		}
+		return nil, err
+	}
+	return [][]pihole.Client{{canary}, others}, nil
+}
+
+// selector is implemented by steps that decide which replicas they execute on only when they execute, since
+// that depends on the replicas' state after the previous steps.
+type selector interface {
+	Select(replicas []pihole.Client) ([]pihole.Client, error)
+}
+
+// pipelineStep is the state of a step during a run of the pipeline.
+type pipelineStep struct {
+	step    Step
+	result  StepResult
+	started bool
+	// replicas are the replicas the step applies to, as returned by Start.
+	replicas []pihole.Client
+}
+
+// newPipeline returns the steps for the sync mode, in the order and with the steps configured in settings.
+func newPipeline(defaults []string, settings *config.PipelineSettings, steps map[string]Step) ([]Step, error) {
+	names := defaults
+	var disabled []string
+	if settings != nil {
+		if len(settings.Steps) > 0 {
+			names = settings.Steps
+		}
+		disabled = settings.Disable
+	}
+
+	for _, name := range disabled {
+		if _, exists := steps[name]; !exists {
+			return nil, fmt.Errorf("unknown step %q, expected one of %s", name, strings.Join(stepNames(steps), ","))
+		}
+	}
+
+	pipeline := make([]Step, 0, len(names))
+	for _, name := range names {
+		step, exists := steps[name]
+		if !exists {
+			return nil, fmt.Errorf("unknown step %q, expected one of %s", name, strings.Join(stepNames(steps), ","))
+		}
+		if slices.Contains(disabled, name) {
+			continue
+		}
+		pipeline = append(pipeline, step)
+	}
+
+	return pipeline, nil
+}
+
+func stepNames(steps map[string]Step) []string {
+	names := make([]string, 0, len(steps))
+	for name := range steps {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// newSteps returns every available step, configured for a sync with the given settings. When merging, the
+// merge step owns the domains and adlists, so they are left out of the teleporter import and verification.
+func (target *target) newSteps(conf *config.Sync, gravitySettings *config.GravitySettings, configSettings *config.ConfigSettings, rules *exclude.Rules) map[string]Step {
+	var groups []string
+	if gravitySettings != nil {
+		groups = gravitySettings.Groups
+	}
+
+	if conf.Merge != nil && conf.Merge.Enabled && gravitySettings != nil {
+		withoutLists := *gravitySettings
+		withoutLists.Adlist = false
+		withoutLists.AdlistByGroup = false
+		withoutLists.Domainlist = false
+		withoutLists.DomainlistByGroup = false
+		gravitySettings = &withoutLists
+	}
+
+	verifyEnabled := conf.Verify != nil && conf.Verify.Enabled
+	journalEnabled := conf.Journal != nil && conf.Journal.Enabled
+
+	var backups *backup.Store
+	if conf.Backup != nil && conf.Backup.Enabled {
+		backups = backup.NewStore(conf.Backup.Dir, backup.Retention{
+			Last:    conf.Backup.KeepLast,
+			Daily:   conf.Backup.KeepDaily,
+			Weekly:  conf.Backup.KeepWeekly,
+			Monthly: conf.Backup.KeepMonthly,
+		})
+	}
+
+	steps := map[string]Step{
+		StepTeleporters: newTeleporterStep(gravitySettings, rules, backups),
+		StepGroups:      newGroupsStep(groups, rules),
+		StepMerge:       newMergeStep(conf.Merge, target.Sources, rules),
+		StepConfigs:     newConfigStep(configSettings, conf.Rewrites, journalEnabled),
+		StepGravity:     newGravityStep(conf.RunGravity, conf.GravityRun),
+		StepRestartDNS:  newRestartDNSStep(),
+		StepVerify:      newVerifyStep(verifyEnabled, gravitySettings, configSettings, conf.Rewrites, rules, verifyEnabled && conf.Verify.Strict),
+	}
+	for _, step := range steps {
+		if r, ok := step.(retrying); ok {
+			r.setRetries(target.retries)
+		}
+	}
+	return steps
+}
+
+// runPipeline runs the steps in order and appends their results to target.results. Each step is started once.
+// With a canary, every step executes on the canary first, and on the remaining replicas only after the canary
+// passed its probes, so they receive the same payload. If a step fails, the pipeline stops; the replicas the
+// steps wrote to are restored from their snapshots if rollback is enabled, see pipelineRun.written.
+func (target *target) runPipeline(run *pipelineRun, steps ...Step) error {
+	for _, step := range steps {
+		if !step.Enabled() {
+			continue
+		}
+		if err := step.Prepare(target.Replicas); err != nil {
+			return fmt.Errorf("prepare %s: %w", step.Name(), err)
+		}
+	}
+
+	phases, err := run.phases(target.Replicas)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	pipeline := make([]*pipelineStep, len(steps))
+	defer func() {
+		for _, ps := range pipeline {
+			if ps == nil {
+				continue
+			}
+			if d, ok := ps.step.(describer); ok && !ps.result.Skipped {
+				d.Describe(&ps.result)
+			}
+			target.results = append(target.results, ps.result)
+		}
+	}()
+
+	for i, replicas := range phases {
+		if len(phases) > 1 {
+			if i == 0 {
+				log.Info().Str("replica", replicas[0].String()).Msg("Syncing canary...")
+			} else {
+				if err := probeCanary(phases[0][0], run.canary); err != nil {
+					return err
+				}
+				if len(replicas) == 0 {
+					break
+				}
+				log.Info().Int("replicas", len(replicas)).Msg("Canary passed, syncing remaining replicas...")
+			}
+		}
+
+		for j, step := range steps {
+			if !step.Enabled() {
+				if i == 0 {
+					log.Debug().Str("step", step.Name()).Msg("Skipping step")
+					pipeline[j] = &pipelineStep{step: step, result: StepResult{Step: step.Name(), Skipped: true}}
+				}
+				continue
+			}
+
+			if pipeline[j] == nil {
+				pipeline[j] = &pipelineStep{step: step, result: StepResult{Step: step.Name()}}
+			}
+			ps := pipeline[j]
+
+			stepStart := time.Now()
+			executed, err := target.runStep(run, ps, replicas)
+			duration := time.Since(stepStart)
+			ps.result.Duration += duration
+			ps.result.Err = err
+
+			if err != nil {
+				log.Error().Err(err).Stringer("class", retry.Classify(err)).Str("step", step.Name()).Dur("duration", duration).Msg("Step failed")
+				err = fmt.Errorf("%s: %w", step.Name(), err)
+				if len(phases) > 1 && i == 0 {
+					return fmt.Errorf("canary %s: %w", replicas[0].String(), err)
+				}
+				return err
+			}
+
+			log.Info().Str("step", step.Name()).Int("replicas", len(executed)).Dur("duration", duration).Msg("Step completed")
+		}
+	}
+
+	log.Info().Int("steps", len(steps)).Dur("duration", time.Since(start)).Msg("Pipeline completed")
+	return nil
+}
+
+// runStep starts the step if it has not started yet, executes it on those of the given replicas it applies to,
+// records the outcome on each replica in the step's result and returns the replicas it executed on successfully.
+func (target *target) runStep(run *pipelineRun, ps *pipelineStep, replicas []pihole.Client) ([]pihole.Client, error) {
+	step := ps.step
+	if !ps.started {
+		ps.started = true
+		applies, err := step.Start(target.Primary, target.Replicas)
+		if err != nil {
+			return nil, err
+		}
+		ps.replicas = applies
+	}
+
+	replicas = slices.DeleteFunc(slices.Clone(replicas), func(replica pihole.Client) bool {
+		return !slices.Contains(ps.replicas, replica)
+	})
+	if s, ok := step.(selector); ok {
+		var err error
+		if replicas, err = s.Select(replicas); err != nil {
+			return nil, err
+		}
+	}
+
+	var mu gosync.Mutex
+	var done []pihole.Client
+	execute := func(replica pihole.Client) error {
+		run.write(replica)
+		err := step.Execute(replica)
+		mu.Lock()
+		defer mu.Unlock()
+		ps.result.Replicas = append(ps.result.Replicas, ReplicaResult{Replica: replica, Err: err})
+		if err != nil {
+			return err
+		}
+		done = append(done, replica)
+		return nil
+	}
+
+	var err error
+	if step.Disruptive() {
+		err = target.rollout(run.rolling, step.Name(), replicas, execute)
+	} else {
+		for _, replica := range replicas {
+			if err = execute(replica); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return done, err
+	}
+
+	return done, step.Finish()
+}
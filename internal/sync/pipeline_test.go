@@ -0,0 +1,117 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStep struct {
+	baseStep
+	name     string
+	disabled bool
+	started  int
+	err      error
+	executed []pihole.Client
+}
+
+func (step *fakeStep) Name() string  { return step.name }
+func (step *fakeStep) Enabled() bool { return !step.disabled }
+
+func (step *fakeStep) Start(primary pihole.Client, replicas []pihole.Client) ([]pihole.Client, error) {
+	step.started++
+	return replicas, nil
+}
+
+func (step *fakeStep) Execute(replica pihole.Client) error {
+	if step.err != nil {
+		return step.err
+	}
+	step.executed = append(step.executed, replica)
+	return nil
+}
+
+func stepMap(steps ...*fakeStep) map[string]Step {
+	m := make(map[string]Step, len(steps))
+	for _, step := range steps {
+		m[step.name] = step
+	}
+	return m
+}
+
+func pipelineNames(pipeline []Step) []string {
+	names := make([]string, 0, len(pipeline))
+	for _, step := range pipeline {
+		names = append(names, step.Name())
+	}
+	return names
+}
+
+func Test_newPipeline(t *testing.T) {
+	steps := stepMap(&fakeStep{name: "a"}, &fakeStep{name: "b"}, &fakeStep{name: "c"})
+
+	pipeline, err := newPipeline([]string{"a", "b"}, nil, steps)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, pipelineNames(pipeline))
+
+	pipeline, err = newPipeline([]string{"a", "b"}, &config.PipelineSettings{Steps: []string{"c", "b", "a"}, Disable: []string{"b"}}, steps)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "a"}, pipelineNames(pipeline))
+
+	_, err = newPipeline([]string{"a"}, &config.PipelineSettings{Steps: []string{"d"}}, steps)
+	assert.ErrorContains(t, err, `unknown step "d", expected one of a,b,c`)
+
+	_, err = newPipeline([]string{"a"}, &config.PipelineSettings{Disable: []string{"d"}}, steps)
+	assert.ErrorContains(t, err, `unknown step "d"`)
+}
+
+func Test_target_runPipeline(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+	target := target{Primary: primary, Replicas: []pihole.Client{replica}}
+
+	first := &fakeStep{name: "first"}
+	skipped := &fakeStep{name: "skipped", disabled: true}
+	second := &fakeStep{name: "second"}
+
+	err := target.runPipeline(&pipelineRun{}, first, skipped, second)
+	require.NoError(t, err)
+
+	assert.Equal(t, []pihole.Client{replica}, first.executed)
+	assert.Empty(t, skipped.executed)
+	assert.Equal(t, []pihole.Client{replica}, second.executed)
+
+	require.Len(t, target.results, 3)
+	assert.Equal(t, "first", target.results[0].Step)
+	assert.Equal(t, []ReplicaResult{{Replica: replica}}, target.results[0].Replicas)
+	assert.True(t, target.results[1].Skipped)
+	assert.Equal(t, "second", target.results[2].Step)
+}
+
+func Test_target_runPipeline_failure(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+	replica.EXPECT().String().Maybe().Return("http://replica.lan")
+	target := target{Primary: primary, Replicas: []pihole.Client{replica}}
+
+	first := &fakeStep{name: "first"}
+	failing := &fakeStep{name: "failing", err: errors.New("boom")}
+	last := &fakeStep{name: "last"}
+
+	run := &pipelineRun{}
+	err := target.runPipeline(run, first, failing, last)
+	assert.EqualError(t, err, "failing: boom")
+
+	assert.Equal(t, []pihole.Client{replica}, first.executed)
+	assert.Empty(t, last.executed)
+	assert.Equal(t, []pihole.Client{replica}, run.written)
+
+	require.Len(t, target.results, 2)
+	assert.EqualError(t, target.results[1].Err, "boom")
+	assert.EqualError(t, target.results[1].Replicas[0].Err, "boom")
+}
@@ -0,0 +1,149 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Kind string
+
+const (
+	// Allow expects the domain to resolve to an address that is not a block response.
+	Allow Kind = "allow"
+	// Block expects the domain to be answered with a block response.
+	Block Kind = "block"
+	// Local expects the domain to resolve to the configured addresses.
+	Local Kind = "local"
+)
+
+// blockedAddresses are the answers Pi-hole gives for blocked domains in its default NULL blocking mode.
+var blockedAddresses = []string{"0.0.0.0", "::"}
+
+type Probe struct {
+	Kind     Kind
+	Domain   string
+	Expected []string
+}
+
+func (p Probe) String() string {
+	return fmt.Sprintf("%s %s", p.Kind, p.Domain)
+}
+
+type Result struct {
+	Probe   Probe
+	Answers []string
+	Passed  bool
+	Reason  string
+}
+
+func (r Result) String() string {
+	if r.Passed {
+		return fmt.Sprintf("%s: passed", r.Probe)
+	}
+	return fmt.Sprintf("%s: %s", r.Probe, r.Reason)
+}
+
+// Prober resolves domains against a single DNS server, bypassing the system resolver.
+type Prober struct {
+	resolver *net.Resolver
+	timeout  time.Duration
+}
+
+func NewProber(server string, timeout time.Duration) *Prober {
+	dialer := net.Dialer{Timeout: timeout}
+	return &Prober{
+		resolver: &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, server)
+			},
+		},
+		timeout: timeout,
+	}
+}
+
+func (p *Prober) Run(probes []Probe) []Result {
+	results := make([]Result, 0, len(probes))
+	for _, probe := range probes {
+		results = append(results, p.run(probe))
+	}
+	return results
+}
+
+func (p *Prober) run(probe Probe) Result {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	answers, err := p.resolver.LookupHost(ctx, probe.Domain)
+	slices.Sort(answers)
+	result := Result{Probe: probe, Answers: answers}
+
+	var dnsErr *net.DNSError
+	notFound := errors.As(err, &dnsErr) && dnsErr.IsNotFound
+	if err != nil && !notFound {
+		result.Reason = fmt.Sprintf("lookup failed: %v", err)
+		return result
+	}
+
+	switch probe.Kind {
+	case Allow:
+		if notFound {
+			result.Reason = "not found"
+		} else if blocked(answers) {
+			result.Reason = "blocked: " + strings.Join(answers, ",")
+		}
+	case Block:
+		if !notFound && !blocked(answers) {
+			result.Reason = "not blocked: " + strings.Join(answers, ",")
+		}
+	case Local:
+		if notFound {
+			result.Reason = "not found"
+		} else if missing := difference(probe.Expected, answers); len(missing) > 0 {
+			result.Reason = fmt.Sprintf("expected %s, got %s", strings.Join(probe.Expected, ","), strings.Join(answers, ","))
+		}
+	default:
+		result.Reason = fmt.Sprintf("unknown probe kind %q", probe.Kind)
+	}
+
+	result.Passed = result.Reason == ""
+	return result
+}
+
+func blocked(answers []string) bool {
+	if len(answers) == 0 {
+		return true
+	}
+	for _, answer := range answers {
+		if !slices.Contains(blockedAddresses, answer) {
+			return false
+		}
+	}
+	return true
+}
+
+func difference(expected, actual []string) []string {
+	var missing []string
+	for _, address := range expected {
+		if !slices.Contains(actual, address) {
+			missing = append(missing, address)
+		}
+	}
+	return missing
+}
+
+// Failed returns the results of the probes that did not pass.
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, result := range results {
+		if !result.Passed {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,101 @@
+package probe
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startResolver serves A records from records on a local udp port and answers NXDOMAIN for unknown names.
+func startResolver(t *testing.T, records map[string]string) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) == 0 {
+				continue
+			}
+			question := query.Questions[0]
+
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true},
+				Questions: query.Questions,
+			}
+
+			address, exists := records[question.Name.String()]
+			if !exists {
+				response.RCode = dnsmessage.RCodeNameError
+			} else if question.Type == dnsmessage.TypeA {
+				ip := net.ParseIP(address).To4()
+				response.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.AResource{A: [4]byte(ip)},
+				}}
+			}
+
+			packed, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(packed, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestProber_Run(t *testing.T) {
+	server := startResolver(t, map[string]string{
+		"example.com.": "93.184.215.14",
+		"ads.test.":    "0.0.0.0",
+		"nas.lan.":     "192.168.1.10",
+	})
+
+	results := NewProber(server, time.Second).Run([]Probe{
+		{Kind: Allow, Domain: "example.com"},
+		{Kind: Allow, Domain: "ads.test"},
+		{Kind: Allow, Domain: "missing.test"},
+		{Kind: Block, Domain: "ads.test"},
+		{Kind: Block, Domain: "missing.test"},
+		{Kind: Block, Domain: "example.com"},
+		{Kind: Local, Domain: "nas.lan", Expected: []string{"192.168.1.10"}},
+		{Kind: Local, Domain: "nas.lan", Expected: []string{"192.168.1.11"}},
+	})
+	require.Len(t, results, 8)
+
+	passed := make([]bool, 0, len(results))
+	for _, result := range results {
+		passed = append(passed, result.Passed)
+	}
+	assert.Equal(t, []bool{true, false, false, true, true, false, true, false}, passed)
+
+	assert.Equal(t, "blocked: 0.0.0.0", results[1].Reason)
+	assert.Equal(t, "not blocked: 93.184.215.14", results[5].Reason)
+	assert.Equal(t, "expected 192.168.1.11, got 192.168.1.10", results[7].Reason)
+	assert.Len(t, Failed(results), 4)
+}
+
+func TestProber_Run_unreachable(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := conn.LocalAddr().String()
+	conn.Close()
+
+	results := NewProber(server, 200*time.Millisecond).Run([]Probe{{Kind: Block, Domain: "ads.test"}})
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Passed)
+	assert.Contains(t, results[0].Reason, "lookup failed")
+}
@@ -0,0 +1,46 @@
+package sync
+
+import (
+	"strings"
+
+	"github.com/lovelaze/nebula-sync/internal/sync/filter"
+)
+
+// secretKeys are config keys that are never synced.
+var secretKeys = []string{
+	"webserver.api.password",
+	"webserver.api.pwhash",
+	"webserver.api.app_pwhash",
+	"webserver.api.totp_secret",
+}
+
+// hostKeys are config keys specific to an instance, such as its paths and listening ports. They are only synced
+// if a config filter includes them.
+var hostKeys = []string{
+	"files",
+	"webserver.domain",
+	"webserver.port",
+	"webserver.paths",
+	"webserver.tls.cert",
+}
+
+// protectConfig returns a copy of the json of a config section without the secret keys, and without the host
+// keys the rules do not include.
+func protectConfig(section string, rules filter.Rules, json map[string]interface{}) map[string]interface{} {
+	return filter.Keep(json, func(segments []string) bool {
+		path := section + "." + strings.Join(segments, ".")
+		if protected(secretKeys, path) {
+			return false
+		}
+		return !protected(hostKeys, path) || rules.Includes(segments)
+	})
+}
+
+func protected(keys []string, path string) bool {
+	for _, key := range keys {
+		if path == key || strings.HasPrefix(path, key+".") {
+			return true
+		}
+	}
+	return false
+}
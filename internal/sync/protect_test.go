@@ -0,0 +1,52 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/sync/filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func webserverJson() map[string]interface{} {
+	return map[string]interface{}{
+		"port":   "80o,443os",
+		"domain": "pi.hole",
+		"api": map[string]interface{}{
+			"pwhash":      "$BALLOON-SHA256$v=1$s=1024,t=32$secret",
+			"totp_secret": "secret",
+			"max_history": 86400.0,
+		},
+		"interface": map[string]interface{}{"theme": "default-dark"},
+	}
+}
+
+func Test_protectConfig(t *testing.T) {
+	result := protectConfig("webserver", nil, webserverJson())
+
+	assert.Equal(t, map[string]interface{}{
+		"api":       map[string]interface{}{"max_history": 86400.0},
+		"interface": map[string]interface{}{"theme": "default-dark"},
+	}, result)
+}
+
+func Test_protectConfig_included(t *testing.T) {
+	rules, err := filter.NewRules([]string{"port", "api.**"}, nil)
+	require.NoError(t, err)
+
+	result := protectConfig("webserver", rules, rules.Apply(webserverJson()))
+
+	assert.Equal(t, map[string]interface{}{
+		"port": "80o,443os",
+		"api":  map[string]interface{}{"max_history": 86400.0},
+	}, result, "secrets are not synced even if included")
+}
+
+func Test_protectConfig_files(t *testing.T) {
+	files := map[string]interface{}{"database": "/etc/pihole/pihole-FTL.db"}
+
+	assert.Empty(t, protectConfig("files", nil, files))
+	assert.Empty(t, filterPatchConfigRequest("files", config.NewConfigSetting(true, nil, []string{"log"}), files))
+	assert.Equal(t, files, filterPatchConfigRequest("files", config.NewConfigSetting(true, []string{"database"}, nil), files))
+}
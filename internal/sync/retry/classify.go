@@ -0,0 +1,85 @@
+package retry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// Class tells whether an operation that failed with an error may succeed when retried.
+type Class int
+
+const (
+	// Transient errors, like timeouts, refused connections, 5xx and 429 responses, are retried.
+	Transient Class = iota
+	// Permanent errors, like rejected config values, TLS and url errors, are not retried.
+	Permanent
+	// Unauthorized errors, like a wrong password, 401 and 403 responses or an invalid session, are not retried.
+	Unauthorized
+)
+
+func (class Class) String() string {
+	var s string
+	switch class {
+	case Transient:
+		s = "transient"
+	case Permanent:
+		s = "permanent"
+	case Unauthorized:
+		s = "auth"
+	}
+	return s
+}
+
+// statusError is implemented by errors of responses with an unsuccessful http status, see pihole.StatusError.
+type statusError interface {
+	error
+	StatusCode() int
+}
+
+// authError is implemented by errors of requests without a valid session, see pihole.AuthError.
+type authError interface {
+	error
+	Unauthenticated() bool
+}
+
+// Classify returns the class of err. Errors that are not known to be auth or permanent errors are transient.
+func Classify(err error) Class {
+	var auth authError
+	if errors.As(err, &auth) && auth.Unauthenticated() {
+		return Unauthorized
+	}
+
+	var status statusError
+	if errors.As(err, &status) {
+		code := status.StatusCode()
+		if code == http.StatusUnauthorized || code == http.StatusForbidden {
+			return Unauthorized
+		}
+		if code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout {
+			return Transient
+		}
+		return Permanent
+	}
+
+	var verification *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var record tls.RecordHeaderError
+	if errors.As(err, &verification) || errors.As(err, &unknownAuthority) || errors.As(err, &hostname) ||
+		errors.As(err, &invalid) || errors.As(err, &record) {
+		return Permanent
+	}
+
+	var urlErr *url.Error
+	var invalidHost url.InvalidHostError
+	var escape url.EscapeError
+	if errors.As(err, &urlErr) && urlErr.Op == "parse" || errors.As(err, &invalidHost) || errors.As(err, &escape) {
+		return Permanent
+	}
+
+	return Transient
+}
@@ -0,0 +1,82 @@
+package retry
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testStatusError int
+
+func (err testStatusError) Error() string   { return fmt.Sprintf("unexpected status code: %d", int(err)) }
+func (err testStatusError) StatusCode() int { return int(err) }
+
+type testAuthError string
+
+func (err testAuthError) Error() string         { return string(err) }
+func (err testAuthError) Unauthenticated() bool { return true }
+
+func TestClassify(t *testing.T) {
+	refused := &url.Error{Op: "Post", URL: "http://ph2.lan/api/auth", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}
+	_, parseErr := url.Parse("http://ph2.lan:port")
+
+	tests := []struct {
+		name  string
+		err   error
+		class Class
+	}{
+		{"503", testStatusError(503), Transient},
+		{"429", testStatusError(429), Transient},
+		{"408", testStatusError(408), Transient},
+		{"401", testStatusError(401), Unauthorized},
+		{"403", testStatusError(403), Unauthorized},
+		{"404", testStatusError(404), Permanent},
+		{"400 wrapped", fmt.Errorf("http://ph2.lan/api/config: %w", testStatusError(400)), Permanent},
+		{"connection refused", refused, Transient},
+		{"timeout", &url.Error{Op: "Get", URL: "http://ph2.lan", Err: &net.DNSError{IsTimeout: true}}, Transient},
+		{"unknown authority", &url.Error{Op: "Get", URL: "https://ph2.lan", Err: x509.UnknownAuthorityError{}}, Permanent},
+		{"hostname", fmt.Errorf("tls: %w", x509.HostnameError{Host: "ph2.lan"}), Permanent},
+		{"url parse", parseErr, Permanent},
+		{"invalid sid", fmt.Errorf("http://ph2.lan: %w", testAuthError("invalid sid found")), Unauthorized},
+		{"unknown", errors.New("unexpected EOF"), Transient},
+		{"joined", errors.Join(errors.New("restore config"), testStatusError(400)), Permanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.class, Classify(tt.err))
+		})
+	}
+}
+
+func TestWithRetry_PermanentNotRetried(t *testing.T) {
+	policy := Policy{Attempts: 5}
+
+	counter := 0
+	err := policy.Do(func() error {
+		counter++
+		return fmt.Errorf("auth: %w", testStatusError(401))
+	})
+
+	assert.ErrorContains(t, err, "unexpected status code: 401")
+	assert.Equal(t, 1, counter, "Expected permanent errors not to be retried")
+}
+
+func TestWithRetry_UnauthorizedNotRetried(t *testing.T) {
+	policy := Policy{Attempts: 5}
+
+	counter := 0
+	err := policy.Do(func() error {
+		counter++
+		return fmt.Errorf("http://ph2.lan: %w", testAuthError("invalid sid found"))
+	})
+
+	assert.ErrorContains(t, err, "invalid sid found")
+	assert.Equal(t, 1, counter, "Expected auth errors not to be retried")
+}
@@ -0,0 +1,177 @@
+package retry
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operation is a replica operation that is retried when it fails.
+type Operation string
+
+const (
+	Auth          Operation = "auth"
+	DeleteSession Operation = "session"
+	Teleporter    Operation = "teleporter"
+	PatchConfig   Operation = "patch"
+	RunGravity    Operation = "gravity"
+	RestartDNS    Operation = "restartdns"
+	SyncGroups    Operation = "groups"
+	Merge         Operation = "merge"
+
+	// AllOperations selects every operation in an override.
+	AllOperations Operation = "*"
+)
+
+// defaultAttempts are the attempts of each operation unless overridden.
+var defaultAttempts = map[Operation]uint{
+	Auth:          3,
+	DeleteSession: 3,
+	Teleporter:    5,
+	PatchConfig:   5,
+	RunGravity:    5,
+	RestartDNS:    3,
+	SyncGroups:    3,
+	Merge:         3,
+}
+
+// Policies hold the retry policy of each operation. Policies are safe to share, and a nil *Policies retries
+// every operation its default number of attempts without delay.
+type Policies struct {
+	base      Policy
+	overrides []*Override
+}
+
+// NewPolicies returns policies that use base, with the default attempts of each operation, changed by the
+// overrides in order.
+func NewPolicies(base Policy, overrides []*Override) *Policies {
+	return &Policies{base: base, overrides: overrides}
+}
+
+// For returns the policy of the operation on the replica, whose url is only read if an override is limited
+// to some replicas.
+func (policies *Policies) For(operation Operation, replica fmt.Stringer) Policy {
+	if policies == nil {
+		return Policy{Attempts: defaultAttempts[operation]}
+	}
+
+	policy := policies.base
+	policy.Attempts = defaultAttempts[operation]
+
+	var url string
+	for _, override := range policies.overrides {
+		if override.Replica != "" && url == "" {
+			url = replica.String()
+		}
+		if override.AppliesTo(operation, url) {
+			override.apply(&policy)
+		}
+	}
+	return policy
+}
+
+// Do runs retryFunc with the policy of the operation on the replica.
+func (policies *Policies) Do(operation Operation, replica fmt.Stringer, retryFunc func() error) error {
+	return policies.For(operation, replica).Do(retryFunc)
+}
+
+// Override changes the retry policy of an operation on the replicas whose url contains Replica. An override
+// without Replica applies to every replica.
+type Override struct {
+	Replica   string
+	Operation Operation
+	settings  []func(policy *Policy)
+}
+
+// ParseOverride parses an override of the form [replica@]operation=key:value[,key:value...], where operation
+// is one of auth, session, teleporter, patch, gravity, restartdns, groups or * for all of them. The keys are
+// attempts, delay, max_delay, deadline, backoff and jitter, durations are given like 2s or 1m30s.
+// For example "teleporter=attempts:10,backoff:exponential" or "ph2@*=deadline:2m".
+func ParseOverride(rule string) (*Override, error) {
+	target, settings, found := strings.Cut(strings.TrimSpace(rule), "=")
+	if !found || settings == "" {
+		return nil, fmt.Errorf("retry policy %q: expected [replica@]operation=key:value", rule)
+	}
+
+	override := &Override{Operation: Operation(target)}
+	if replica, operation, found := strings.Cut(target, "@"); found {
+		override.Replica, override.Operation = replica, Operation(operation)
+	}
+	if _, known := defaultAttempts[override.Operation]; !known && override.Operation != AllOperations {
+		return nil, fmt.Errorf("retry policy %q: unknown operation %q, expected one of %s", rule, override.Operation, strings.Join(operationNames(), ","))
+	}
+
+	for _, setting := range strings.Split(settings, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(setting), ":")
+		if !found {
+			return nil, fmt.Errorf("retry policy %q: expected key:value, got %q", rule, setting)
+		}
+		apply, err := parseSetting(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("retry policy %q: %s: %w", rule, key, err)
+		}
+		override.settings = append(override.settings, apply)
+	}
+
+	return override, nil
+}
+
+func parseSetting(key, value string) (func(policy *Policy), error) {
+	switch key {
+	case "attempts":
+		attempts, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || attempts == 0 {
+			return nil, fmt.Errorf("expected a positive number, got %q", value)
+		}
+		return func(policy *Policy) { policy.Attempts = uint(attempts) }, nil
+	case "delay", "max_delay", "deadline":
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			return nil, fmt.Errorf("expected a duration like 2s, got %q", value)
+		}
+		switch key {
+		case "delay":
+			return func(policy *Policy) { policy.Delay = duration }, nil
+		case "max_delay":
+			return func(policy *Policy) { policy.MaxDelay = duration }, nil
+		default:
+			return func(policy *Policy) { policy.Deadline = duration }, nil
+		}
+	case "backoff":
+		backoff, err := ParseBackoff(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(policy *Policy) { policy.Backoff = backoff }, nil
+	case "jitter":
+		jitter, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(policy *Policy) { policy.Jitter = jitter }, nil
+	}
+	return nil, fmt.Errorf("unknown setting, expected attempts, delay, max_delay, deadline, backoff or jitter")
+}
+
+// AppliesTo reports whether the override applies to the operation on the replica with the given url.
+func (override *Override) AppliesTo(operation Operation, replica string) bool {
+	return (override.Operation == AllOperations || override.Operation == operation) &&
+		(override.Replica == "" || strings.Contains(replica, override.Replica))
+}
+
+func (override *Override) apply(policy *Policy) {
+	for _, setting := range override.settings {
+		setting(policy)
+	}
+}
+
+func operationNames() []string {
+	names := make([]string, 0, len(defaultAttempts))
+	for operation := range defaultAttempts {
+		names = append(names, string(operation))
+	}
+	slices.Sort(names)
+	return names
+}
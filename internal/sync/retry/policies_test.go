@@ -0,0 +1,68 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type replicaURL string
+
+func (u replicaURL) String() string { return string(u) }
+
+func TestParseOverride(t *testing.T) {
+	override, err := ParseOverride("ph2@teleporter=attempts:10,delay:2s,max_delay:1m,deadline:5m,backoff:exponential,jitter:true")
+	require.NoError(t, err)
+	assert.Equal(t, "ph2", override.Replica)
+	assert.Equal(t, Teleporter, override.Operation)
+
+	policy := Policy{}
+	override.apply(&policy)
+	assert.Equal(t, Policy{
+		Attempts: 10,
+		Delay:    2 * time.Second,
+		MaxDelay: time.Minute,
+		Deadline: 5 * time.Minute,
+		Backoff:  Exponential,
+		Jitter:   true,
+	}, policy)
+}
+
+func TestParseOverride_Invalid(t *testing.T) {
+	for _, rule := range []string{
+		"teleporter",
+		"teleporter=",
+		"unknown=attempts:3",
+		"auth=attempts",
+		"auth=attempts:0",
+		"auth=delay:soon",
+		"auth=backoff:linear",
+		"auth=jitter:maybe",
+		"auth=retries:3",
+	} {
+		_, err := ParseOverride(rule)
+		assert.Error(t, err, rule)
+	}
+}
+
+func TestPolicies_For(t *testing.T) {
+	all, err := ParseOverride("*=backoff:exponential")
+	require.NoError(t, err)
+	gravity, err := ParseOverride("gravity=attempts:8")
+	require.NoError(t, err)
+	ph2, err := ParseOverride("ph2@gravity=attempts:12,deadline:10m")
+	require.NoError(t, err)
+
+	policies := NewPolicies(Policy{Delay: time.Second, MaxDelay: time.Minute}, []*Override{all, gravity, ph2})
+
+	assert.Equal(t, Policy{Attempts: 3, Delay: time.Second, MaxDelay: time.Minute, Backoff: Exponential}, policies.For(Auth, replicaURL("http://ph1.lan")))
+	assert.Equal(t, Policy{Attempts: 8, Delay: time.Second, MaxDelay: time.Minute, Backoff: Exponential}, policies.For(RunGravity, replicaURL("http://ph1.lan")))
+	assert.Equal(t, Policy{Attempts: 12, Delay: time.Second, MaxDelay: time.Minute, Backoff: Exponential, Deadline: 10 * time.Minute}, policies.For(RunGravity, replicaURL("http://ph2.lan")))
+}
+
+func TestPolicies_For_nil(t *testing.T) {
+	var policies *Policies
+	assert.Equal(t, Policy{Attempts: 5}, policies.For(Teleporter, replicaURL("http://ph1.lan")))
+}
@@ -1,41 +1,114 @@
 package retry
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/lovelaze/nebula-sync/internal/config"
+	"math"
+	"math/rand"
 	"time"
 
 	"github.com/avast/retry-go"
 	"github.com/rs/zerolog/log"
 )
 
+type Backoff int
+
 const (
-	AttemptsPostTeleporter = 5
-	AttemptsPatchConfig    = 5
-	AttemptsPostRunGravity = 5
-	AttemptsPostAuth       = 3
-	AttemptsDeleteSession  = 3
+	Fixed Backoff = iota
+	Exponential
 )
 
-var (
-	delay time.Duration
-)
+func (backoff Backoff) String() string {
+	var s string
+	switch backoff {
+	case Fixed:
+		s = "fixed"
+	case Exponential:
+		s = "exponential"
+	}
+	return s
+}
 
-func Init(clientConfig *config.Client) {
-	delay = time.Duration(clientConfig.RetryDelay) * time.Second
+func ParseBackoff(s string) (Backoff, error) {
+	switch s {
+	case Fixed.String():
+		return Fixed, nil
+	case Exponential.String():
+		return Exponential, nil
+	}
+	return Fixed, fmt.Errorf("unknown backoff %q, expected fixed or exponential", s)
 }
 
-func Fixed(retryFunc func() error, attempts uint) error {
-	return retry.Do(
+// Policy controls how often and when a failed operation is retried. With exponential backoff the delay doubles
+// after every attempt, up to MaxDelay. With Jitter each delay is picked at random between zero and the delay.
+// A Deadline, if set, bounds the total time spent retrying.
+type Policy struct {
+	Attempts uint
+	Delay    time.Duration
+	MaxDelay time.Duration
+	Backoff  Backoff
+	Jitter   bool
+	Deadline time.Duration
+}
+
+// Do runs retryFunc until it succeeds, fails with a permanent error or the policy is exhausted, and returns its
+// last error.
+func (policy Policy) Do(retryFunc func() error) error {
+	ctx := context.Background()
+	if policy.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Deadline)
+		defer cancel()
+	}
+
+	var lastErr error
+	err := retry.Do(
 		func() error {
 			return retryFunc()
 		},
-		retry.Attempts(attempts),
-		retry.Delay(delay),
+		retry.Attempts(max(policy.Attempts, 1)),
 		retry.LastErrorOnly(true),
-		retry.DelayType(retry.FixedDelay),
+		retry.DelayType(func(n uint, _ error, _ *retry.Config) time.Duration {
+			return policy.delay(n)
+		}),
+		retry.Context(ctx),
+		retry.RetryIf(func(err error) bool {
+			if class := Classify(err); class != Transient {
+				log.Debug().Err(err).Stringer("class", class).Msg("Not retrying error")
+				return false
+			}
+			return true
+		}),
 		retry.OnRetry(func(n uint, err error) {
+			lastErr = err
 			log.Debug().Msg(fmt.Sprintf("Retrying(%d): %v", n+1, err))
 		}),
 	)
+
+	if errors.Is(err, context.DeadlineExceeded) && lastErr != nil {
+		return fmt.Errorf("retry deadline of %s exceeded: %w", policy.Deadline, lastErr)
+	}
+	return err
+}
+
+// delay returns the delay after the nth failed attempt, starting at zero.
+func (policy Policy) delay(n uint) time.Duration {
+	delay := policy.Delay
+	if policy.Backoff == Exponential {
+		for i := uint(0); i < n && delay < math.MaxInt64/2; i++ {
+			if policy.MaxDelay > 0 && delay >= policy.MaxDelay {
+				break
+			}
+			delay *= 2
+		}
+	}
+
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	if policy.Jitter && delay > 0 {
+		delay = time.Duration(rand.Int63n(int64(delay) + 1))
+	}
+	return delay
 }
@@ -2,7 +2,6 @@ package retry
 
 import (
 	"errors"
-	"github.com/lovelaze/nebula-sync/internal/config"
 	"testing"
 	"time"
 
@@ -15,19 +14,17 @@ import (
 func TestWithRetry_DelayBetweenRetries(t *testing.T) {
 	t.Parallel()
 
-	Init(&config.Client{
-		RetryDelay: 1,
-	})
+	policy := Policy{Attempts: 3, Delay: time.Second}
 
 	counter := 0
 	start := time.Now()
-	err := Fixed(func() error {
+	err := policy.Do(func() error {
 		counter++
 		if counter < 3 {
 			return errors.New("test error")
 		}
 		return nil
-	}, 3) // 3 attempts, 1-second delay
+	}) // 3 attempts, 1-second delay
 
 	elapsed := time.Since(start)
 
@@ -40,15 +37,13 @@ func TestWithRetry_DelayBetweenRetries(t *testing.T) {
 func TestWithRetry_NoRetriesOnImmediateSuccess(t *testing.T) {
 	t.Parallel()
 
-	Init(&config.Client{
-		RetryDelay: 2,
-	})
+	policy := Policy{Attempts: 5, Delay: 2 * time.Second}
 
 	counter := 0
-	err := Fixed(func() error {
+	err := policy.Do(func() error {
 		counter++
 		return nil
-	}, 5) // 5 attempts, 2-second delay
+	}) // 5 attempts, 2-second delay
 
 	assert.NoError(t, err, "Expected no error when function succeeds immediately")
 	assert.Equal(t, 1, counter, "Expected function to run only once without retries")
@@ -58,18 +53,16 @@ func TestWithRetry_NoRetriesOnImmediateSuccess(t *testing.T) {
 func TestWithRetry_SuccessAfterRetries(t *testing.T) {
 	t.Parallel()
 
-	Init(&config.Client{
-		RetryDelay: 1,
-	})
+	policy := Policy{Attempts: 3, Delay: time.Second}
 
 	counter := 0
-	err := Fixed(func() error {
+	err := policy.Do(func() error {
 		counter++
 		if counter < 2 {
 			return errors.New("test error")
 		}
 		return nil
-	}, 3) // 3 attempts, 1-second delay
+	}) // 3 attempts, 1-second delay
 
 	assert.NoError(t, err, "Expected success before max attempts")
 	assert.Equal(t, 2, counter, "Expected function to retry once before success")
@@ -79,16 +72,52 @@ func TestWithRetry_SuccessAfterRetries(t *testing.T) {
 func TestWithRetry_MaxAttemptsFailure(t *testing.T) {
 	t.Parallel()
 
-	Init(&config.Client{
-		RetryDelay: 1,
-	})
+	policy := Policy{Attempts: 3, Delay: time.Second}
 
 	counter := 0
-	err := Fixed(func() error {
+	err := policy.Do(func() error {
 		counter++
 		return errors.New("test error")
-	}, 3) // 3 attempts, 1-second delay
+	}) // 3 attempts, 1-second delay
 
 	assert.Error(t, err, "Expected an error after max attempts")
 	assert.Equal(t, 3, counter, "Expected function to be retried 3 times")
 }
+
+func TestWithRetry_Deadline(t *testing.T) {
+	t.Parallel()
+
+	policy := Policy{Attempts: 10, Delay: 100 * time.Millisecond, Deadline: 250 * time.Millisecond}
+
+	counter := 0
+	err := policy.Do(func() error {
+		counter++
+		return errors.New("test error")
+	})
+
+	assert.ErrorContains(t, err, "test error")
+	assert.ErrorContains(t, err, "deadline")
+	assert.Equal(t, 3, counter, "Expected retries to stop at the deadline")
+}
+
+func TestPolicy_delay(t *testing.T) {
+	fixed := Policy{Delay: time.Second}
+	assert.Equal(t, time.Second, fixed.delay(0))
+	assert.Equal(t, time.Second, fixed.delay(4))
+
+	exponential := Policy{Delay: time.Second, MaxDelay: 10 * time.Second, Backoff: Exponential}
+	assert.Equal(t, time.Second, exponential.delay(0))
+	assert.Equal(t, 2*time.Second, exponential.delay(1))
+	assert.Equal(t, 8*time.Second, exponential.delay(3))
+	assert.Equal(t, 10*time.Second, exponential.delay(4))
+	assert.Equal(t, 10*time.Second, exponential.delay(100))
+
+	unbounded := Policy{Delay: time.Second, Backoff: Exponential}
+	assert.Positive(t, unbounded.delay(100))
+
+	jitter := Policy{Delay: time.Second, MaxDelay: 4 * time.Second, Backoff: Exponential, Jitter: true}
+	for range 100 {
+		assert.GreaterOrEqual(t, jitter.delay(5), time.Duration(0))
+		assert.LessOrEqual(t, jitter.delay(5), 4*time.Second)
+	}
+}
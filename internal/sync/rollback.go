@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/sync/retry"
+	"github.com/lovelaze/nebula-sync/internal/sync/snapshot"
+	"github.com/rs/zerolog/log"
+)
+
+// replicaSnapshot is the path of a replica's snapshot in the snapshot store. Snapshots are read back from the
+// store on rollback, so the archives are not held in memory during the sync.
+type replicaSnapshot struct {
+	replica pihole.Client
+	path    string
+}
+
+func newSnapshotStore(settings *config.RollbackSettings) *snapshot.Store {
+	return snapshot.NewStore(settings.SnapshotDir, time.Duration(settings.SnapshotRetention)*time.Hour)
+}
+
+func (target *target) takeSnapshots(settings *config.RollbackSettings) ([]replicaSnapshot, error) {
+	log.Info().Msg("Taking replica snapshots...")
+
+	store := newSnapshotStore(settings)
+	if err := store.Prune(time.Now()); err != nil {
+		log.Warn().Err(err).Msg("Failed to prune snapshots")
+	}
+
+	snapshots := make([]replicaSnapshot, 0, len(target.Replicas))
+	for _, replica := range target.Replicas {
+		teleporter, err := replica.GetTeleporter()
+		if err != nil {
+			return nil, err
+		}
+
+		configResponse, err := replica.GetConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		s := &snapshot.Snapshot{
+			Target:     replica.String(),
+			Time:       time.Now(),
+			Teleporter: teleporter,
+			Config:     configResponse,
+		}
+
+		path, err := store.Save(s)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug().Str("replica", replica.String()).Str("path", path).Msg("Saved snapshot")
+
+		snapshots = append(snapshots, replicaSnapshot{replica: replica, path: path})
+	}
+
+	return snapshots, nil
+}
+
+// writtenSnapshots returns the snapshots of the written replicas. Replicas no step executed on, such as the
+// replicas after a failed canary or rolling batch, are left alone.
+func writtenSnapshots(snapshots []replicaSnapshot, written []pihole.Client) []replicaSnapshot {
+	var result []replicaSnapshot
+	for _, rs := range snapshots {
+		if slices.Contains(written, rs.replica) {
+			result = append(result, rs)
+		}
+	}
+	return result
+}
+
+// rollback restores the replicas from their snapshots. The config is restored with the config settings of the
+// failed run, so that the sections and keys it synced, including protected keys it selected, are restored.
+func (target *target) rollback(settings *config.RollbackSettings, configSettings *config.ConfigSettings, snapshots []replicaSnapshot) error {
+	log.Info().Msg("Rolling back replicas...")
+
+	store := newSnapshotStore(settings)
+	teleporterRequest := createPostTeleporterRequest(newFullSyncGravitySettings())
+	if configSettings == nil {
+		configSettings = newFullSyncConfigSettings()
+	}
+
+	var errs []error
+	for _, rs := range snapshots {
+		replica := rs.replica
+		s, err := store.Load(rs.path)
+		if err != nil {
+			log.Error().Err(err).Str("replica", replica.String()).Str("path", rs.path).Msg("Failed to load snapshot")
+			errs = append(errs, fmt.Errorf("load snapshot: %w", err))
+			continue
+		}
+		configRequest := createPatchConfigRequest(configSettings, s.Config)
+
+		if err := target.retries.Do(retry.Teleporter, replica, func() error {
+			return replica.PostTeleporter(s.Teleporter, teleporterRequest)
+		}); err != nil {
+			log.Error().Err(err).Str("replica", replica.String()).Msg("Failed to restore teleporter")
+			errs = append(errs, fmt.Errorf("restore teleporter: %w", err))
+			continue
+		}
+
+		if err := target.retries.Do(retry.PatchConfig, replica, func() error {
+			return replica.PatchConfig(configRequest)
+		}); err != nil {
+			log.Error().Err(err).Str("replica", replica.String()).Msg("Failed to restore config")
+			errs = append(errs, fmt.Errorf("restore config: %w", err))
+			continue
+		}
+
+		log.Info().Str("replica", replica.String()).Msg("Replica rolled back")
+	}
+
+	return errors.Join(errs...)
+}
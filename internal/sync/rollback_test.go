@@ -0,0 +1,157 @@
+package sync
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarget_FullSync_rollback(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+
+	target := NewTarget(primary, []pihole.Client{replica}, nil)
+	snapshotDir := t.TempDir()
+
+	primary.EXPECT().PostAuth().Once().Return(nil)
+	replica.EXPECT().PostAuth().Once().Return(nil)
+
+	replica.EXPECT().String().Return("http://replica")
+	replica.EXPECT().GetTeleporter().Once().Return([]byte("before"), nil)
+	replica.EXPECT().GetConfig().Once().Return(emptyConfigResponse(), nil)
+
+	primary.EXPECT().GetTeleporter().Once().Return([]byte("after"), nil)
+	replica.EXPECT().PostTeleporter([]byte("after"), mock.Anything).Once().Return(nil)
+
+	patchErr := errors.New("patch failed")
+	primary.EXPECT().GetConfig().Once().Return(emptyConfigResponse(), nil)
+	replica.EXPECT().PatchConfig(mock.Anything).Times(5).Return(patchErr)
+
+	replica.EXPECT().PostTeleporter([]byte("before"), mock.Anything).Once().Return(nil)
+	replica.EXPECT().PatchConfig(mock.Anything).Once().Return(nil)
+
+	primary.EXPECT().DeleteSession().Once().Return(nil)
+	replica.EXPECT().DeleteSession().Once().Return(nil)
+
+	err := target.FullSync(&config.Sync{
+		FullSync: true,
+		Rollback: &config.RollbackSettings{
+			Enabled:           true,
+			SnapshotDir:       snapshotDir,
+			SnapshotRetention: 1,
+		},
+	})
+	require.ErrorIs(t, err, patchErr)
+	assert.Contains(t, err.Error(), "rolled back")
+
+	entries, err := os.ReadDir(snapshotDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func Test_target_rollback_failure(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+
+	target := target{
+		Primary:  primary,
+		Replicas: []pihole.Client{replica},
+	}
+
+	replica.EXPECT().String().Return("http://replica")
+	replica.EXPECT().GetTeleporter().Once().Return([]byte("before"), nil)
+	replica.EXPECT().GetConfig().Once().Return(emptyConfigResponse(), nil)
+
+	settings := &config.RollbackSettings{Enabled: true, SnapshotDir: t.TempDir()}
+	snapshots, err := target.takeSnapshots(settings)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+
+	restoreErr := errors.New("restore failed")
+	replica.EXPECT().PostTeleporter([]byte("before"), mock.Anything).Times(5).Return(restoreErr)
+
+	err = target.rollback(settings, nil, snapshots)
+	assert.ErrorIs(t, err, restoreErr)
+}
+
+func TestTarget_FullSync_rollbackWrittenOnly(t *testing.T) {
+	useFakeProber(t, false)
+
+	primary := piholemock.NewClient(t)
+	canary := newNamedReplica(t, "http://canary.lan")
+	other := newNamedReplica(t, "http://other.lan")
+
+	primary.EXPECT().PostAuth().Once().Return(nil)
+	primary.EXPECT().DeleteSession().Once().Return(nil)
+	for _, replica := range []*piholemock.Client{canary, other} {
+		replica.EXPECT().PostAuth().Once().Return(nil)
+		replica.EXPECT().DeleteSession().Once().Return(nil)
+		replica.EXPECT().GetTeleporter().Once().Return([]byte("before"), nil)
+		replica.EXPECT().GetConfig().Once().Return(emptyConfigResponse(), nil)
+	}
+	canarySyncExpectations(primary, canary)
+
+	// only the canary was written, so only the canary is restored
+	canary.EXPECT().PostTeleporter([]byte("before"), mock.Anything).Once().Return(nil)
+	canary.EXPECT().PatchConfig(mock.Anything).Once().Return(nil)
+
+	target := NewTarget(primary, []pihole.Client{canary, other}, nil)
+	err := target.FullSync(&config.Sync{
+		FullSync: true,
+		Canary: &config.CanarySettings{
+			Enabled:      true,
+			Replica:      "canary",
+			DNSServer:    "127.0.0.1:5353",
+			BlockDomains: []string{"doubleclick.net"},
+		},
+		Rollback: &config.RollbackSettings{Enabled: true, SnapshotDir: t.TempDir()},
+	})
+	require.ErrorIs(t, err, ErrCanaryFailed)
+	assert.Contains(t, err.Error(), "rolled back")
+}
+
+func Test_target_rollback_configSettings(t *testing.T) {
+	replica := piholemock.NewClient(t)
+	replica.EXPECT().String().Return("http://replica")
+	replica.EXPECT().GetTeleporter().Once().Return([]byte("before"), nil)
+	replica.EXPECT().GetConfig().Once().Return(&model.ConfigResponse{Config: map[string]interface{}{
+		"dns":       map[string]interface{}{"upstreams": []interface{}{"9.9.9.9"}},
+		"webserver": map[string]interface{}{"port": "8080", "domain": "pi.hole"},
+	}}, nil)
+
+	target := target{Replicas: []pihole.Client{replica}}
+	settings := &config.RollbackSettings{Enabled: true, SnapshotDir: t.TempDir()}
+	snapshots, err := target.takeSnapshots(settings)
+	require.NoError(t, err)
+
+	// the failed run synced webserver.port, a protected host key, so it is restored from the snapshot
+	configSettings := &config.ConfigSettings{Sections: map[string]*config.ConfigSetting{
+		"webserver": config.NewConfigSetting(true, []string{"port"}, nil),
+	}}
+	replica.EXPECT().PostTeleporter([]byte("before"), mock.Anything).Once().Return(nil)
+	replica.EXPECT().PatchConfig(&model.PatchConfigRequest{Config: model.PatchConfig{
+		"webserver": {"port": "8080"},
+	}}).Once().Return(nil)
+
+	require.NoError(t, target.rollback(settings, configSettings, snapshots))
+}
+
+func Test_target_rollback_missingSnapshot(t *testing.T) {
+	replica := piholemock.NewClient(t)
+	replica.EXPECT().String().Return("http://replica")
+
+	target := target{Replicas: []pihole.Client{replica}}
+	settings := &config.RollbackSettings{Enabled: true, SnapshotDir: t.TempDir()}
+
+	err := target.rollback(settings, nil, []replicaSnapshot{{replica: replica, path: filepath.Join(settings.SnapshotDir, "missing")}})
+	assert.ErrorContains(t, err, "load snapshot")
+}
@@ -0,0 +1,128 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/rs/zerolog/log"
+)
+
+var readyPollInterval = time.Second
+
+// rollout runs a disruptive action, such as a teleporter import or a gravity run, on the given replicas.
+// Without rolling settings the replicas are processed one after another. With rolling enabled the replicas
+// are ordered by priority and processed in batches; every replica in a batch must report ready before the
+// next batch starts, after an optional pause.
+func (target *target) rollout(rolling *config.RollingSettings, action string, replicas []pihole.Client, fn func(replica pihole.Client) error) error {
+	if rolling == nil || !rolling.Enabled {
+		for _, replica := range replicas {
+			if err := fn(replica); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	batches := batchReplicas(orderReplicas(replicas, rolling.Order), rolling.BatchSize)
+	for i, batch := range batches {
+		log.Info().Str("action", action).Int("batch", i+1).Int("batches", len(batches)).Strs("replicas", replicaNames(batch)).Msg("Rolling out")
+
+		if err := runBatch(batch, fn); err != nil {
+			return err
+		}
+
+		for _, replica := range batch {
+			if err := waitReady(replica, time.Duration(rolling.ReadyTimeout)*time.Second); err != nil {
+				target.failed = append(target.failed, replica)
+				return fmt.Errorf("%s not ready after %s: %w", replica.String(), action, err)
+			}
+		}
+
+		if i < len(batches)-1 && rolling.Pause > 0 {
+			log.Debug().Int64("seconds", rolling.Pause).Msg("Pausing rollout")
+			time.Sleep(time.Duration(rolling.Pause) * time.Second)
+		}
+	}
+
+	return nil
+}
+
+func runBatch(batch []pihole.Client, fn func(replica pihole.Client) error) error {
+	errs := make([]error, len(batch))
+
+	var wg gosync.WaitGroup
+	for i, replica := range batch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = fn(replica)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// waitReady polls the replica until it answers api requests again or the timeout expires.
+func waitReady(replica pihole.Client, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := replica.GetVersion()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(readyPollInterval)
+	}
+}
+
+// orderReplicas sorts the replicas by the first entry of order contained in their url, keeping the
+// configured order for replicas with the same priority. Replicas not matching any entry go last.
+func orderReplicas(replicas []pihole.Client, order []string) []pihole.Client {
+	priority := func(replica pihole.Client) int {
+		for i, entry := range order {
+			if strings.Contains(replica.String(), entry) {
+				return i
+			}
+		}
+		return len(order)
+	}
+
+	ordered := make([]pihole.Client, len(replicas))
+	copy(ordered, replicas)
+	if len(order) > 0 {
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return priority(ordered[i]) < priority(ordered[j])
+		})
+	}
+	return ordered
+}
+
+func batchReplicas(replicas []pihole.Client, size int) [][]pihole.Client {
+	if size < 1 {
+		size = 1
+	}
+
+	var batches [][]pihole.Client
+	for start := 0; start < len(replicas); start += size {
+		end := min(start+size, len(replicas))
+		batches = append(batches, replicas[start:end])
+	}
+	return batches
+}
+
+func replicaNames(replicas []pihole.Client) []string {
+	names := make([]string, 0, len(replicas))
+	for _, replica := range replicas {
+		names = append(names, replica.String())
+	}
+	return names
+}
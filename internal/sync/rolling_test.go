@@ -0,0 +1,112 @@
+package sync
+
+import (
+	"errors"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newNamedReplica(t *testing.T, name string) *piholemock.Client {
+	replica := piholemock.NewClient(t)
+	replica.EXPECT().String().Maybe().Return(name)
+	return replica
+}
+
+func Test_orderReplicas(t *testing.T) {
+	office := newNamedReplica(t, "http://pihole-office.lan")
+	lab := newNamedReplica(t, "http://pihole-lab.lan")
+	core := newNamedReplica(t, "http://pihole-core.lan")
+	lab2 := newNamedReplica(t, "http://pihole-lab2.lan")
+
+	ordered := orderReplicas([]pihole.Client{office, lab, core, lab2}, []string{"lab", "office"})
+	assert.Equal(t, []pihole.Client{lab, lab2, office, core}, ordered)
+
+	unordered := orderReplicas([]pihole.Client{office, lab}, nil)
+	assert.Equal(t, []pihole.Client{office, lab}, unordered)
+}
+
+func Test_batchReplicas(t *testing.T) {
+	a, b, c := piholemock.NewClient(t), piholemock.NewClient(t), piholemock.NewClient(t)
+
+	assert.Equal(t, [][]pihole.Client{{a, b}, {c}}, batchReplicas([]pihole.Client{a, b, c}, 2))
+	assert.Equal(t, [][]pihole.Client{{a}, {b}, {c}}, batchReplicas([]pihole.Client{a, b, c}, 0))
+}
+
+func fastReadyPolls(t *testing.T) {
+	previous := readyPollInterval
+	readyPollInterval = time.Millisecond
+	t.Cleanup(func() { readyPollInterval = previous })
+}
+
+func Test_target_rollout(t *testing.T) {
+	fastReadyPolls(t)
+
+	core := newNamedReplica(t, "http://core")
+	lab := newNamedReplica(t, "http://lab")
+	office := newNamedReplica(t, "http://office")
+
+	lab.EXPECT().GetVersion().Once().Return(nil, errors.New("restarting"))
+	for _, replica := range []*piholemock.Client{core, lab, office} {
+		replica.EXPECT().GetVersion().Once().Return(&model.VersionResponse{}, nil)
+	}
+
+	target := target{Replicas: []pihole.Client{core, lab, office}}
+	rolling := &config.RollingSettings{Enabled: true, BatchSize: 2, ReadyTimeout: 1, Order: []string{"lab", "office"}}
+
+	var mu gosync.Mutex
+	var processed []string
+	err := target.rollout(rolling, "gravity", target.Replicas, func(replica pihole.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		processed = append(processed, replica.String())
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, processed, 3)
+	assert.ElementsMatch(t, []string{"http://lab", "http://office"}, processed[:2])
+	assert.Equal(t, "http://core", processed[2])
+}
+
+func Test_target_rollout_notReady(t *testing.T) {
+	fastReadyPolls(t)
+
+	first := newNamedReplica(t, "http://first")
+	second := newNamedReplica(t, "http://second")
+
+	first.EXPECT().GetVersion().Return(nil, errors.New("down"))
+
+	target := target{Replicas: []pihole.Client{first, second}}
+	rolling := &config.RollingSettings{Enabled: true, BatchSize: 1}
+
+	var processed []string
+	err := target.rollout(rolling, "teleporter", target.Replicas, func(replica pihole.Client) error {
+		processed = append(processed, replica.String())
+		return nil
+	})
+	require.Error(t, err)
+	assert.Equal(t, []string{"http://first"}, processed)
+}
+
+func Test_target_rollout_disabled(t *testing.T) {
+	first := piholemock.NewClient(t)
+	second := piholemock.NewClient(t)
+
+	target := target{Replicas: []pihole.Client{first, second}}
+
+	var processed []pihole.Client
+	err := target.rollout(nil, "gravity", target.Replicas, func(replica pihole.Client) error {
+		processed = append(processed, replica)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []pihole.Client{first, second}, processed)
+}
@@ -7,24 +7,23 @@ import (
 )
 
 func (target *target) SelectiveSync(conf *config.Sync) error {
-	return target.sync(func() error {
-		return target.selective(conf)
-	}, "selective")
+	return target.sync(func(run *pipelineRun) error {
+		return target.selective(conf, run)
+	}, "selective", conf)
 }
 
-func (target *target) selective(conf *config.Sync) error {
-	if err := target.syncTeleporters(conf.GravitySettings); err != nil {
-		return fmt.Errorf("sync teleporters: %w", err)
+func (target *target) selective(conf *config.Sync, run *pipelineRun) error {
+	rules, err := conf.Exclude.Rules()
+	if err != nil {
+		return fmt.Errorf("exclude rules: %w", err)
 	}
 
-	if err := target.syncConfigs(conf.ConfigSettings); err != nil {
-		return fmt.Errorf("sync configs: %w", err)
+	run.configSettings = conf.ConfigSettings
+	steps := target.newSteps(conf, conf.GravitySettings, run.configSettings, rules)
+	pipeline, err := newPipeline(selectiveSyncSteps, conf.Pipeline, steps)
+	if err != nil {
+		return err
 	}
 
-	if conf.RunGravity {
-		if err := target.runGravity(); err != nil {
-			return fmt.Errorf("run gravity: %w", err)
-		}
-	}
-	return nil
+	return target.runPipeline(run, pipeline...)
 }
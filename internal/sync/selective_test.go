@@ -14,7 +14,7 @@ func TestTarget_SelectiveSync(t *testing.T) {
 	primary := piholemock.NewClient(t)
 	replica := piholemock.NewClient(t)
 
-	target := NewTarget(primary, []pihole.Client{replica})
+	target := NewTarget(primary, []pihole.Client{replica}, nil)
 
 	settings := config.Sync{
 		FullSync:   false,
@@ -30,15 +30,17 @@ func TestTarget_SelectiveSync(t *testing.T) {
 			ClientByGroup:     true,
 		},
 		ConfigSettings: &config.ConfigSettings{
-			DNS:       config.NewConfigSetting(true, nil, nil),
-			DHCP:      config.NewConfigSetting(true, nil, nil),
-			NTP:       config.NewConfigSetting(true, nil, nil),
-			Resolver:  config.NewConfigSetting(true, nil, nil),
-			Database:  config.NewConfigSetting(true, nil, nil),
-			Webserver: config.NewConfigSetting(false, nil, nil),
-			Files:     config.NewConfigSetting(false, nil, nil),
-			Misc:      config.NewConfigSetting(true, nil, nil),
-			Debug:     config.NewConfigSetting(true, nil, nil),
+			Sections: map[string]*config.ConfigSetting{
+				"dns":       config.NewConfigSetting(true, nil, nil),
+				"dhcp":      config.NewConfigSetting(true, nil, nil),
+				"ntp":       config.NewConfigSetting(true, nil, nil),
+				"resolver":  config.NewConfigSetting(true, nil, nil),
+				"database":  config.NewConfigSetting(true, nil, nil),
+				"webserver": config.NewConfigSetting(false, nil, nil),
+				"files":     config.NewConfigSetting(false, nil, nil),
+				"misc":      config.NewConfigSetting(true, nil, nil),
+				"debug":     config.NewConfigSetting(true, nil, nil),
+			},
 		},
 	}
 
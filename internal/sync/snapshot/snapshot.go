@@ -0,0 +1,134 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	teleporterFile = "teleporter.zip"
+	configFile     = "config.json"
+	timeFormat     = "20060102T150405Z"
+)
+
+type Snapshot struct {
+	Target     string
+	Time       time.Time
+	Teleporter []byte
+	Config     *model.ConfigResponse
+}
+
+type Store struct {
+	dir       string
+	retention time.Duration
+}
+
+func NewStore(dir string, retention time.Duration) *Store {
+	return &Store{
+		dir:       dir,
+		retention: retention,
+	}
+}
+
+func (store *Store) Save(snapshot *Snapshot) (string, error) {
+	path := filepath.Join(store.dir, fmt.Sprintf("%s_%s", snapshot.Time.UTC().Format(timeFormat), sanitize(snapshot.Target)))
+
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return "", fmt.Errorf("create snapshot dir: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(path, teleporterFile), snapshot.Teleporter, 0o600); err != nil {
+		return "", fmt.Errorf("write teleporter: %w", err)
+	}
+
+	configBytes, err := json.Marshal(snapshot.Config)
+	if err != nil {
+		return "", fmt.Errorf("marshal config: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(path, configFile), configBytes, 0o600); err != nil {
+		return "", fmt.Errorf("write config: %w", err)
+	}
+
+	return path, nil
+}
+
+func (store *Store) Load(path string) (*Snapshot, error) {
+	teleporter, err := os.ReadFile(filepath.Join(path, teleporterFile))
+	if err != nil {
+		return nil, fmt.Errorf("read teleporter: %w", err)
+	}
+
+	configBytes, err := os.ReadFile(filepath.Join(path, configFile))
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	configResponse := model.ConfigResponse{}
+	if err := json.Unmarshal(configBytes, &configResponse); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	timestamp, target, _ := strings.Cut(filepath.Base(path), "_")
+	parsedTime, err := time.Parse(timeFormat, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("parse snapshot time: %w", err)
+	}
+
+	return &Snapshot{
+		Target:     target,
+		Time:       parsedTime,
+		Teleporter: teleporter,
+		Config:     &configResponse,
+	}, nil
+}
+
+// Prune removes snapshots older than the retention period.
+func (store *Store) Prune(now time.Time) error {
+	entries, err := os.ReadDir(store.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read snapshot dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		timestamp, _, _ := strings.Cut(entry.Name(), "_")
+		created, err := time.Parse(timeFormat, timestamp)
+		if err != nil {
+			continue
+		}
+
+		if now.Sub(created) > store.retention {
+			log.Debug().Str("snapshot", entry.Name()).Msg("Removing expired snapshot")
+			if err := os.RemoveAll(filepath.Join(store.dir, entry.Name())); err != nil {
+				return fmt.Errorf("remove snapshot: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func sanitize(target string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '-'
+		}
+	}, target)
+}
@@ -0,0 +1,58 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_SaveLoad(t *testing.T) {
+	store := NewStore(t.TempDir(), time.Hour)
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	path, err := store.Save(&Snapshot{
+		Target:     "http://ph2.example.com:8080",
+		Time:       now,
+		Teleporter: []byte("zip"),
+		Config: &model.ConfigResponse{Config: map[string]interface{}{
+			"dns": map[string]interface{}{"upstreams": []interface{}{"1.1.1.1"}},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "20250102T030405Z_http---ph2.example.com-8080", filepath.Base(path))
+
+	snapshot, err := store.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, now, snapshot.Time)
+	assert.Equal(t, []byte("zip"), snapshot.Teleporter)
+	assert.Equal(t, []interface{}{"1.1.1.1"}, snapshot.Config.Get("dns")["upstreams"])
+}
+
+func TestStore_Prune(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir, 24*time.Hour)
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	old, err := store.Save(&Snapshot{Target: "old", Time: now.Add(-48 * time.Hour), Config: &model.ConfigResponse{}})
+	require.NoError(t, err)
+	recent, err := store.Save(&Snapshot{Target: "recent", Time: now.Add(-time.Hour), Config: &model.ConfigResponse{}})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Prune(now))
+
+	assert.NoDirExists(t, old)
+	assert.DirExists(t, recent)
+}
+
+func TestStore_Prune_MissingDir(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "missing"), time.Hour)
+	assert.NoError(t, store.Prune(time.Now()))
+
+	_, err := os.Stat(store.dir)
+	assert.True(t, os.IsNotExist(err))
+}
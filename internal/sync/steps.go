@@ -0,0 +1,389 @@
+package sync
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync/backup"
+	"github.com/lovelaze/nebula-sync/internal/sync/exclude"
+	"github.com/lovelaze/nebula-sync/internal/sync/filter"
+	"github.com/lovelaze/nebula-sync/internal/sync/retry"
+	"github.com/rs/zerolog/log"
+)
+
+// teleporterStep imports the primary's teleporter archive on the replicas.
+type teleporterStep struct {
+	baseStep
+	gravitySettings *config.GravitySettings
+	rules           *exclude.Rules
+	backups         *backup.Store
+	archive         []byte
+	fingerprint     string
+	excluded        []exclude.Excluded
+	request         *model.PostTeleporterRequest
+}
+
+func newTeleporterStep(gravitySettings *config.GravitySettings, rules *exclude.Rules, backups *backup.Store) *teleporterStep {
+	return &teleporterStep{gravitySettings: gravitySettings, rules: rules, backups: backups}
+}
+
+func (step *teleporterStep) Name() string     { return StepTeleporters }
+func (step *teleporterStep) Disruptive() bool { return true }
+
+func (step *teleporterStep) Start(primary pihole.Client, replicas []pihole.Client) ([]pihole.Client, error) {
+	log.Info().Msg("Syncing teleporters...")
+	archive, err := primary.GetTeleporter()
+	if err != nil {
+		return nil, err
+	}
+
+	step.fingerprint = backup.Fingerprint(archive)
+	if step.backups != nil {
+		saveBackup(step.backups, archive)
+	}
+
+	step.excluded = nil
+	if importsLists(step.gravitySettings) {
+		if archive, step.excluded, err = step.rules.FilterTeleporter(archive); err != nil {
+			return nil, fmt.Errorf("exclude: %w", err)
+		}
+	}
+
+	step.archive = archive
+	step.request = nil
+	if step.gravitySettings != nil {
+		step.request = createPostTeleporterRequest(step.gravitySettings)
+	}
+
+	return replicas, nil
+}
+
+func (step *teleporterStep) Execute(replica pihole.Client) error {
+	return step.retries.Do(retry.Teleporter, replica, func() error {
+		return replica.PostTeleporter(step.archive, step.request)
+	})
+}
+
+func (step *teleporterStep) Describe(result *StepResult) {
+	result.Fingerprint = step.fingerprint
+	result.Excluded = step.excluded
+}
+
+// saveBackup stores the primary's unfiltered archive. A failed backup is logged and does not fail the sync.
+func saveBackup(backups *backup.Store, archive []byte) {
+	saved, created, err := backups.Save(archive, time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to back up teleporter")
+		return
+	}
+
+	if !created {
+		log.Debug().Str("backup", saved.ID).Msg("Teleporter unchanged since latest backup")
+		return
+	}
+
+	log.Info().Str("backup", saved.ID).Str("fingerprint", saved.Fingerprint).Msg("Backed up teleporter")
+	if err := backups.Prune(); err != nil {
+		log.Warn().Err(err).Msg("Failed to prune backups")
+	}
+}
+
+// groupsStep reconciles the selected groups through the gravity entity api, see reconcileGroups.
+type groupsStep struct {
+	baseStep
+	names    []string
+	rules    *exclude.Rules
+	primary  *gravity
+	excluded []exclude.Excluded
+}
+
+func newGroupsStep(names []string, rules *exclude.Rules) *groupsStep {
+	return &groupsStep{names: names, rules: rules}
+}
+
+func (step *groupsStep) Name() string  { return StepGroups }
+func (step *groupsStep) Enabled() bool { return len(step.names) > 0 }
+
+// Start reads the primary's gravity. Groups and group assignments that only exist on a replica are left
+// untouched, as are adlists and domains matched by the exclusion rules on either side.
+func (step *groupsStep) Start(primary pihole.Client, replicas []pihole.Client) ([]pihole.Client, error) {
+	log.Info().Strs("groups", step.names).Msg("Syncing groups...")
+
+	primaryGravity, err := readGravity(primary)
+	if err != nil {
+		return nil, err
+	}
+
+	var excludedLists, excludedDomains []exclude.Excluded
+	primaryGravity.lists, excludedLists = excludeLists(step.rules, primaryGravity.lists, primaryGravity.groups)
+	primaryGravity.domains, excludedDomains = excludeDomains(step.rules, primaryGravity.domains, primaryGravity.groups)
+	step.excluded = append(excludedLists, excludedDomains...)
+
+	step.primary = primaryGravity
+	return replicas, nil
+}
+
+func (step *groupsStep) Execute(replica pihole.Client) error {
+	return step.retries.Do(retry.SyncGroups, replica, func() error {
+		return reconcileGroups(step.primary, replica, step.names, step.rules)
+	})
+}
+
+func (step *groupsStep) Describe(result *StepResult) {
+	result.Excluded = step.excluded
+}
+
+// configStep patches the replicas' config with the primary's filtered config. If countChanges is set, or element
+// filters are configured, each replica's config is read before patching it to count the keys that change and to
+// keep the array elements that are not synced.
+type configStep struct {
+	baseStep
+	configSettings *config.ConfigSettings
+	rewrites       []*filter.Rewrite
+	countChanges   bool
+	request        *model.PatchConfigRequest
+	changed        map[pihole.Client]int
+}
+
+func newConfigStep(configSettings *config.ConfigSettings, rewrites []*filter.Rewrite, countChanges bool) *configStep {
+	return &configStep{configSettings: configSettings, rewrites: rewrites, countChanges: countChanges}
+}
+
+func (step *configStep) Name() string  { return StepConfigs }
+func (step *configStep) Enabled() bool { return step.configSettings != nil }
+
+func (step *configStep) Start(primary pihole.Client, replicas []pihole.Client) ([]pihole.Client, error) {
+	log.Info().Msg("Syncing configs...")
+	configResponse, err := primary.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	step.request = selectPatchConfigElements(createPatchConfigRequest(step.configSettings, configResponse), step.configSettings.Elements)
+	step.changed = make(map[pihole.Client]int, len(replicas))
+	return replicas, nil
+}
+
+func (step *configStep) Execute(replica pihole.Client) error {
+	request, rewritten := rewritePatchConfigRequest(step.request, step.rewrites, replica)
+	for _, r := range rewritten {
+		log.Debug().Str("replica", replica.String()).Str("key", r.Path).Str("from", r.From).Str("to", r.To).Msg("Rewrote config value")
+	}
+
+	if step.countChanges || len(step.configSettings.Elements) > 0 {
+		current, err := replica.GetConfig()
+		if err != nil {
+			return err
+		}
+		request = mergePatchConfigElements(request, step.configSettings.Elements, current)
+		step.changed[replica] = len(compareConfig(request, current))
+	}
+
+	return step.retries.Do(retry.PatchConfig, replica, func() error {
+		return replica.PatchConfig(request)
+	})
+}
+
+func (step *configStep) Describe(result *StepResult) {
+	for i := range result.Replicas {
+		result.Replicas[i].Changed = step.changed[result.Replicas[i].Replica]
+	}
+}
+
+// gravityStep runs gravity on the primary and the replicas. In conditional mode it only runs on the replicas
+// whose adlists changed during the sync or whose gravity is older than the configured max age, and only
+// on the primary if requested.
+type gravityStep struct {
+	baseStep
+	enabled       bool
+	settings      *config.GravityRunSettings
+	now           func() time.Time
+	adlistsBefore map[pihole.Client]string
+}
+
+func newGravityStep(enabled bool, settings *config.GravityRunSettings) *gravityStep {
+	return &gravityStep{enabled: enabled, settings: settings, now: time.Now}
+}
+
+func (step *gravityStep) Name() string     { return StepGravity }
+func (step *gravityStep) Enabled() bool    { return step.enabled }
+func (step *gravityStep) Disruptive() bool { return true }
+
+func (step *gravityStep) Prepare(replicas []pihole.Client) (err error) {
+	if step.settings.Conditional() {
+		step.adlistsBefore, err = adlistStates(replicas)
+	}
+	return err
+}
+
+func (step *gravityStep) Start(primary pihole.Client, replicas []pihole.Client) ([]pihole.Client, error) {
+	if !step.settings.Conditional() {
+		log.Info().Msg("Running gravity...")
+		return replicas, primary.PostRunGravity()
+	}
+
+	log.Info().Msg("Running gravity on changed replicas...")
+	if step.settings.Primary {
+		if err := primary.PostRunGravity(); err != nil {
+			return nil, err
+		}
+	}
+	return replicas, nil
+}
+
+// Select returns the replicas whose adlists changed during the sync or whose gravity is too old, in conditional mode.
+func (step *gravityStep) Select(replicas []pihole.Client) ([]pihole.Client, error) {
+	if !step.settings.Conditional() {
+		return replicas, nil
+	}
+
+	var changed []pihole.Client
+	for _, replica := range replicas {
+		reason, err := gravityReason(replica, step.adlistsBefore[replica], step.settings.MaxAge, step.now())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", replica.String(), err)
+		}
+
+		if reason == "" {
+			log.Info().Str("replica", replica.String()).Msg("Skipping gravity, adlists unchanged")
+			continue
+		}
+
+		log.Info().Str("replica", replica.String()).Str("reason", reason).Msg("Running gravity")
+		changed = append(changed, replica)
+	}
+
+	return changed, nil
+}
+
+func (step *gravityStep) Execute(replica pihole.Client) error {
+	return step.retries.Do(retry.RunGravity, replica, func() error {
+		return replica.PostRunGravity()
+	})
+}
+
+// restartDNSStep restarts the DNS resolver on the replicas. It is not part of the predefined pipelines.
+type restartDNSStep struct {
+	baseStep
+}
+
+func newRestartDNSStep() *restartDNSStep {
+	return &restartDNSStep{}
+}
+
+func (step *restartDNSStep) Name() string     { return StepRestartDNS }
+func (step *restartDNSStep) Disruptive() bool { return true }
+
+func (step *restartDNSStep) Start(primary pihole.Client, replicas []pihole.Client) ([]pihole.Client, error) {
+	log.Info().Msg("Restarting DNS...")
+	return replicas, nil
+}
+
+func (step *restartDNSStep) Execute(replica pihole.Client) error {
+	return step.retries.Do(retry.RestartDNS, replica, func() error {
+		return replica.PostRestartDNS()
+	})
+}
+
+// verifyStep re-reads the replicas and compares them with what the primary produced, see Report.
+type verifyStep struct {
+	baseStep
+	enabled         bool
+	gravitySettings *config.GravitySettings
+	configSettings  *config.ConfigSettings
+	rewrites        []*filter.Rewrite
+	rules           *exclude.Rules
+	strict          bool
+	expected        *model.PatchConfigRequest
+	expectedCounts  map[string]int
+	reports         []Report
+}
+
+func newVerifyStep(enabled bool, gravitySettings *config.GravitySettings, configSettings *config.ConfigSettings, rewrites []*filter.Rewrite, rules *exclude.Rules, strict bool) *verifyStep {
+	return &verifyStep{
+		enabled:         enabled,
+		gravitySettings: gravitySettings,
+		configSettings:  configSettings,
+		rewrites:        rewrites,
+		rules:           rules,
+		strict:          strict,
+	}
+}
+
+func (step *verifyStep) Name() string  { return StepVerify }
+func (step *verifyStep) Enabled() bool { return step.enabled }
+
+func (step *verifyStep) Start(primary pihole.Client, replicas []pihole.Client) ([]pihole.Client, error) {
+	log.Info().Msg("Verifying replicas...")
+
+	primaryConfig, err := primary.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	step.expected = selectPatchConfigElements(createPatchConfigRequest(step.configSettings, primaryConfig), step.configSettings.Elements)
+
+	step.expectedCounts = nil
+	if step.gravitySettings != nil {
+		if step.expectedCounts, err = gravityCounts(primary, step.gravitySettings, step.rules); err != nil {
+			return nil, err
+		}
+	}
+
+	step.reports = make([]Report, 0, len(replicas))
+	return replicas, nil
+}
+
+func (step *verifyStep) Execute(replica pihole.Client) error {
+	actual, err := replica.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	expected, _ := rewritePatchConfigRequest(step.expected, step.rewrites, replica)
+	expected = mergePatchConfigElements(expected, step.configSettings.Elements, actual)
+	divergences := compareConfig(expected, actual)
+
+	if step.gravitySettings != nil {
+		actualCounts, err := gravityCounts(replica, step.gravitySettings, step.rules)
+		if err != nil {
+			return err
+		}
+		divergences = append(divergences, compareCounts(step.expectedCounts, actualCounts)...)
+	}
+
+	step.reports = append(step.reports, Report{Replica: replica.String(), Divergences: divergences})
+	return nil
+}
+
+func (step *verifyStep) Finish() error {
+	diverged := 0
+	for _, report := range step.reports {
+		if len(report.Divergences) == 0 {
+			log.Debug().Str("replica", report.Replica).Msg("Replica verified")
+			continue
+		}
+
+		diverged++
+		for _, divergence := range report.Divergences {
+			log.Warn().
+				Str("replica", report.Replica).
+				Str("key", divergence.Key).
+				Interface("expected", divergence.Expected).
+				Interface("actual", divergence.Actual).
+				Msg("Replica diverged")
+		}
+	}
+
+	verified := len(step.reports)
+	// with a canary the step finishes twice, the remaining replicas are reported on their own
+	step.reports = nil
+
+	if diverged > 0 && step.strict {
+		return fmt.Errorf("%w: %d of %d replicas", ErrDiverged, diverged, verified)
+	}
+
+	return nil
+}
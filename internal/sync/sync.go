@@ -6,43 +6,145 @@ import (
 	"github.com/lovelaze/nebula-sync/internal/pihole"
 	"github.com/lovelaze/nebula-sync/internal/pihole/model"
 	"github.com/lovelaze/nebula-sync/internal/sync/filter"
+	"github.com/lovelaze/nebula-sync/internal/sync/journal"
 	"github.com/lovelaze/nebula-sync/internal/sync/retry"
 	"github.com/rs/zerolog/log"
+	"slices"
+	"time"
 )
 
 type Target interface {
 	FullSync(sync *config.Sync) error
 	SelectiveSync(sync *config.Sync) error
+	DetectDrift(sync *config.Sync) ([]Report, error)
+	ValidateConfig(sync *config.Sync) error
+	// BreakerEvents returns the replicas quarantined or recovered by the circuit breaker during the latest sync.
+	BreakerEvents() []BreakerEvent
+	// LastRun returns the journal record of the latest sync, or nil if journaling is disabled.
+	LastRun() *journal.Record
+	// Inherit takes over the circuit breaker and drift state of previous for the replicas whose url is unchanged,
+	// so that they survive a config reload.
+	Inherit(previous Target)
 }
 
 type target struct {
-	Primary  pihole.Client
-	Replicas []pihole.Client
-	Client   *config.Client
+	Primary    pihole.Client
+	Replicas   []pihole.Client
+	Sources    []pihole.Client
+	Client     *config.Client
+	driftSince map[string]map[string]time.Time
+	results    []StepResult
+	run        *journal.Record
+	retries    *retry.Policies
+	// failed holds the replicas that failed to authenticate or become ready during the latest run.
+	failed        []pihole.Client
+	breakers      map[string]*breaker
+	breakerEvents []BreakerEvent
 }
 
-func NewTarget(primary pihole.Client, replicas []pihole.Client) Target {
+// NewTarget returns a target syncing the primary to the replicas, retrying failed replica operations with
+// retries. Sources are additional instances whose domains and adlists are merged with the primary's when
+// merging is enabled.
+func NewTarget(primary pihole.Client, replicas []pihole.Client, retries *retry.Policies, sources ...pihole.Client) Target {
 	return &target{
 		Primary:  primary,
 		Replicas: replicas,
+		Sources:  sources,
+		retries:  retries,
 	}
 }
 
-func (target *target) sync(syncFunc func() error, mode string) (err error) {
+func (target *target) Inherit(previous Target) {
+	prev := targetOf(previous)
+	if prev == nil {
+		return
+	}
+
+	for _, replica := range target.Replicas {
+		name := replica.String()
+		if b, ok := prev.breakers[name]; ok {
+			if target.breakers == nil {
+				target.breakers = make(map[string]*breaker)
+			}
+			target.breakers[name] = b
+		}
+		if since, ok := prev.driftSince[name]; ok {
+			if target.driftSince == nil {
+				target.driftSince = make(map[string]map[string]time.Time)
+			}
+			target.driftSince[name] = since
+		}
+	}
+}
+
+// targetOf returns the target behind t, or nil if t is another implementation, e.g. a mock.
+func targetOf(t Target) *target {
+	prev, _ := t.(*target)
+	return prev
+}
+
+func (target *target) sync(syncFunc func(run *pipelineRun) error, mode string, conf *config.Sync) (err error) {
+	target.failed = nil
+	target.breakerEvents = nil
+	var quarantined []pihole.Client
+	if conf.Breaker != nil && conf.Breaker.Enabled {
+		replicas := target.Replicas
+		target.Replicas = target.admit(time.Now())
+		quarantined = slices.DeleteFunc(slices.Clone(replicas), func(replica pihole.Client) bool {
+			return slices.Contains(target.Replicas, replica)
+		})
+		defer func() {
+			target.trackBreakers(conf.Breaker, target.Replicas, err, time.Now())
+			target.Replicas = replicas
+		}()
+	}
+
 	log.Info().Str("mode", mode).Int("replicas", len(target.Replicas)).Msg("Running sync")
 
 	defer func() {
 		if err != nil {
-			log.Error().Err(err).Msg("Error during sync")
+			log.Error().Err(err).Stringer("class", retry.Classify(err)).Msg("Error during sync")
 		}
+		logExcluded(target.results)
 		target.deleteSessions()
 	}()
 
+	target.results = nil
+	target.run = nil
+	if conf.Journal != nil && conf.Journal.Enabled {
+		start := time.Now()
+		defer func() {
+			target.run = target.record(mode, start, err)
+		}()
+	}
+
 	if err := target.authenticate(); err != nil {
 		return fmt.Errorf("authenticate: %w", err)
 	}
 
-	return syncFunc()
+	run := &pipelineRun{rolling: conf.Rolling, canary: conf.Canary, quarantined: quarantined}
+
+	if conf.Rollback == nil || !conf.Rollback.Enabled {
+		return syncFunc(run)
+	}
+
+	snapshots, err := target.takeSnapshots(conf.Rollback)
+	if err != nil {
+		return fmt.Errorf("snapshot replicas: %w", err)
+	}
+
+	if err := syncFunc(run); err != nil {
+		written := writtenSnapshots(snapshots, run.written)
+		if len(written) == 0 {
+			return err
+		}
+		if rollbackErr := target.rollback(conf.Rollback, run.configSettings, written); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		return fmt.Errorf("%w (rolled back)", err)
+	}
+
+	return nil
 }
 
 func (target *target) authenticate() (err error) {
@@ -51,10 +153,11 @@ func (target *target) authenticate() (err error) {
 		return err
 	}
 
-	for _, replica := range target.Replicas {
-		if err := retry.Fixed(func() error {
-			return replica.PostAuth()
-		}, retry.AttemptsPostAuth); err != nil {
+	for _, client := range append(slices.Clone(target.Replicas), target.Sources...) {
+		if err := target.retries.Do(retry.Auth, client, func() error {
+			return client.PostAuth()
+		}); err != nil {
+			target.failed = append(target.failed, client)
 			return err
 		}
 	}
@@ -68,119 +171,115 @@ func (target *target) deleteSessions() {
 		log.Warn().Msgf("Failed to invalidate session for target: %s", target.Primary.String())
 	}
 
-	for _, replica := range target.Replicas {
-		if err := retry.Fixed(func() error {
-			return replica.DeleteSession()
-		}, retry.AttemptsDeleteSession); err != nil {
-			log.Warn().Msgf("Failed to invalidate session for target: %s", replica.String())
+	for _, client := range append(slices.Clone(target.Replicas), target.Sources...) {
+		if err := target.retries.Do(retry.DeleteSession, client, func() error {
+			return client.DeleteSession()
+		}); err != nil {
+			log.Warn().Msgf("Failed to invalidate session for target: %s", client.String())
 		}
 	}
 }
 
-func (target *target) syncTeleporters(gravitySettings *config.GravitySettings) error {
-	log.Info().Msg("Syncing teleporters...")
-	conf, err := target.Primary.GetTeleporter()
-	if err != nil {
-		return err
-	}
-
-	var teleporterRequest *model.PostTeleporterRequest = nil
-	if gravitySettings != nil {
-		teleporterRequest = createPostTeleporterRequest(gravitySettings)
-	}
+// createPatchConfigRequest returns the request patching the sections of the primary's config that are synced,
+// filtered by their settings.
+func createPatchConfigRequest(config *config.ConfigSettings, configResponse *model.ConfigResponse) *model.PatchConfigRequest {
+	patchConfig := model.PatchConfig{}
 
-	for _, replica := range target.Replicas {
-		if err := retry.Fixed(func() error {
-			return replica.PostTeleporter(conf, teleporterRequest)
-		}, retry.AttemptsPostTeleporter); err != nil {
-			return err
+	for name, value := range configResponse.Config {
+		section, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if json := filterPatchConfigRequest(name, config.Setting(name), section); json != nil {
+			patchConfig[name] = json
 		}
 	}
 
-	return err
+	return &model.PatchConfigRequest{Config: patchConfig}
 }
 
-func (target *target) syncConfigs(configSettings *config.ConfigSettings) error {
-	log.Info().Msg("Syncing configs...")
-	configResponse, err := target.Primary.GetConfig()
-	if err != nil {
-		return err
+// rewritePatchConfigRequest returns a copy of the request with the rewrite rules that apply to the replica
+// applied, and the values they changed.
+func rewritePatchConfigRequest(request *model.PatchConfigRequest, rules []*filter.Rewrite, replica pihole.Client) (*model.PatchConfigRequest, []filter.Rewritten) {
+	if len(rules) == 0 {
+		return request, nil
 	}
 
-	configRequest := createPatchConfigRequest(configSettings, configResponse)
-
-	for _, replica := range target.Replicas {
-		if err := retry.Fixed(func() error {
-			return replica.PatchConfig(configRequest)
-		}, retry.AttemptsPatchConfig); err != nil {
-			return err
+	var applicable []*filter.Rewrite
+	for _, rule := range rules {
+		if rule.AppliesTo(replica.String()) {
+			applicable = append(applicable, rule)
 		}
 	}
+	if len(applicable) == 0 {
+		return request, nil
+	}
 
-	return err
-}
+	patchConfig := make(model.PatchConfig, len(request.Config))
+	var rewritten []filter.Rewritten
+	for name, section := range request.Config {
+		var changed []filter.Rewritten
+		patchConfig[name], changed = filter.Apply(applicable, name, section)
+		rewritten = append(rewritten, changed...)
+	}
 
-func (target *target) runGravity() error {
-	log.Info().Msg("Running gravity...")
+	return &model.PatchConfigRequest{Config: patchConfig}, rewritten
+}
 
-	if err := target.Primary.PostRunGravity(); err != nil {
-		return err
+// selectPatchConfigElements returns a copy of the request where the arrays filtered by the element filters only
+// hold their selected elements. It runs on the primary's values, before any rewrite, so that the element filters
+// match the primary's config.
+func selectPatchConfigElements(request *model.PatchConfigRequest, filters []*filter.ElementFilter) *model.PatchConfigRequest {
+	if len(filters) == 0 {
+		return request
 	}
 
-	for _, replica := range target.Replicas {
-		if err := retry.Fixed(func() error {
-			return replica.PostRunGravity()
-		}, retry.AttemptsPostRunGravity); err != nil {
-			return err
-		}
+	patchConfig := make(model.PatchConfig, len(request.Config))
+	for name, section := range request.Config {
+		patchConfig[name] = filter.SelectElements(filters, name, section)
 	}
 
-	return nil
+	return &model.PatchConfigRequest{Config: patchConfig}
 }
 
-func createPatchConfigRequest(config *config.ConfigSettings, configResponse *model.ConfigResponse) *model.PatchConfigRequest {
-	patchConfig := model.PatchConfig{}
-
-	if json := filterPatchConfigRequest(config.DNS, configResponse.Get("dns")); json != nil {
-		patchConfig.DNS = json
-	}
-	if json := filterPatchConfigRequest(config.DHCP, configResponse.Get("dhcp")); json != nil {
-		patchConfig.DHCP = json
+// mergePatchConfigElements returns a copy of the request where the arrays filtered by the element filters hold
+// the replica's current elements that are not selected, and the elements of the request, which are expected to
+// be selected by selectPatchConfigElements.
+func mergePatchConfigElements(request *model.PatchConfigRequest, filters []*filter.ElementFilter, current *model.ConfigResponse) *model.PatchConfigRequest {
+	if len(filters) == 0 || current == nil {
+		return request
 	}
-	if json := filterPatchConfigRequest(config.NTP, configResponse.Get("ntp")); json != nil {
-		patchConfig.NTP = json
-	}
-	if json := filterPatchConfigRequest(config.Resolver, configResponse.Get("resolver")); json != nil {
-		patchConfig.Resolver = json
-	}
-	if json := filterPatchConfigRequest(config.Database, configResponse.Get("database")); json != nil {
-		patchConfig.Database = json
-	}
-	if json := filterPatchConfigRequest(config.Misc, configResponse.Get("misc")); json != nil {
-		patchConfig.Misc = json
-	}
-	if json := filterPatchConfigRequest(config.Debug, configResponse.Get("debug")); json != nil {
-		patchConfig.Debug = json
+
+	patchConfig := make(model.PatchConfig, len(request.Config))
+	for name, section := range request.Config {
+		currentSection, _ := current.Config[name].(map[string]interface{})
+		patchConfig[name] = filter.MergeElements(filters, name, section, currentSection)
 	}
 
 	return &model.PatchConfigRequest{Config: patchConfig}
 }
 
-func filterPatchConfigRequest(setting *config.ConfigSetting, json map[string]interface{}) map[string]interface{} {
+func filterPatchConfigRequest(section string, setting *config.ConfigSetting, json map[string]interface{}) map[string]interface{} {
 	if !setting.Enabled {
 		return nil
 	}
 
+	var rules filter.Rules
 	if setting.Filter != nil {
-		filteredJson, err := filter.ByType(setting.Filter.Type, setting.Filter.Keys, json)
-		if err != nil {
+		var err error
+		if rules, err = setting.Filter.Rules(); err != nil {
 			log.Warn().Err(err).Msg("Unable to filter json object")
 			return nil
 		}
-		return filteredJson
+		json = rules.Apply(json)
 	}
 
-	return json
+	return protectConfig(section, rules, json)
+}
+
+// importsLists reports whether the teleporter import touches the domain or adlist tables.
+func importsLists(gravity *config.GravitySettings) bool {
+	return gravity == nil || gravity.Adlist || gravity.AdlistByGroup || gravity.Domainlist || gravity.DomainlistByGroup
 }
 
 func createPostTeleporterRequest(gravity *config.GravitySettings) *model.PostTeleporterRequest {
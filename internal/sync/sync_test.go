@@ -7,7 +7,11 @@ import (
 	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
 	"github.com/lovelaze/nebula-sync/internal/pihole"
 	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync/backup"
+	"github.com/lovelaze/nebula-sync/internal/sync/filter"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_target_authenticate(t *testing.T) {
@@ -82,10 +86,29 @@ func Test_target_syncTeleporters(t *testing.T) {
 	primary.EXPECT().GetTeleporter().Once().Return([]byte{}, nil)
 	replica.EXPECT().PostTeleporter([]byte{}, createPostTeleporterRequest(&gravitySettings)).Once().Return(nil)
 
-	err := target.syncTeleporters(&gravitySettings)
+	err := target.runPipeline(&pipelineRun{}, newTeleporterStep(&gravitySettings, nil, nil))
 	assert.NoError(t, err)
 }
 
+func Test_teleporterStep_backup(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+	target := target{Primary: primary, Replicas: []pihole.Client{replica}}
+
+	primary.EXPECT().GetTeleporter().Twice().Return([]byte("zip"), nil)
+	replica.EXPECT().PostTeleporter([]byte("zip"), mock.Anything).Twice().Return(nil)
+
+	backups := backup.NewStore(t.TempDir(), backup.Retention{})
+	step := newTeleporterStep(nil, nil, backups)
+	require.NoError(t, target.runPipeline(&pipelineRun{}, step))
+	require.NoError(t, target.runPipeline(&pipelineRun{}, step))
+
+	saved, err := backups.List()
+	require.NoError(t, err)
+	require.Len(t, saved, 1)
+	assert.Equal(t, backup.Fingerprint([]byte("zip")), saved[0].Fingerprint)
+}
+
 func Test_target_syncConfigs(t *testing.T) {
 	primary := piholemock.NewClient(t)
 	replica := piholemock.NewClient(t)
@@ -104,24 +127,102 @@ func Test_target_syncConfigs(t *testing.T) {
 	configResponse := emptyConfigResponse()
 
 	gravitySettings := config.ConfigSettings{
-		DNS:       config.NewConfigSetting(false, nil, nil),
-		DHCP:      config.NewConfigSetting(false, nil, nil),
-		NTP:       config.NewConfigSetting(false, nil, nil),
-		Resolver:  config.NewConfigSetting(false, nil, nil),
-		Database:  config.NewConfigSetting(false, nil, nil),
-		Webserver: config.NewConfigSetting(false, nil, nil),
-		Files:     config.NewConfigSetting(false, nil, nil),
-		Misc:      config.NewConfigSetting(false, nil, nil),
-		Debug:     config.NewConfigSetting(false, nil, nil),
+		Default: config.NewConfigSetting(false, nil, nil),
 	}
 
 	primary.EXPECT().GetConfig().Once().Return(configResponse, nil)
 	replica.EXPECT().PatchConfig(createPatchConfigRequest(&gravitySettings, configResponse)).Once().Return(nil)
 
-	err := target.syncConfigs(&gravitySettings)
+	err := target.runPipeline(&pipelineRun{}, newConfigStep(&gravitySettings, nil, false))
 	assert.NoError(t, err)
 }
 
+func Test_configStep_rewrites(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	site2 := piholemock.NewClient(t)
+	site3 := piholemock.NewClient(t)
+	site2.EXPECT().String().Return("http://ph2.site2.lan")
+	site3.EXPECT().String().Return("http://ph3.site3.lan")
+
+	target := target{Primary: primary, Replicas: []pihole.Client{site2, site3}}
+
+	primary.EXPECT().GetConfig().Once().Return(&model.ConfigResponse{Config: map[string]interface{}{
+		"dns":  map[string]interface{}{"hosts": []interface{}{"192.168.1.10 nas.lan"}},
+		"dhcp": map[string]interface{}{"router": "192.168.1.1"},
+	}}, nil)
+
+	var rewrites []*filter.Rewrite
+	for _, rule := range []string{"dns.hosts=cidr:192.168.1.0/24>10.0.2.0/24", "site3@dhcp.router=literal:192.168.1.1>10.0.3.1"} {
+		rewrite, err := filter.ParseRewrite(rule)
+		require.NoError(t, err)
+		rewrites = append(rewrites, rewrite)
+	}
+
+	site2.EXPECT().PatchConfig(mock.MatchedBy(func(request *model.PatchConfigRequest) bool {
+		return request.Config["dns"]["hosts"].([]interface{})[0] == "10.0.2.10 nas.lan" && request.Config["dhcp"]["router"] == "192.168.1.1"
+	})).Once().Return(nil)
+	site3.EXPECT().PatchConfig(mock.MatchedBy(func(request *model.PatchConfigRequest) bool {
+		return request.Config["dns"]["hosts"].([]interface{})[0] == "10.0.2.10 nas.lan" && request.Config["dhcp"]["router"] == "10.0.3.1"
+	})).Once().Return(nil)
+
+	configSettings := newFullSyncConfigSettings()
+	err := target.runPipeline(&pipelineRun{}, newConfigStep(configSettings, rewrites, false))
+	require.NoError(t, err)
+}
+
+func Test_configStep_elements(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+
+	target := target{Primary: primary, Replicas: []pihole.Client{replica}}
+
+	primary.EXPECT().GetConfig().Once().Return(&model.ConfigResponse{Config: map[string]interface{}{
+		"dns": map[string]interface{}{"hosts": []interface{}{"192.168.1.10 nas.lan", "192.168.1.20 printer.home"}},
+	}}, nil)
+	replica.EXPECT().GetConfig().Once().Return(&model.ConfigResponse{Config: map[string]interface{}{
+		"dns": map[string]interface{}{"hosts": []interface{}{"10.0.0.5 own.home", "10.0.0.6 old.lan"}},
+	}}, nil)
+	replica.EXPECT().PatchConfig(&model.PatchConfigRequest{Config: model.PatchConfig{
+		"dns": {"hosts": []interface{}{"10.0.0.5 own.home", "192.168.1.10 nas.lan"}},
+	}}).Once().Return(nil)
+
+	ef, err := filter.ParseElementFilter("dns.hosts=host:*.lan")
+	require.NoError(t, err)
+
+	configSettings := newFullSyncConfigSettings()
+	configSettings.Elements = []*filter.ElementFilter{ef}
+	err = target.runPipeline(&pipelineRun{}, newConfigStep(configSettings, nil, false))
+	require.NoError(t, err)
+}
+
+func Test_configStep_elementsBeforeRewrites(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+	replica.EXPECT().String().Return("http://ph2.site2.lan")
+
+	target := target{Primary: primary, Replicas: []pihole.Client{replica}}
+
+	primary.EXPECT().GetConfig().Once().Return(&model.ConfigResponse{Config: map[string]interface{}{
+		"dns": map[string]interface{}{"hosts": []interface{}{"192.168.1.10 nas.lan", "192.168.2.20 guest.lan"}},
+	}}, nil)
+	replica.EXPECT().GetConfig().Once().Return(&model.ConfigResponse{Config: map[string]interface{}{
+		"dns": map[string]interface{}{"hosts": []interface{}{"10.0.2.5 own.lan"}},
+	}}, nil)
+	replica.EXPECT().PatchConfig(&model.PatchConfigRequest{Config: model.PatchConfig{
+		"dns": {"hosts": []interface{}{"10.0.2.5 own.lan", "10.0.2.10 nas.lan"}},
+	}}).Once().Return(nil)
+
+	ef, err := filter.ParseElementFilter("dns.hosts=ip:192.168.1.0/24")
+	require.NoError(t, err)
+	rewrite, err := filter.ParseRewrite("dns.hosts=cidr:192.168.1.0/24>10.0.2.0/24")
+	require.NoError(t, err)
+
+	configSettings := newFullSyncConfigSettings()
+	configSettings.Elements = []*filter.ElementFilter{ef}
+	err = target.runPipeline(&pipelineRun{}, newConfigStep(configSettings, []*filter.Rewrite{rewrite}, false))
+	require.NoError(t, err)
+}
+
 func Test_target_runGravity(t *testing.T) {
 	primary := piholemock.NewClient(t)
 	replica := piholemock.NewClient(t)
@@ -140,14 +241,14 @@ func Test_target_runGravity(t *testing.T) {
 	primary.EXPECT().PostRunGravity().Once().Return(nil)
 	replica.EXPECT().PostRunGravity().Once().Return(nil)
 
-	err := target.runGravity()
+	err := target.runPipeline(&pipelineRun{}, newGravityStep(true, nil))
 	assert.NoError(t, err)
 }
 
 func Test_filterPatchConfigRequest_enabled(t *testing.T) {
 	dns := emptyConfigResponse().Get("dns")
 
-	request := filterPatchConfigRequest(&config.ConfigSetting{
+	request := filterPatchConfigRequest("dns", &config.ConfigSetting{
 		Enabled: true,
 		Filter:  nil,
 	}, dns)
@@ -157,7 +258,7 @@ func Test_filterPatchConfigRequest_enabled(t *testing.T) {
 func Test_filterPatchConfigRequest_disabled(t *testing.T) {
 	dns := emptyConfigResponse().Get("dns")
 
-	request := filterPatchConfigRequest(&config.ConfigSetting{
+	request := filterPatchConfigRequest("dns", &config.ConfigSetting{
 		Enabled: false,
 		Filter:  nil,
 	}, dns)
@@ -0,0 +1,326 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync/filter"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrInvalidConfigKeys = errors.New("invalid config keys")
+
+// KeyIssue describes a configured config key that does not match the primary's config.
+type KeyIssue struct {
+	Setting    string
+	Key        string
+	Reason     string
+	Suggestion string
+}
+
+func (issue KeyIssue) String() string {
+	s := fmt.Sprintf("%s: %s: %s", issue.Setting, issue.Key, issue.Reason)
+	if issue.Suggestion != "" {
+		s += fmt.Sprintf(", did you mean %s?", issue.Suggestion)
+	}
+	return s
+}
+
+type valueKind int
+
+const (
+	anyValue valueKind = iota
+	stringValue
+	arrayValue
+)
+
+// keySelector is a config key selector of a setting. Section is empty for selectors of full config keys.
+// Keys matched by a synced selector are synced if the section's rules keep them, or there are none.
+type keySelector struct {
+	setting  string
+	section  string
+	selector *filter.Selector
+	rules    filter.Rules
+	synced   bool
+	kind     valueKind
+}
+
+// ValidateConfig checks the configured config filter, rewrite and element filter keys against the primary's
+// config schema. It logs the keys that do not exist or have the wrong type, with close matches, and the synced
+// keys that restart FTL when changed. With SYNC_CONFIG_VALIDATE_STRICT it returns an error for invalid keys, or
+// if the schema cannot be read.
+func (target *target) ValidateConfig(conf *config.Sync) error {
+	selectors, err := keySelectors(conf)
+	if err != nil {
+		return err
+	}
+	if len(selectors) == 0 {
+		return nil
+	}
+
+	strict := conf.Validation != nil && conf.Validation.Strict
+	log.Info().Int("keys", len(selectors)).Msg("Validating config keys...")
+
+	schema, err := configSchema(target.Primary)
+	if err != nil {
+		if strict {
+			return fmt.Errorf("config schema: %w", err)
+		}
+		log.Warn().Err(err).Msg("Unable to validate config keys")
+		return nil
+	}
+
+	issues, restarts := validateKeys(selectors, schema.Keys())
+	for _, key := range restarts {
+		log.Info().Str("key", key).Msg("Synced config key restarts FTL when changed")
+	}
+	for _, issue := range issues {
+		log.Warn().
+			Str("setting", issue.Setting).
+			Str("key", issue.Key).
+			Str("reason", issue.Reason).
+			Str("suggestion", issue.Suggestion).
+			Msg("Invalid config key")
+	}
+
+	if strict && len(issues) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfigKeys, issues[0])
+	}
+	return nil
+}
+
+func configSchema(primary pihole.Client) (*model.ConfigSchemaResponse, error) {
+	if err := primary.PostAuth(); err != nil {
+		return nil, fmt.Errorf("authenticate: %w", err)
+	}
+
+	defer func() {
+		if err := primary.DeleteSession(); err != nil {
+			log.Warn().Msgf("Failed to invalidate session for target: %s", primary.String())
+		}
+	}()
+
+	return primary.GetConfigSchema()
+}
+
+func keySelectors(conf *config.Sync) ([]keySelector, error) {
+	var selectors []keySelector
+	add := func(setting, section string, rules filter.Rules, key string, synced bool, kind valueKind) error {
+		selector, err := filter.ParseSelector(key)
+		if err != nil {
+			return fmt.Errorf("%s: %w", setting, err)
+		}
+		selectors = append(selectors, keySelector{setting: setting, section: section, selector: selector, rules: rules, synced: synced, kind: kind})
+		return nil
+	}
+
+	if settings := conf.ConfigSettings; settings != nil && !conf.FullSync {
+		names := make([]string, 0, len(settings.Sections))
+		for name := range settings.Sections {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			setting := settings.Sections[name]
+			if setting == nil || !setting.Enabled {
+				continue
+			}
+
+			prefix := "SYNC_CONFIG_" + strings.ToUpper(name)
+			if err := add(prefix, "", nil, name, false, anyValue); err != nil {
+				return nil, err
+			}
+			if setting.Filter == nil {
+				continue
+			}
+
+			rules, err := setting.Filter.Rules()
+			if err != nil {
+				return nil, err
+			}
+
+			for _, key := range setting.Filter.Include {
+				key, negated := strings.CutPrefix(strings.TrimSpace(key), "!")
+				if err := add(prefix+"_INCLUDE", name, rules, key, !negated, anyValue); err != nil {
+					return nil, err
+				}
+			}
+			for _, key := range setting.Filter.Exclude {
+				key, negated := strings.CutPrefix(strings.TrimSpace(key), "!")
+				if err := add(prefix+"_EXCLUDE", name, rules, key, negated, anyValue); err != nil {
+					return nil, err
+				}
+			}
+			if setting.Filter.Ordered == nil {
+				continue
+			}
+			for _, rule := range rules {
+				if err := add(prefix+"_RULES", name, rules, rule.Selector.String(), rule.Type == filter.Include, anyValue); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for _, ef := range settings.Elements {
+			if err := add("SYNC_CONFIG_ELEMENTS", "", nil, ef.Path, !ef.Drop, arrayValue); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, rewrite := range conf.Rewrites {
+		if err := add("SYNC_CONFIG_REWRITE", "", nil, rewrite.Path, true, stringValue); err != nil {
+			return nil, err
+		}
+	}
+
+	return selectors, nil
+}
+
+// validateKeys returns the issues of the selectors, and the sorted config keys selected for syncing that
+// restart FTL when changed.
+func validateKeys(selectors []keySelector, keys map[string]model.ConfigKey) ([]KeyIssue, []string) {
+	var issues []KeyIssue
+	var restarts []string
+
+	for _, s := range selectors {
+		var matched []string
+		for path := range keys {
+			if s.matches(path) {
+				matched = append(matched, path)
+			}
+		}
+
+		if len(matched) == 0 {
+			issues = append(issues, KeyIssue{
+				Setting:    s.setting,
+				Key:        s.selector.String(),
+				Reason:     "no such key",
+				Suggestion: s.suggest(keys),
+			})
+			continue
+		}
+		slices.Sort(matched)
+
+		if !slices.ContainsFunc(matched, func(path string) bool { return s.kind.accepts(keys[path].Value) }) {
+			issues = append(issues, KeyIssue{
+				Setting: s.setting,
+				Key:     s.selector.String(),
+				Reason:  fmt.Sprintf("expected %s value, got %s", s.kind, keys[matched[0]].Type),
+			})
+			continue
+		}
+
+		if s.synced {
+			for _, path := range matched {
+				if keys[path].Restart && s.keeps(path) && !slices.Contains(restarts, path) {
+					restarts = append(restarts, path)
+				}
+			}
+		}
+	}
+
+	slices.Sort(restarts)
+	return issues, restarts
+}
+
+func (s keySelector) relative(path string) (string, bool) {
+	if s.section == "" {
+		return path, true
+	}
+	return strings.CutPrefix(path, s.section+".")
+}
+
+func (s keySelector) keeps(path string) bool {
+	relative, _ := s.relative(path)
+	return s.rules == nil || s.rules.Keeps(strings.Split(relative, "."))
+}
+
+func (s keySelector) matches(path string) bool {
+	relative, ok := s.relative(path)
+	return ok && s.selector.Matches(strings.Split(relative, "."))
+}
+
+// suggest returns the closest config key, or parent key, to a plain selector that matches nothing.
+func (s keySelector) suggest(keys map[string]model.ConfigKey) string {
+	key := s.selector.String()
+	if strings.ContainsAny(key, "*?[/") {
+		return ""
+	}
+
+	limit := len(key)/3 + 1
+	suggestion, best := "", limit
+	for path := range keys {
+		relative, ok := s.relative(path)
+		if !ok {
+			continue
+		}
+
+		segments := strings.Split(relative, ".")
+		for i := range segments {
+			candidate := strings.Join(segments[:i+1], ".")
+			d := distance(strings.ToLower(key), strings.ToLower(candidate))
+			if d < best || d == best && d < limit && candidate < suggestion {
+				suggestion, best = candidate, d
+			}
+		}
+	}
+	return suggestion
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func (kind valueKind) String() string {
+	var s string
+	switch kind {
+	case anyValue:
+		s = "any"
+	case stringValue:
+		s = "a string or array"
+	case arrayValue:
+		s = "an array"
+	}
+	return s
+}
+
+func (kind valueKind) accepts(value interface{}) bool {
+	switch kind {
+	case stringValue:
+		switch value.(type) {
+		case string, []interface{}:
+			return true
+		}
+		return false
+	case arrayValue:
+		_, ok := value.([]interface{})
+		return ok
+	default:
+		return true
+	}
+}
@@ -0,0 +1,159 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync/filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const schemaJson = `{"config": {
+	"dns": {
+		"upstreams": {"type": "string array", "value": ["1.1.1.1"], "flags": {"restart_dnsmasq": true}},
+		"hosts": {"type": "string array", "value": [], "flags": {"restart_dnsmasq": false}},
+		"port": {"type": "unsigned integer (16 bit)", "value": 53, "flags": {"restart_dnsmasq": true}},
+		"reply": {"host": {"IPv4": {"type": "IPv4 address", "value": "", "flags": {"restart_dnsmasq": false}}}}
+	},
+	"dhcp": {
+		"router": {"type": "IPv4 address", "value": "", "flags": {"restart_dnsmasq": true}}
+	}
+}}`
+
+func loadSchema(t *testing.T) *model.ConfigSchemaResponse {
+	var schema model.ConfigSchemaResponse
+	require.NoError(t, json.Unmarshal([]byte(schemaJson), &schema))
+	return &schema
+}
+
+func validationConf(t *testing.T, strict bool) *config.Sync {
+	port, err := filter.ParseRewrite("dns.port=literal:53>5353")
+	require.NoError(t, err)
+	ef, err := filter.ParseElementFilter("dhcp.router=host:*.lan")
+	require.NoError(t, err)
+
+	settings := newFullSyncConfigSettings()
+	settings.Sections["dns"] = config.NewConfigSetting(true, []string{"upstream", "reply.**", "Port"}, []string{"!hosts"})
+	settings.Sections["dhcp"] = config.NewConfigSetting(true, nil, []string{"router"})
+	settings.Sections["ntp"] = config.NewConfigSetting(false, []string{"ignored"}, nil)
+	settings.Elements = []*filter.ElementFilter{ef}
+
+	return &config.Sync{
+		ConfigSettings: settings,
+		Rewrites:       config.RewriteRules{port},
+		Validation:     &config.ValidationSettings{Enabled: true, Strict: strict},
+	}
+}
+
+func TestConfigSchemaResponse_Keys(t *testing.T) {
+	keys := loadSchema(t).Keys()
+
+	assert.Len(t, keys, 5)
+	assert.Equal(t, model.ConfigKey{Type: "string array", Value: []interface{}{"1.1.1.1"}, Restart: true}, keys["dns.upstreams"])
+	assert.Equal(t, "IPv4 address", keys["dns.reply.host.IPv4"].Type)
+}
+
+func Test_validateKeys(t *testing.T) {
+	selectors, err := keySelectors(validationConf(t, false))
+	require.NoError(t, err)
+	require.Len(t, selectors, 9)
+
+	issues, restarts := validateKeys(selectors, loadSchema(t).Keys())
+	assert.Equal(t, []KeyIssue{
+		{Setting: "SYNC_CONFIG_DNS_INCLUDE", Key: "upstream", Reason: "no such key", Suggestion: "upstreams"},
+		{Setting: "SYNC_CONFIG_DNS_INCLUDE", Key: "Port", Reason: "no such key", Suggestion: "port"},
+		{Setting: "SYNC_CONFIG_ELEMENTS", Key: "dhcp.router", Reason: "expected an array value, got IPv4 address"},
+		{Setting: "SYNC_CONFIG_REWRITE", Key: "dns.port", Reason: "expected a string or array value, got unsigned integer (16 bit)"},
+	}, issues)
+	assert.Empty(t, restarts, "excluded keys are not flagged")
+	assert.Equal(t, "SYNC_CONFIG_DNS_INCLUDE: upstream: no such key, did you mean upstreams?", issues[0].String())
+}
+
+func Test_validateKeys_restarts(t *testing.T) {
+	conf := &config.Sync{ConfigSettings: newFullSyncConfigSettings()}
+	conf.ConfigSettings.Sections["dns"] = config.NewConfigSetting(true, []string{"upstreams", "port", "!port"}, nil)
+	conf.ConfigSettings.Sections["dhcp"] = config.NewConfigSetting(true, []string{"router"}, nil)
+
+	selectors, err := keySelectors(conf)
+	require.NoError(t, err)
+
+	issues, restarts := validateKeys(selectors, loadSchema(t).Keys())
+	assert.Empty(t, issues)
+	assert.Equal(t, []string{"dhcp.router", "dns.upstreams"}, restarts)
+}
+
+func Test_validateKeys_rules(t *testing.T) {
+	conf := &config.Sync{ConfigSettings: newFullSyncConfigSettings()}
+	conf.ConfigSettings.Sections["dns"] = &config.ConfigSetting{
+		Enabled: true,
+		Filter:  &config.ConfigFilter{Ordered: []string{"+upstreams", "-port", "+hots"}},
+	}
+
+	selectors, err := keySelectors(conf)
+	require.NoError(t, err)
+
+	issues, restarts := validateKeys(selectors, loadSchema(t).Keys())
+	assert.Equal(t, []KeyIssue{
+		{Setting: "SYNC_CONFIG_DNS_RULES", Key: "hots", Reason: "no such key", Suggestion: "hosts"},
+	}, issues)
+	assert.Equal(t, []string{"dns.upstreams"}, restarts)
+}
+
+func Test_validateKeys_unknownSection(t *testing.T) {
+	conf := &config.Sync{ConfigSettings: &config.ConfigSettings{Sections: map[string]*config.ConfigSetting{
+		"dhc":       config.NewConfigSetting(true, nil, nil),
+		"webserver": config.NewConfigSetting(false, nil, nil),
+	}}}
+
+	selectors, err := keySelectors(conf)
+	require.NoError(t, err)
+
+	issues, _ := validateKeys(selectors, loadSchema(t).Keys())
+	assert.Equal(t, []KeyIssue{
+		{Setting: "SYNC_CONFIG_DHC", Key: "dhc", Reason: "no such key", Suggestion: "dhcp"},
+	}, issues)
+}
+
+func Test_keySelectors_fullSync(t *testing.T) {
+	conf := validationConf(t, false)
+	conf.FullSync = true
+
+	selectors, err := keySelectors(conf)
+	require.NoError(t, err)
+	assert.Len(t, selectors, 1, "only rewrites apply to full sync")
+}
+
+func TestTarget_ValidateConfig(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	target := NewTarget(primary, nil, nil)
+
+	primary.EXPECT().PostAuth().Return(nil)
+	primary.EXPECT().DeleteSession().Return(nil)
+	primary.EXPECT().GetConfigSchema().Return(loadSchema(t), nil)
+
+	assert.NoError(t, target.ValidateConfig(validationConf(t, false)))
+	assert.ErrorIs(t, target.ValidateConfig(validationConf(t, true)), ErrInvalidConfigKeys)
+}
+
+func TestTarget_ValidateConfig_schemaError(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	target := NewTarget(primary, nil, nil)
+
+	primary.EXPECT().PostAuth().Return(nil)
+	primary.EXPECT().DeleteSession().Return(nil)
+	primary.EXPECT().GetConfigSchema().Return(nil, errors.New("not found"))
+
+	assert.NoError(t, target.ValidateConfig(validationConf(t, false)))
+	assert.Error(t, target.ValidateConfig(validationConf(t, true)))
+}
+
+func TestTarget_ValidateConfig_noKeys(t *testing.T) {
+	target := NewTarget(piholemock.NewClient(t), nil, nil)
+
+	assert.NoError(t, target.ValidateConfig(&config.Sync{ConfigSettings: newFullSyncConfigSettings()}))
+}
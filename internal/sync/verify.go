@@ -0,0 +1,121 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/lovelaze/nebula-sync/internal/sync/exclude"
+	"github.com/lovelaze/nebula-sync/internal/sync/filter"
+)
+
+var ErrDiverged = errors.New("replica diverged")
+
+type Divergence struct {
+	Key      string
+	Expected interface{}
+	Actual   interface{}
+	Since    time.Time
+}
+
+func (d Divergence) String() string {
+	return fmt.Sprintf("%s: expected %v, got %v", d.Key, d.Expected, d.Actual)
+}
+
+type Report struct {
+	Replica     string
+	Divergences []Divergence
+}
+
+func compareConfig(expected *model.PatchConfigRequest, actual *model.ConfigResponse) []Divergence {
+	var divergences []Divergence
+
+	for section, expectedSection := range expected.Config {
+		if expectedSection == nil {
+			continue
+		}
+
+		actualSection, _ := actual.Config[section].(map[string]interface{})
+		actualValues := filter.Flatten(actualSection)
+
+		for key, expectedValue := range filter.Flatten(expectedSection) {
+			actualValue, exists := actualValues[key]
+			if !exists || !reflect.DeepEqual(expectedValue, actualValue) {
+				divergences = append(divergences, Divergence{
+					Key:      section + "." + key,
+					Expected: expectedValue,
+					Actual:   actualValue,
+				})
+			}
+		}
+	}
+
+	sort.Slice(divergences, func(i, j int) bool {
+		return divergences[i].Key < divergences[j].Key
+	})
+
+	return divergences
+}
+
+func compareCounts(expected, actual map[string]int) []Divergence {
+	var divergences []Divergence
+
+	for key, expectedCount := range expected {
+		if actualCount := actual[key]; actualCount != expectedCount {
+			divergences = append(divergences, Divergence{
+				Key:      key,
+				Expected: expectedCount,
+				Actual:   actualCount,
+			})
+		}
+	}
+
+	sort.Slice(divergences, func(i, j int) bool {
+		return divergences[i].Key < divergences[j].Key
+	})
+
+	return divergences
+}
+
+func gravityCounts(client pihole.Client, gravitySettings *config.GravitySettings, rules *exclude.Rules) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	if gravitySettings.Group {
+		groups, err := client.GetGroups()
+		if err != nil {
+			return nil, err
+		}
+		counts["gravity.groups"] = len(groups)
+	}
+
+	if gravitySettings.Adlist {
+		lists, err := getLists(client, rules)
+		if err != nil {
+			return nil, err
+		}
+		counts["gravity.lists"] = len(lists)
+	}
+
+	if gravitySettings.Domainlist {
+		domains, err := getDomains(client, rules)
+		if err != nil {
+			return nil, err
+		}
+		counts["gravity.domains"] = len(domains)
+	}
+
+	if gravitySettings.Client {
+		clients, err := client.GetClients()
+		if err != nil {
+			return nil, err
+		}
+		counts["gravity.clients"] = len(clients)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/lovelaze/nebula-sync/internal/config"
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_compareConfig(t *testing.T) {
+	expected := &model.PatchConfigRequest{Config: model.PatchConfig{
+		"dns": {
+			"upstreams": []interface{}{"1.1.1.1"},
+			"cache":     map[string]interface{}{"size": 10000.0},
+		},
+	}}
+	actual := &model.ConfigResponse{Config: map[string]interface{}{
+		"dns": map[string]interface{}{
+			"upstreams": []interface{}{"8.8.8.8"},
+			"cache":     map[string]interface{}{"size": 10000.0},
+			"port":      53.0,
+		},
+	}}
+
+	divergences := compareConfig(expected, actual)
+
+	assert.Equal(t, []Divergence{{
+		Key:      "dns.upstreams",
+		Expected: []interface{}{"1.1.1.1"},
+		Actual:   []interface{}{"8.8.8.8"},
+	}}, divergences)
+}
+
+func Test_compareConfig_missingSection(t *testing.T) {
+	expected := &model.PatchConfigRequest{Config: model.PatchConfig{
+		"ntp": {"sync": true},
+	}}
+	actual := &model.ConfigResponse{Config: map[string]interface{}{}}
+
+	divergences := compareConfig(expected, actual)
+
+	require.Len(t, divergences, 1)
+	assert.Equal(t, "ntp.sync", divergences[0].Key)
+	assert.Nil(t, divergences[0].Actual)
+}
+
+func Test_compareCounts(t *testing.T) {
+	divergences := compareCounts(
+		map[string]int{"gravity.groups": 2, "gravity.lists": 3},
+		map[string]int{"gravity.groups": 2, "gravity.lists": 1},
+	)
+
+	assert.Equal(t, []Divergence{{Key: "gravity.lists", Expected: 3, Actual: 1}}, divergences)
+}
+
+func Test_target_verify_strict(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	replica := piholemock.NewClient(t)
+
+	target := target{
+		Primary:  primary,
+		Replicas: []pihole.Client{replica},
+	}
+
+	configSettings := newFullSyncConfigSettings()
+	gravitySettings := &config.GravitySettings{Group: true}
+
+	primary.EXPECT().GetConfig().Return(emptyConfigResponse(), nil)
+	primary.EXPECT().GetGroups().Return([]model.Group{{Name: "Default"}, {Name: "Kids"}}, nil)
+	replica.EXPECT().GetConfig().Return(emptyConfigResponse(), nil)
+	replica.EXPECT().GetGroups().Return([]model.Group{{Name: "Default"}}, nil)
+	replica.EXPECT().String().Return("http://replica")
+
+	err := target.runPipeline(&pipelineRun{}, newVerifyStep(true, gravitySettings, configSettings, nil, nil, false))
+	assert.NoError(t, err)
+
+	err = target.runPipeline(&pipelineRun{}, newVerifyStep(true, gravitySettings, configSettings, nil, nil, true))
+	assert.ErrorIs(t, err, ErrDiverged)
+}
@@ -0,0 +1,115 @@
+package sync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lovelaze/nebula-sync/internal/pihole"
+	"github.com/rs/zerolog/log"
+)
+
+// Fingerprinter fingerprints the primary for watch mode.
+type Fingerprinter interface {
+	// Fingerprint summarizes the primary's config and gravity database so changes can be detected without a
+	// full sync. Gravity tables are represented by their entity count and latest modification time.
+	Fingerprint() (string, error)
+	// Close invalidates the session of the fingerprinter.
+	Close()
+}
+
+type fingerprinter struct {
+	primary       pihole.Client
+	authenticated bool
+}
+
+// NewFingerprinter returns a fingerprinter polling the primary through its own client, so that it never
+// interferes with the session of a running sync. It keeps its session across polls until Close.
+func NewFingerprinter(primary pihole.Client) Fingerprinter {
+	return &fingerprinter{primary: primary}
+}
+
+func (f *fingerprinter) Fingerprint() (string, error) {
+	if f.authenticated {
+		fingerprint, err := fingerprintPrimary(f.primary)
+		if err == nil {
+			return fingerprint, nil
+		}
+		// the session may have expired, authenticate again
+		log.Debug().Err(err).Msg("Failed to fingerprint primary, authenticating again")
+		f.authenticated = false
+	}
+
+	if err := f.primary.PostAuth(); err != nil {
+		return "", fmt.Errorf("authenticate: %w", err)
+	}
+	f.authenticated = true
+
+	return fingerprintPrimary(f.primary)
+}
+
+func (f *fingerprinter) Close() {
+	if !f.authenticated {
+		return
+	}
+	f.authenticated = false
+
+	if err := f.primary.DeleteSession(); err != nil {
+		log.Warn().Msgf("Failed to invalidate session for target: %s", f.primary.String())
+	}
+}
+
+func fingerprintPrimary(primary pihole.Client) (string, error) {
+	configResponse, err := primary.GetConfig()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	if err := json.NewEncoder(hash).Encode(configResponse.Config); err != nil {
+		return "", fmt.Errorf("encode config: %w", err)
+	}
+
+	groups, err := primary.GetGroups()
+	if err != nil {
+		return "", err
+	}
+	var groupsModified int64
+	for _, group := range groups {
+		groupsModified = max(groupsModified, group.DateModified)
+	}
+	fmt.Fprintf(hash, "groups:%d:%d\n", len(groups), groupsModified)
+
+	lists, err := primary.GetLists()
+	if err != nil {
+		return "", err
+	}
+	var listsModified int64
+	for _, list := range lists {
+		listsModified = max(listsModified, list.DateModified)
+	}
+	fmt.Fprintf(hash, "lists:%d:%d\n", len(lists), listsModified)
+
+	domains, err := primary.GetDomains()
+	if err != nil {
+		return "", err
+	}
+	var domainsModified int64
+	for _, domain := range domains {
+		domainsModified = max(domainsModified, domain.DateModified)
+	}
+	fmt.Fprintf(hash, "domains:%d:%d\n", len(domains), domainsModified)
+
+	clients, err := primary.GetClients()
+	if err != nil {
+		return "", err
+	}
+	var clientsModified int64
+	for _, client := range clients {
+		clientsModified = max(clientsModified, client.DateModified)
+	}
+	fmt.Fprintf(hash, "clients:%d:%d\n", len(clients), clientsModified)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
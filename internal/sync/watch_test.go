@@ -0,0 +1,61 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+
+	piholemock "github.com/lovelaze/nebula-sync/internal/mocks/pihole"
+	"github.com/lovelaze/nebula-sync/internal/pihole/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprinter_Fingerprint(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	fingerprinter := NewFingerprinter(primary)
+
+	primary.EXPECT().PostAuth().Once().Return(nil)
+	primary.EXPECT().GetConfig().Return(emptyConfigResponse(), nil)
+	primary.EXPECT().GetLists().Return([]model.List{}, nil)
+	primary.EXPECT().GetDomains().Return([]model.Domain{}, nil)
+	primary.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	primary.EXPECT().GetGroups().Once().Return([]model.Group{{Name: "Default", DateModified: 1}}, nil)
+	first, err := fingerprinter.Fingerprint()
+	require.NoError(t, err)
+
+	primary.EXPECT().GetGroups().Once().Return([]model.Group{{Name: "Default", DateModified: 1}}, nil)
+	same, err := fingerprinter.Fingerprint()
+	require.NoError(t, err)
+	assert.Equal(t, first, same)
+
+	primary.EXPECT().GetGroups().Once().Return([]model.Group{{Name: "Default", DateModified: 2}}, nil)
+	changed, err := fingerprinter.Fingerprint()
+	require.NoError(t, err)
+	assert.NotEqual(t, first, changed)
+
+	primary.EXPECT().DeleteSession().Once().Return(nil)
+	fingerprinter.Close()
+}
+
+func TestFingerprinter_Fingerprint_expiredSession(t *testing.T) {
+	primary := piholemock.NewClient(t)
+	primary.EXPECT().String().Maybe().Return("http://primary")
+	fingerprinter := NewFingerprinter(primary)
+
+	primary.EXPECT().PostAuth().Times(2).Return(nil)
+	primary.EXPECT().GetConfig().Once().Return(emptyConfigResponse(), nil)
+	primary.EXPECT().GetGroups().Return([]model.Group{}, nil)
+	primary.EXPECT().GetLists().Return([]model.List{}, nil)
+	primary.EXPECT().GetDomains().Return([]model.Domain{}, nil)
+	primary.EXPECT().GetClients().Return([]model.Client{}, nil)
+
+	_, err := fingerprinter.Fingerprint()
+	require.NoError(t, err)
+
+	// the reused session was rejected, so the fingerprinter authenticates again
+	primary.EXPECT().GetConfig().Once().Return(nil, errors.New("401 unauthorized"))
+	primary.EXPECT().GetConfig().Once().Return(emptyConfigResponse(), nil)
+	_, err = fingerprinter.Fingerprint()
+	require.NoError(t, err)
+}
@@ -3,30 +3,46 @@ package webhook
 import (
 	"crypto/tls"
 	"fmt"
+	"maps"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/sync"
+	"github.com/lovelaze/nebula-sync/internal/sync/retry"
 	"github.com/lovelaze/nebula-sync/version"
 	"github.com/rs/zerolog/log"
 )
 
 type WebhookClient interface {
 	Success() error
-	Failure() error
+	// Failure invokes the failure webhook for a sync that failed with err.
+	Failure(err error) error
+	// Drift invokes the drift webhook for the reports of a drift detection that found drift.
+	Drift(reports []sync.Report) error
+	// Quarantine invokes the quarantine webhook for a replica the circuit breaker stopped syncing.
+	Quarantine(replica string) error
+	// Recovery invokes the recovery webhook for a quarantined replica that synced again.
+	Recovery(replica string) error
 }
 
 type webhookClient struct {
-	successConfig config.WebhookEventSetting
-	failureConfig config.WebhookEventSetting
-	client        *http.Client
+	successConfig    config.WebhookEventSetting
+	failureConfig    config.WebhookEventSetting
+	driftConfig      config.WebhookEventSetting
+	quarantineConfig config.WebhookEventSetting
+	recoveryConfig   config.WebhookEventSetting
+	client           *http.Client
 }
 
 func NewWebhookClient(c *config.WebhookSettings) WebhookClient {
 	return &webhookClient{
-		successConfig: c.Success,
-		failureConfig: c.Failure,
+		successConfig:    c.Success,
+		failureConfig:    c.Failure,
+		driftConfig:      c.Drift,
+		quarantineConfig: c.Quarantine,
+		recoveryConfig:   c.Recovery,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 			Transport: &http.Transport{
@@ -39,8 +55,80 @@ func (webhookClient *webhookClient) Success() error {
 	return invokeWebhook(webhookClient.client, webhookClient.successConfig)
 }
 
-func (webhookClient *webhookClient) Failure() error {
-	return invokeWebhook(webhookClient.client, webhookClient.failureConfig)
+// ErrorClassHeader is the failure webhook header holding the class of the error, see retry.Classify.
+const ErrorClassHeader = "X-Nebula-Sync-Error-Class"
+
+// errorClassPlaceholder is replaced by the class of the error in the failure webhook body.
+const errorClassPlaceholder = "{{error_class}}"
+
+func (webhookClient *webhookClient) Failure(err error) error {
+	class := retry.Classify(err).String()
+
+	settings := webhookClient.failureConfig
+	settings.Body = strings.ReplaceAll(settings.Body, errorClassPlaceholder, class)
+
+	return invokeWebhook(webhookClient.client, withHeader(settings, ErrorClassHeader, class))
+}
+
+// DriftReplicasHeader is the drift webhook header holding the urls of the drifted replicas, separated by commas.
+const DriftReplicasHeader = "X-Nebula-Sync-Drift-Replicas"
+
+const (
+	// driftReplicasPlaceholder is replaced by the urls of the drifted replicas in the drift webhook body.
+	driftReplicasPlaceholder = "{{replicas}}"
+	// driftPlaceholder is replaced by the drifted keys of each replica in the drift webhook body, e.g.
+	// "http://ph2.lan: dns.upstreams,dns.hosts; http://ph3.lan: adlists".
+	driftPlaceholder = "{{drift}}"
+)
+
+func (webhookClient *webhookClient) Drift(reports []sync.Report) error {
+	var replicas, drift []string
+	for _, report := range reports {
+		if len(report.Divergences) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(report.Divergences))
+		for _, divergence := range report.Divergences {
+			keys = append(keys, divergence.Key)
+		}
+		replicas = append(replicas, report.Replica)
+		drift = append(drift, report.Replica+": "+strings.Join(keys, ","))
+	}
+
+	settings := webhookClient.driftConfig
+	settings.Body = strings.ReplaceAll(settings.Body, driftReplicasPlaceholder, strings.Join(replicas, ","))
+	settings.Body = strings.ReplaceAll(settings.Body, driftPlaceholder, strings.Join(drift, "; "))
+
+	return invokeWebhook(webhookClient.client, withHeader(settings, DriftReplicasHeader, strings.Join(replicas, ",")))
+}
+
+// ReplicaHeader is the quarantine and recovery webhook header holding the url of the replica.
+const ReplicaHeader = "X-Nebula-Sync-Replica"
+
+// replicaPlaceholder is replaced by the url of the replica in the quarantine and recovery webhook bodies.
+const replicaPlaceholder = "{{replica}}"
+
+func (webhookClient *webhookClient) Quarantine(replica string) error {
+	return invokeWebhook(webhookClient.client, withReplica(webhookClient.quarantineConfig, replica))
+}
+
+func (webhookClient *webhookClient) Recovery(replica string) error {
+	return invokeWebhook(webhookClient.client, withReplica(webhookClient.recoveryConfig, replica))
+}
+
+func withReplica(settings config.WebhookEventSetting, replica string) config.WebhookEventSetting {
+	settings.Body = strings.ReplaceAll(settings.Body, replicaPlaceholder, replica)
+	return withHeader(settings, ReplicaHeader, replica)
+}
+
+// withHeader returns a copy of the settings with the header set, leaving the original headers unchanged.
+func withHeader(settings config.WebhookEventSetting, key, value string) config.WebhookEventSetting {
+	settings.Headers = maps.Clone(settings.Headers)
+	if settings.Headers == nil {
+		settings.Headers = make(map[string]string)
+	}
+	settings.Headers[key] = value
+	return settings
 }
 
 func invokeWebhook(client *http.Client, settings config.WebhookEventSetting) error {
@@ -1,11 +1,13 @@
 package webhook
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/lovelaze/nebula-sync/internal/config"
+	"github.com/lovelaze/nebula-sync/internal/sync"
 	"github.com/lovelaze/nebula-sync/version"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -62,18 +64,96 @@ func TestWebhookClient(t *testing.T) {
 			Failure: config.WebhookEventSetting{
 				Url:     ts.URL,
 				Method:  "PUT",
-				Body:    "failure-body",
+				Body:    "failure-body {{error_class}}",
 				Headers: map[string]string{"X-Test": "failure"},
 			},
 			Client: config.WebhookClient{},
 		}
 
 		client := NewWebhookClient(settings)
-		err := client.Failure()
+		err := client.Failure(errors.New("connection refused"))
 		require.NoError(t, err)
 
-		assert.Equal(t, "failure-body", receivedBody)
+		assert.Equal(t, "failure-body transient", receivedBody)
 		assert.Equal(t, "failure", receivedHeaders.Get("X-Test"))
+		assert.Equal(t, "transient", receivedHeaders.Get(ErrorClassHeader))
+		assert.Equal(t, map[string]string{"X-Test": "failure"}, settings.Failure.Headers, "settings are not modified")
+	})
+
+	t.Run("drift webhook uses drift configuration", func(t *testing.T) {
+		var receivedMethod string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			receivedMethod = r.Method
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer ts.Close()
+
+		settings := &config.WebhookSettings{
+			Drift: config.WebhookEventSetting{
+				Url:    ts.URL,
+				Method: "PATCH",
+			},
+			Client: config.WebhookClient{},
+		}
+
+		client := NewWebhookClient(settings)
+		err := client.Drift(nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, "PATCH", receivedMethod)
+	})
+
+	t.Run("drift webhook reports the drifted replicas and keys", func(t *testing.T) {
+		var receivedBody, receivedReplicas string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			buf := make([]byte, 1024)
+			n, _ := r.Body.Read(buf)
+			receivedBody = string(buf[:n])
+			receivedReplicas = r.Header.Get(DriftReplicasHeader)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer ts.Close()
+
+		settings := &config.WebhookSettings{
+			Drift:  config.WebhookEventSetting{Url: ts.URL, Method: "POST", Body: "drifted {{replicas}} ({{drift}})"},
+			Client: config.WebhookClient{},
+		}
+
+		client := NewWebhookClient(settings)
+		err := client.Drift([]sync.Report{
+			{Replica: "http://ph2.lan", Divergences: []sync.Divergence{{Key: "dns.upstreams"}, {Key: "dns.hosts"}}},
+			{Replica: "http://ph3.lan"},
+			{Replica: "http://ph4.lan", Divergences: []sync.Divergence{{Key: "adlists"}}},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "drifted http://ph2.lan,http://ph4.lan (http://ph2.lan: dns.upstreams,dns.hosts; http://ph4.lan: adlists)", receivedBody)
+		assert.Equal(t, "http://ph2.lan,http://ph4.lan", receivedReplicas)
+	})
+
+	t.Run("quarantine and recovery webhooks name the replica", func(t *testing.T) {
+		var receivedBodies, receivedReplicas []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			buf := make([]byte, 1024)
+			n, _ := r.Body.Read(buf)
+			receivedBodies = append(receivedBodies, string(buf[:n]))
+			receivedReplicas = append(receivedReplicas, r.Header.Get(ReplicaHeader))
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer ts.Close()
+
+		settings := &config.WebhookSettings{
+			Quarantine: config.WebhookEventSetting{Url: ts.URL, Method: "POST", Body: "quarantined {{replica}}"},
+			Recovery:   config.WebhookEventSetting{Url: ts.URL, Method: "POST", Body: "recovered {{replica}}"},
+			Client:     config.WebhookClient{},
+		}
+
+		client := NewWebhookClient(settings)
+		require.NoError(t, client.Quarantine("http://ph2.lan"))
+		require.NoError(t, client.Recovery("http://ph2.lan"))
+
+		assert.Equal(t, []string{"quarantined http://ph2.lan", "recovered http://ph2.lan"}, receivedBodies)
+		assert.Equal(t, []string{"http://ph2.lan", "http://ph2.lan"}, receivedReplicas)
 	})
 
 	t.Run("empty url skips webhook", func(t *testing.T) {